@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"node/constants"
+)
+
+const (
+	commandKey     = "key"
+	commandResolve = "resolve"
+)
+
+type configuration struct {
+	command    string
+	key        string
+	publicKey  string
+	output     string
+	transcript string
+}
+
+const invalidParamExitCode = 64
+
+func parseFlags() configuration {
+	config := configuration{}
+
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(invalidParamExitCode)
+	}
+	config.command = os.Args[1]
+
+	flags := flag.NewFlagSet(config.command, flag.ExitOnError)
+	flags.StringVar(&config.key, "key", constants.ArbiterKeyFilePath, "Path of the arbiter's private key")
+
+	switch config.command {
+	case commandKey:
+		flags.StringVar(&config.publicKey, "public", constants.ArbiterPublicKeyFilePath, "Path the public key is exported to. Listeners and requesters are configured with this file")
+	case commandResolve:
+		flags.StringVar(&config.output, "out", "", "Path the resolved transcript is written to. Defaults to the transcript's path with '.resolved.json' instead of '.json'")
+	default:
+		fmt.Printf("Unknown command '%s'\n", config.command)
+		printUsage()
+		os.Exit(invalidParamExitCode)
+	}
+
+	_ = flags.Parse(os.Args[2:])
+
+	if config.command == commandResolve {
+		if flags.NArg() != 1 {
+			fmt.Printf("Expected one transcript, got %d\n", flags.NArg())
+			flags.PrintDefaults()
+			os.Exit(invalidParamExitCode)
+		}
+
+		config.transcript = flags.Arg(0)
+	}
+
+	return config
+}
+
+func printUsage() {
+	fmt.Printf("Usage:\n")
+	fmt.Printf("\t%s %s [-key path] [-public path]\tCreates the key if it does not exist and exports the public key\n", os.Args[0], commandKey)
+	fmt.Printf("\t%s %s [-key path] [-out path] transcript.json\tReleases the escrowed decryption data of a transcript\n", os.Args[0], commandResolve)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"node/arbiter"
+	"node/constants"
+	"node/revolori"
+	"node/storage"
+)
+
+// invalidEscrowExitCode is returned if the listener's escrow is invalid. The resolution is stored anyway since it
+// proves that the listener cheated.
+const invalidEscrowExitCode = 1
+
+func main() {
+	config := parseFlags()
+
+	key, err := arbiter.LoadOrCreateKey(config.key)
+	if err != nil {
+		log.Fatalf("arbiter/main - %s\n", err)
+	}
+
+	if config.command == commandKey {
+		exportKey(config, key.PublicKey)
+		return
+	}
+
+	fmt.Printf("Loading Revolori's public key...\n\n")
+	revoloriPublicKey, err := revolori.GetPublicKey()
+	if err != nil {
+		log.Fatalf("arbiter/main - %s\n", err)
+	}
+
+	metadata, _, err := storage.LoadProofMetadata(config.transcript)
+	if err != nil {
+		log.Fatalf("arbiter/main - %s\n", err)
+	} else if metadata.Role != constants.ProofRoleTranscript {
+		log.Fatalf("arbiter/main - '%s' is not a transcript but has role '%s'\n", config.transcript, metadata.Role)
+	}
+
+	signedMessages, _, _, parameters, err := storage.LoadExchangeProof(config.transcript)
+	if err != nil {
+		log.Fatalf("arbiter/main - %s\n", err)
+	}
+
+	signedResolution, resolution, err := arbiter.Resolve(signedMessages, parameters, &revoloriPublicKey, key)
+	if err != nil {
+		log.Fatalf("arbiter/main - Could not resolve the transcript: %s\n", err)
+	}
+
+	output := config.output
+	if output == "" {
+		output = strings.TrimSuffix(config.transcript, ".json") + ".resolved.json"
+	}
+
+	err = storage.ResolveTranscript(config.transcript, output, signedResolution)
+	if err != nil {
+		log.Fatalf("arbiter/main - %s\n", err)
+	}
+
+	if resolution.Verdict != constants.ArbiterVerdictReleased {
+		fmt.Printf("The escrow of '%s' is invalid: %s\n", metadata.PeerSSOID, resolution.Reason)
+		fmt.Printf("The resolution was written to %s\n", output)
+		os.Exit(invalidEscrowExitCode)
+	}
+
+	fmt.Printf("Released the decryption data of the exchange with '%s' to %s\n", metadata.PeerSSOID, output)
+}
+
+func exportKey(config configuration, publicKey rsa.PublicKey) {
+	err := arbiter.WritePublicKey(config.publicKey, &publicKey)
+	if err != nil {
+		log.Fatalf("arbiter/exportKey - %s\n", err)
+	}
+
+	keyID, err := arbiter.KeyID(&publicKey)
+	if err != nil {
+		log.Fatalf("arbiter/exportKey - %s\n", err)
+	}
+
+	fmt.Printf("Exported the public key to %s\n", config.publicKey)
+	fmt.Printf("Key ID: %s\n", keyID)
+}
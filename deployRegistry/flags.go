@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"node/constants"
+)
+
+type configuration struct {
+	ethereumKey   string
+	gethAddress   string
+	confirmations int
+	mine          bool
+}
+
+const invalidParamExitCode = 64
+
+func parseFlags() configuration {
+	config := configuration{}
+
+	flag.StringVar(&config.gethAddress, "gethAddress", constants.GethAddress, "JSON-RPC endpoint of the geth node the registry is deployed to. Defaults to '"+constants.GethAddress+"'")
+	flag.StringVar(&config.ethereumKey, "ethereumKey", constants.EthereumKeyFilePath, "Path of the key that signs the deployment. It is created if it does not exist")
+	flag.IntVar(&config.confirmations, "confirmations", constants.DefaultConfirmations, "Number of blocks the deployment needs before the address is printed. Defaults to 1")
+	flag.BoolVar(&config.mine, "mine", true, "Let the local geth node mine while the deployment needs funds or confirmations")
+	flag.Parse()
+
+	if config.confirmations < 1 {
+		fmt.Println("At least 1 confirmation is required")
+		os.Exit(invalidParamExitCode)
+	}
+
+	return config
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"node/storage"
+)
+
+func main() {
+	config := parseFlags()
+	storage.SetGethAddress(config.gethAddress)
+
+	address, err := storage.DeployRegistry(storage.StoreOptions{
+		EthereumKeyPath: config.ethereumKey,
+		Confirmations:   config.confirmations,
+		Mine:            config.mine,
+	})
+	if err != nil {
+		log.Fatalf("deployRegistry/main - %s\n", err)
+	}
+
+	fmt.Println(address.Hex())
+}
@@ -1,44 +1,100 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"sync/atomic"
 
+	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/network"
 	"node/constants"
+	"node/exchange"
 	"node/logging"
 	"node/p2p"
+	"node/revolori"
 )
 
 var connectionCount int64 = 0 //nolint:revive
-var ownSignedIdentityCard p2p.SignedMessage
 
 // Adapted from https://github.com/libp2p/go-libp2p/tree/v0.16.0/examples/chat-with-mdns
-func createNode(port int) {
+func createNode(config *configuration) {
 	var err error
 
-	ownSignedIdentityCard, err = p2p.LoadSignedIdentityCard(&globalPrivateKey)
+	hostKey, err := p2p.LoadOrCreateHostKey(config.hostKey, config.hostKeyType)
 	if err != nil {
 		log.Error.Fatalln(err)
 	}
 
-	h, err := p2p.MakeHost(port)
+	h, err := p2p.MakeHost(config.port, hostKey)
 	if err != nil {
 		log.Error.Fatalln(err)
 	}
 
+	listenerOptions.RevoloriPublicKey = revoloriPublicKey
+	listenerOptions.PrivateKey = globalPrivateKey
+	listenerOptions.IdentityCards, err = p2p.LoadSignedIdentityCards(&globalPrivateKey, h.ID())
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	exchangeListener = exchange.NewListener(listenerOptions)
+
 	h.SetStreamHandler(constants.P2PProtocolName, handleListenerStream)
+	h.SetStreamHandler(constants.P2PLegacyProtocolName, handleListenerStream)
 
-	_ = p2p.InitMDNS(h)
+	if config.discovery != constants.DiscoveryDHT {
+		_ = p2p.InitMDNS(h)
+	}
+
+	var dhtDiscovery *p2p.DHTDiscovery
+	if config.discovery != constants.DiscoveryMDNS {
+		dhtDiscovery = advertiseOnDHT(h, config)
+	}
+
+	if deploymentKey, ok := p2p.DeploymentKeyFromEnv(); ok {
+		advertiseToken(h, config, dhtDiscovery, deploymentKey)
+	}
 
 	select {} // wait here
 }
 
+// advertiseOnDHT joins the DHT and advertises the listener under the rendezvous namespace.
+func advertiseOnDHT(h host.Host, config *configuration) *p2p.DHTDiscovery {
+	bootstrapPeers, err := p2p.ParseBootstrapPeers(config.bootstrap)
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+
+	ctx := context.Background()
+	dhtDiscovery, err := p2p.NewDHTDiscovery(ctx, h, bootstrapPeers, config.rendezvous)
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+
+	dhtDiscovery.Advertise(ctx)
+	log.Info.Printf("Advertising on the DHT under '%s'\n", config.rendezvous)
+
+	return dhtDiscovery
+}
+
+// advertiseToken publishes the blinded SSOID of the listener so that requesters do not have to contact every peer.
+func advertiseToken(h host.Host, config *configuration, dhtDiscovery *p2p.DHTDiscovery, deploymentKey []byte) {
+	ssoid, err := revolori.LoadOwnIdentityCard(&globalPrivateKey, &revoloriPublicKey)
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+
+	err = p2p.AdvertiseToken(context.Background(), h, config.discovery, dhtDiscovery, p2p.RendezvousToken(deploymentKey, ssoid))
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+
+	log.Info.Println("Publishing the rendezvous token")
+}
+
 func handleListenerStream(s network.Stream) {
 	current := atomic.AddInt64(&connectionCount, 1)
 
 	// Create a buffer stream for non-blocking read and write.
-	rw := bufio.NewReadWriter(bufio.NewReader(s), bufio.NewWriter(s))
+	rw := p2p.NewReadWriter(s, string(s.Protocol()))
 
 	go streamHandler(rw, current)
 }
@@ -3,21 +3,119 @@ package main
 import (
 	"flag"
 	"log"
+	"time"
+
+	"node/consent"
+	"node/constants"
+	"node/datum"
+	nP "node/nonRepudiation"
+	"node/storage"
 )
 
-func parseFlags() (int, bool) {
-	var port int
-	var printName bool
+type configuration struct {
+	port           int
+	printName      bool
+	datumProvider  string
+	datumSource    string
+	datumTable     string
+	policyFile     string
+	consentMode    string
+	consentAddress string
+	consentTimeout time.Duration
+	discovery      string
+	bootstrap      string
+	rendezvous     string
+	hostKey        string
+	hostKeyType    string
+	stores         string
+	sqlitePath     string
+	gethAddress    string
+	ethereumKey    string
+	confirmations  int
+	mine           bool
+	batchSize      int
+	batchInterval  time.Duration
+	batchDirectory string
+	registry       string
+	arbiterKey     string
+	rounds         nP.Rounds
+}
+
+func parseFlags() configuration {
+	config := configuration{}
 
-	flag.BoolVar(&printName, "whoAmI", true, "Print username associated with this listener")
-	flag.IntVar(&port, "port", 40000, "Port to listen to. Defaults to 40000")
+	var distribution string
+	var minRounds, maxRounds int
+	var cheatingProbability float64
+
+	flag.BoolVar(&config.printName, "whoAmI", true, "Print username associated with this listener")
+	flag.IntVar(&config.port, "port", 40000, "Port to listen to. Defaults to 40000")
 	flag.BoolVar(&cpuProf, "cpuProf", false, "Enable CPU profiling")
 	flag.BoolVar(&memProf, "memProf", false, "Enable memory profiling")
+	flag.StringVar(&config.datumProvider, "datumProvider", datum.ProviderEcho, "Where requested data is loaded from: 'echo', 'directory', 'sqlite' or 'http'. Defaults to 'echo', which only returns the name of the requested datum")
+	flag.StringVar(&config.datumSource, "datumSource", "", "The directory, SQLite database file or callback URL of the datum provider")
+	flag.StringVar(&config.datumTable, "datumTable", "data", "The table used by the SQLite datum provider. Defaults to 'data'")
+	flag.StringVar(&config.policyFile, "policy", "", "Path to the access policy file. Changes to the file are applied without a restart. If not set, every verified requester is allowed")
+	flag.StringVar(&config.consentMode, "consent", consent.ModeNone, "Ask the data owner before serving a request: 'none', 'cli', 'http' or 'webhook'. Fake chatter is never held, so an observer can tell real exchanges apart by their duration. Defaults to 'none'")
+	flag.StringVar(&config.consentAddress, "consentAddress", "127.0.0.1:40080", "Listen address of the 'http' consent endpoint or URL of the 'webhook'. Defaults to '127.0.0.1:40080'")
+	flag.DurationVar(&config.consentTimeout, "consentTimeout", 2*time.Minute, "How long to wait for the data owner's consent before denying the request. Defaults to 2m")
+	flag.StringVar(&config.discovery, "discovery", constants.DiscoveryMDNS, "How requesters find this listener: 'mdns', 'dht' or 'both'. Defaults to 'mdns'")
+	flag.StringVar(&config.bootstrap, "bootstrap", "", "Comma-separated multiaddrs of the DHT bootstrap peers. Without bootstrap peers, the listener only serves as a bootstrap peer")
+	flag.StringVar(&config.rendezvous, "rendezvous", constants.DefaultRendezvousNamespace, "DHT namespace the listener advertises itself under. Every deployment should use its own namespace")
+	flag.StringVar(&config.hostKey, "hostKey", constants.HostKeyFilePath, "Path of the libp2p host key, which determines the peer ID. It is created if it does not exist")
+	flag.StringVar(&config.hostKeyType, "hostKeyType", constants.HostKeyRSA, "Type of a newly created host key: 'rsa' or 'ed25519'. Defaults to 'rsa'")
+	flag.StringVar(&config.stores, "stores", storage.StoreSQLite+","+storage.StoreBlockchain, "Comma-separated list of stores the usage logs are written to: 'sqlite', 'blockchain', 'blockchain-batch' and/or 'registry'. Defaults to 'sqlite,blockchain'")
+	flag.StringVar(&config.sqlitePath, "sqlitePath", constants.SQLitePath, "Path of the SQLite database the usage logs are written to. Defaults to 'database.db' in the working directory")
+	flag.StringVar(&config.gethAddress, "gethAddress", constants.GethAddress, "JSON-RPC endpoint of the geth node the usage logs are sent to and read from. Defaults to '"+constants.GethAddress+"'")
+	flag.StringVar(&config.ethereumKey, "ethereumKey", constants.EthereumKeyFilePath, "Path of the key that signs the transactions of the usage logs. It is created if it does not exist")
+	flag.IntVar(&config.confirmations, "confirmations", constants.DefaultConfirmations, "Number of blocks a transaction needs before a usage log counts as stored on the blockchain. Defaults to 1")
+	flag.BoolVar(&config.mine, "mine", true, "Let the local geth node mine while the usage log transactions need funds or confirmations")
+	flag.IntVar(&config.batchSize, "batchSize", constants.DefaultBatchSize, "Number of usage logs the 'blockchain-batch' store anchors in one transaction. Defaults to 32")
+	flag.DurationVar(&config.batchInterval, "batchInterval", constants.DefaultBatchInterval, "Longest time a usage log waits in the 'blockchain-batch' store before its batch is anchored. Defaults to 30s")
+	flag.StringVar(&config.batchDirectory, "batchDirectory", constants.BatchDirectory, "Directory of the usage logs and inclusion proofs of the 'blockchain-batch' store. Defaults to './batches/'")
+	flag.StringVar(&config.registry, "registry", "", "Address of the registry contract used by the 'registry' store. It is deployed with kovacs-deploy-registry")
+	flag.StringVar(&distribution, "roundDistribution", constants.RoundDistributionUniform, "Distribution the number of fake rounds of the non-repudiation protocol is drawn from: 'uniform' or 'geometric'. Defaults to 'uniform'")
+	flag.IntVar(&minRounds, "minRounds", constants.DefaultMinimumRepetitions, "Minimum number of fake rounds. Defaults to 25")
+	flag.IntVar(&maxRounds, "maxRounds", constants.DefaultMaxRepetitions, "Maximum number of fake rounds. The uniform distribution only uses as many as the cheating probability needs. Defaults to 124")
+	flag.Float64Var(&cheatingProbability, "cheatingProbability", constants.DefaultCheatingProbability, "Highest chance of a requester to get the datum without acknowledging it. Lower values need more rounds. Defaults to 0.01")
+	flag.StringVar(&config.arbiterKey, "arbiterKey", "", "Path of the arbiter's public key. If set, the decryption data is escrowed with the arbiter when a requester asks for it")
 	flag.Parse()
 
-	if port < 1024 {
+	if config.port < 1024 {
 		log.Fatalf("listener/main - Port provided is too small (<1024)\n")
 	}
 
-	return port, printName
+	if config.datumProvider != datum.ProviderEcho && config.datumSource == "" {
+		log.Fatalf("listener/main - The datum provider '%s' requires -datumSource\n", config.datumProvider)
+	}
+
+	if config.consentTimeout <= 0 || config.consentTimeout > constants.MaxConsentWaitTime {
+		log.Fatalf("listener/main - The consent timeout must be between 0 and %s\n", constants.MaxConsentWaitTime)
+	}
+
+	if config.discovery != constants.DiscoveryMDNS && config.discovery != constants.DiscoveryDHT && config.discovery != constants.DiscoveryBoth {
+		log.Fatalf("listener/main - Unknown discovery mechanism '%s'\n", config.discovery)
+	}
+
+	if config.hostKeyType != constants.HostKeyRSA && config.hostKeyType != constants.HostKeyEd25519 {
+		log.Fatalf("listener/main - Unknown host key type '%s'\n", config.hostKeyType)
+	}
+
+	if config.confirmations < 1 {
+		log.Fatalf("listener/main - At least 1 confirmation is required\n")
+	}
+
+	if config.batchSize < 1 || config.batchInterval <= 0 {
+		log.Fatalf("listener/main - The batch size and interval must be positive\n")
+	}
+
+	var err error
+	config.rounds, err = nP.NewRounds(distribution, minRounds, maxRounds, cheatingProbability)
+	if err != nil {
+		log.Fatalf("listener/main - Invalid rounds: %s\n", err)
+	} else if config.rounds.Maximum != maxRounds {
+		log.Printf("listener/main - The cheating probability of %g only needs %d to %d fake rounds, the maximum of %d is not used\n", cheatingProbability, config.rounds.Minimum, config.rounds.Maximum, maxRounds)
+	}
+
+	return config
 }
@@ -5,19 +5,29 @@ import (
 	"fmt"
 	"log"
 
+	"node/arbiter"
+	"node/consent"
+	"node/constants"
+	"node/datum"
+	"node/exchange"
 	ownLog "node/logging"
 	"node/password"
+	"node/policy"
 	"node/revolori"
+	"node/storage"
 )
 
 var revoloriPublicKey rsa.PublicKey
 var globalPrivateKey rsa.PrivateKey
+var listenerOptions exchange.ListenerOptions
+var exchangeListener *exchange.Listener
 var cpuProf bool
 var memProf bool
 
 func main() {
 	var err error
-	port, printName := parseFlags()
+	config := parseFlags()
+	storage.SetGethAddress(config.gethAddress)
 
 	ownLog.Info.Println("\n\t===== Starting node =====")
 	revoloriPublicKey, err = revolori.GetPublicKey()
@@ -31,7 +41,59 @@ func main() {
 		ownLog.Error.Fatalln(err)
 	}
 
-	if printName {
+	listenerOptions.DatumProvider, err = datum.NewDatumProvider(config.datumProvider, config.datumSource, config.datumTable)
+	if err != nil {
+		log.Fatalf("listener/main - Could not create the datum provider: %v\n", err)
+	}
+
+	if config.policyFile != "" {
+		listenerOptions.AccessPolicy, err = policy.NewEngine(config.policyFile)
+		if err != nil {
+			log.Fatalf("listener/main - Could not load the access policy: %v\n", err)
+		}
+	}
+
+	listenerOptions.ProofPassphrase, err = storage.ProofPassphrase()
+	if err != nil {
+		log.Fatalf("listener/main - %v\n", err)
+	}
+
+	listenerOptions.UsageLogStores, err = storage.NewUsageLogStores(config.stores, storage.StoreOptions{
+		SQLitePath:      config.sqlitePath,
+		EthereumKeyPath: config.ethereumKey,
+		Confirmations:   config.confirmations,
+		Mine:            config.mine,
+		BatchSize:       config.batchSize,
+		BatchInterval:   config.batchInterval,
+		BatchDirectory:  config.batchDirectory,
+		RegistryAddress: config.registry,
+		IndexPath:       constants.BlockchainIndexPath,
+	})
+	if err != nil {
+		log.Fatalf("listener/main - Could not open the usage log stores: %v\n", err)
+	}
+
+	// The listener runs until it is killed, so the index follows the chain for as long
+	err = storage.FollowIndexes(listenerOptions.UsageLogStores, constants.IndexFollowInterval, nil)
+	if err != nil {
+		log.Fatalf("listener/main - Could not follow the blockchain: %v\n", err)
+	}
+
+	listenerOptions.ConsentAsker, err = consent.NewAsker(config.consentMode, config.consentAddress)
+	if err != nil {
+		log.Fatalf("listener/main - Could not set up consent mode: %v\n", err)
+	}
+	listenerOptions.ConsentTimeout = config.consentTimeout
+	listenerOptions.Rounds = config.rounds
+
+	if config.arbiterKey != "" {
+		listenerOptions.ArbiterPublicKey, err = arbiter.LoadPublicKey(config.arbiterKey)
+		if err != nil {
+			log.Fatalf("listener/main - Could not load the arbiter's public key: %v\n", err)
+		}
+	}
+
+	if config.printName {
 		name, err := revolori.LoadOwnIdentityCard(&globalPrivateKey, &revoloriPublicKey)
 		if err != nil {
 			ownLog.Info.Fatalf("Could not get own name: %s", err)
@@ -43,5 +105,5 @@ func main() {
 
 	// Create password requirements
 	passwordRequirement.Init()
-	createNode(port)
+	createNode(&config)
 }
@@ -3,12 +3,16 @@ package main
 import (
 	"flag"
 	"log"
+
+	"node/constants"
 )
 
 type configuration struct {
 	stepSize     int
 	target       int
 	isBigNetwork bool
+	sqlitePath   string
+	gethAddress  string
 }
 
 func parseFlags() configuration {
@@ -17,6 +21,8 @@ func parseFlags() configuration {
 	flag.IntVar(&config.stepSize, "stepSize", 25, "Dictates after how many logs the database and blockchain sizes are measured. Defaults to 25")
 	flag.IntVar(&config.target, "target", 2000, "The number of logs to create. Must be a multiple of stepSize. Defaults to 2000")
 	flag.BoolVar(&config.isBigNetwork, "bigNetwork", false, "Enable for bigger networks as otherwise the network can crash due to too many blockchain updates")
+	flag.StringVar(&config.sqlitePath, "sqlitePath", constants.SQLitePath, "Path of the SQLite database the logs are written to and that is measured. Defaults to 'database.db' in the working directory")
+	flag.StringVar(&config.gethAddress, "gethAddress", constants.GethAddress, "JSON-RPC endpoint of the geth node the logs are written to and that is measured. Defaults to '"+constants.GethAddress+"'")
 	flag.Parse()
 
 	if config.stepSize < 1 {
@@ -8,14 +8,23 @@ import (
 	"time"
 
 	nP "node/nonRepudiation"
+	"node/storage"
 )
 
 const (
 	sleepDurationAfterExport = 3
+	// The stored exchanges are only measured, so their passphrase does not have to be secret.
+	measurementPassphrase = "measureStorage"
+)
+
+var (
+	sqliteStore *storage.SQLiteStore
+	gethStore   *storage.GethStore
 )
 
 func main() {
 	config := parseFlags()
+	storage.SetGethAddress(config.gethAddress)
 
 	// Private key that will be used as if it belongs to the requester's identity
 	idCardPrivateKey, err := nP.GenerateRSAPrivateKey()
@@ -23,6 +32,16 @@ func main() {
 		log.Fatalf("could not create identity card: %s\n", err)
 	}
 
+	sqliteStore, err = storage.NewSQLiteStore(config.sqlitePath)
+	if err != nil {
+		log.Fatalf("could not open the SQLite database: %s\n", err)
+	}
+	defer sqliteStore.Close()
+
+	options := storage.DefaultStoreOptions()
+	options.SQLitePath = config.sqlitePath
+	gethStore = storage.NewGethStore(options)
+
 	fmt.Printf("Step size: %d\n", config.stepSize)
 	fmt.Printf("Target: %d\n\n", config.target)
 
@@ -35,7 +54,7 @@ func main() {
 			var sqliteSize int64 = 0
 			fmt.Printf("Measuring")
 			if i != 0 {
-				sqliteSize = measureSQLiteSizeInBytes()
+				sqliteSize = measureSQLiteSizeInBytes(config.sqlitePath)
 				// Sleep to give blockchain time to publish blocks
 				time.Sleep(5 * time.Second)
 			}
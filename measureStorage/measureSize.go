@@ -35,8 +35,8 @@ func measureBlockchainSizeInBytes() int64 {
 }
 
 // measureSQLiteSize returns the size of the SQLite database in bytes
-func measureSQLiteSizeInBytes() int64 {
-	db, err := os.Stat("/build/measureStorage/database.db")
+func measureSQLiteSizeInBytes(path string) int64 {
+	db, err := os.Stat(path)
 	if err != nil {
 		log.Fatalf("Could not open database file: %s\n", err)
 	}
@@ -6,7 +6,7 @@ import (
 	"log"
 	"time"
 
-	ownLog "node/logging"
+	"node/constants"
 	nP "node/nonRepudiation"
 	"node/p2p"
 	"node/storage"
@@ -35,7 +35,11 @@ func writeLog(idCardPrivateKey *rsa.PrivateKey, i int) {
 	}
 
 	// SQLite export
-	err = storage.ExportToSQLite(justification, datum, &idCardPrivateKey.PublicKey, &privateKey.PublicKey)
+	usageLog, err := storage.NewUsageLog(justification, datum, &idCardPrivateKey.PublicKey, &privateKey.PublicKey)
+	if err == nil {
+		err = sqliteStore.Append(usageLog)
+	}
+
 	if err != nil {
 		log.Fatalf("could not export data to sqlite: %s\n", err)
 	}
@@ -46,23 +50,21 @@ func writeLog(idCardPrivateKey *rsa.PrivateKey, i int) {
 		p2p.SignedMessage{},
 		p2p.SignedMessage{},
 	}
-	storage.StoreExchange(messages, &privateKey, &idCardPrivateKey.PublicKey)
+	metadata := storage.ProofMetadata{Role: constants.ProofRoleListener, Parameters: p2p.LegacyParameters()}
+	storage.StoreExchange(messages, &privateKey, &idCardPrivateKey.PublicKey, metadata, []byte(measurementPassphrase))
 }
 
 func blockchainExport(justification string, datum string, idCardPublicKey *rsa.PublicKey, publicKey *rsa.PublicKey, done chan bool) {
-	durations, err := storage.ExportToBlockchain(justification, datum, idCardPublicKey, publicKey)
+	// The store logs the durations of the export
+	usageLog, err := storage.NewUsageLog(justification, datum, idCardPublicKey, publicKey)
+	if err == nil {
+		err = gethStore.Append(usageLog)
+	}
+
 	if err != nil {
 		done <- false
 		log.Fatalf("could not export data to blockchain: %s\n", err)
 	}
 
-	ownLog.Info.Printf("Blockchain export summary:\n" +
-		fmt.Sprintf("\tTotal duration: %v", durations.TotalDuration) +
-		fmt.Sprintf("\tDuration of account creation: %v\n", durations.AccountCreation) +
-		fmt.Sprintf("\tDuration of account unlock: %v\n", durations.AccountUnlock) +
-		fmt.Sprintf("\tDuration of mining: %v\n", durations.Mining) +
-		fmt.Sprintf("\tDuration of transaction: %v\n", durations.TransactionDuration),
-	)
-
 	done <- true
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+type configuration struct {
+	outputDirectory string
+	inputs          []string
+}
+
+const invalidParamExitCode = 64
+
+func parseFlags() configuration {
+	config := configuration{}
+
+	flag.StringVar(&config.outputDirectory, "out", "./migrated/", "The directory the migrated proofs are written to. The old proofs are not changed")
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [-out directory] proof.json|directory...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	config.inputs = flag.Args()
+	if len(config.inputs) == 0 {
+		fmt.Println("No proofs were passed")
+		flag.Usage()
+		os.Exit(invalidParamExitCode)
+	}
+
+	return config
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"node/constants"
+	"node/revolori"
+	"node/storage"
+	"node/verification"
+)
+
+const migrationFailedExitCode = 1
+
+var (
+	// The passphrase is only needed for proofs that contain the private key in plaintext.
+	passphrase    []byte
+	passphraseErr error
+)
+
+func main() {
+	config := parseFlags()
+
+	fmt.Printf("Loading Revolori's public key...\n\n")
+	revoloriPublicKey, err := revolori.GetPublicKey()
+	if err != nil {
+		log.Fatalf("migrate/main - %s\n", err)
+	}
+
+	err = os.MkdirAll(config.outputDirectory, constants.ProofDirectoryMode)
+	if err != nil {
+		log.Fatalf("migrate/main - Could not create the output directory: %s\n", err)
+	}
+
+	files, err := collectProofs(config.inputs)
+	if err != nil {
+		log.Fatalf("migrate/main - %s\n", err)
+	}
+
+	migrated, skipped, failed := 0, 0, 0
+	for _, file := range files {
+		output := filepath.Join(config.outputDirectory, filepath.Base(file))
+
+		isMigrated, err := migrate(file, output, &revoloriPublicKey)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("FAILED   %s: %v\n", file, err)
+		case isMigrated:
+			migrated++
+			fmt.Printf("MIGRATED %s => %s\n", file, output)
+		default:
+			skipped++
+			fmt.Printf("SKIPPED  %s: already has version %d\n", file, constants.ProofVersion)
+		}
+	}
+
+	fmt.Printf("\nMigrated: %d, skipped: %d, failed: %d\n", migrated, skipped, failed)
+	if failed > 0 {
+		os.Exit(migrationFailedExitCode)
+	}
+}
+
+// migrate verifies an old proof, rewrites it to output and verifies the result. It returns false if the proof already
+// has the current version.
+func migrate(file string, output string, revoloriPublicKey *rsa.PublicKey) (bool, error) {
+	_, version, err := storage.LoadProofMetadata(file)
+	if err != nil {
+		return false, err
+	} else if version == constants.ProofVersion {
+		return false, nil
+	}
+
+	recorder, ssoid, decrypted, err := verify(file, revoloriPublicKey)
+	if err != nil {
+		return false, fmt.Errorf("the old proof could not be verified: %w", err)
+	}
+
+	role := constants.ProofRoleListener
+	if recorder == constants.MessageTypeRequester {
+		role = constants.ProofRoleRequester
+	}
+
+	_, err = os.Stat(output)
+	if err == nil {
+		return false, fmt.Errorf("'%s' already exists", output)
+	}
+
+	err = storage.MigrateExchange(file, output, role, ssoid, getPassphrase)
+	if err != nil {
+		return false, err
+	}
+
+	// The migrated proof has to prove the same as the old one
+	migratedRecorder, migratedSSOID, migratedDecrypted, err := verify(output, revoloriPublicKey)
+	if err == nil && (migratedRecorder != recorder || migratedSSOID != ssoid || migratedDecrypted != decrypted) {
+		err = errors.New("the result differs from the old proof")
+	}
+
+	if err != nil {
+		_ = os.Remove(output)
+		return false, fmt.Errorf("the migrated proof could not be verified: %w", err)
+	}
+
+	return true, nil
+}
+
+func verify(file string, revoloriPublicKey *rsa.PublicKey) (constants.MessageType, string, string, error) {
+	signedMessages, conversationPublicKey, identityKey, parameters, err := storage.LoadExchangeProof(file)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", err
+	}
+
+	return verification.Exchange(signedMessages, &conversationPublicKey, &identityKey, parameters, revoloriPublicKey)
+}
+
+func getPassphrase() ([]byte, error) {
+	if passphrase == nil && passphraseErr == nil {
+		passphrase, passphraseErr = storage.ProofPassphrase()
+	}
+
+	return passphrase, passphraseErr
+}
+
+// collectProofs returns the passed files and the json files in the passed directories.
+func collectProofs(inputs []string) ([]string, error) {
+	var files []string
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %w", input, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, input)
+			continue
+		}
+
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %w", input, err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+				files = append(files, filepath.Join(input, entry.Name()))
+			}
+		}
+	}
+
+	return files, nil
+}
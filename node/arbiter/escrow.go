@@ -0,0 +1,190 @@
+// Package arbiter implements the optional offline arbiter of the P3 protocol. A requester can ask the listener to
+// escrow the real decryption data with the arbiter. If the listener stops before the decryption data was sent, the
+// requester stores the transcript of the exchange, which the arbiter resolves by releasing the escrowed data.
+//
+// The arbiter is only needed if an exchange stopped early, so it does not have to be online during exchanges.
+package arbiter
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"node/constants"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/storage"
+)
+
+var (
+	// ErrWrongArbiter is returned if an escrow was created for another arbiter.
+	ErrWrongArbiter = errors.New("the escrow was created for another arbiter")
+	// ErrInvalidEscrow is returned if an escrow could not be opened or does not match its commitment. Since the escrow
+	// is signed by the listener, this proves that the listener cheated.
+	ErrInvalidEscrow = errors.New("the escrow is invalid")
+)
+
+// escrowedData is the plaintext of an escrow.
+type escrowedData struct {
+	Data     nP.Data `json:"data"`
+	Blinding []byte  `json:"blinding"`
+}
+
+// KeyID returns the ID that requesters and listeners use to name the arbiter with the public key.
+func KeyID(publicKey *rsa.PublicKey) (string, error) {
+	keyID, err := storage.GeneratePseudonym(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("arbiter/KeyID - %w", err)
+	}
+
+	return keyID, nil
+}
+
+// RequestHash returns the hash of the signed request that an escrow is bound to.
+func RequestHash(signedRequest p2p.SignedMessage) []byte {
+	hash := sha256.Sum256(signedRequest.Content)
+	return hash[:]
+}
+
+// NewEscrow encrypts the decryption data to the arbiter and binds it to the signed request of the exchange.
+func NewEscrow(data nP.Data, signedRequest p2p.SignedMessage, arbiterPublicKey *rsa.PublicKey) (p2p.Escrow, error) {
+	keyID, err := KeyID(arbiterPublicKey)
+	if err != nil {
+		return p2p.Escrow{}, fmt.Errorf("arbiter/NewEscrow - %w", err)
+	}
+
+	blinding := make([]byte, constants.ArbiterBlindingLength)
+	key := make([]byte, 32)
+	nonce := make([]byte, 12)
+	for _, buffer := range [][]byte{blinding, key, nonce} {
+		if _, err = io.ReadFull(rand.Reader, buffer); err != nil {
+			return p2p.Escrow{}, fmt.Errorf("arbiter/NewEscrow - Could not generate randomness: %w", err)
+		}
+	}
+
+	plaintext, err := json.Marshal(escrowedData{Data: data, Blinding: blinding})
+	if err != nil {
+		return p2p.Escrow{}, fmt.Errorf("arbiter/NewEscrow - Could not marshal decryption data: %w", err)
+	}
+
+	aesGCM, err := newEscrowCipher(key)
+	if err != nil {
+		return p2p.Escrow{}, fmt.Errorf("arbiter/NewEscrow - %w", err)
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, arbiterPublicKey, key, []byte(constants.RSAEncryptionLabel))
+	if err != nil {
+		return p2p.Escrow{}, fmt.Errorf("arbiter/NewEscrow - Could not wrap key: %w", err)
+	}
+
+	escrow := p2p.Escrow{
+		ArbiterKeyID: keyID,
+		RequestHash:  RequestHash(signedRequest),
+		Commitment:   commitment(&data, blinding),
+		WrappedKey:   wrappedKey,
+		Nonce:        nonce,
+	}
+	escrow.Ciphertext = aesGCM.Seal(nil, nonce, plaintext, additionalData(&escrow))
+
+	return escrow, nil
+}
+
+// OpenEscrow decrypts the escrow with the arbiter's private key and checks the decryption data against the
+// commitment. It returns the decryption data and the blinding value of the commitment.
+func OpenEscrow(escrow *p2p.Escrow, privateKey *rsa.PrivateKey) (nP.Data, []byte, error) {
+	keyID, err := KeyID(&privateKey.PublicKey)
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w", err)
+	} else if keyID != escrow.ArbiterKeyID {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: %s", ErrWrongArbiter, escrow.ArbiterKeyID)
+	}
+
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, escrow.WrappedKey, []byte(constants.RSAEncryptionLabel))
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: could not unwrap key: %s", ErrInvalidEscrow, err)
+	}
+
+	aesGCM, err := newEscrowCipher(key)
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: %s", ErrInvalidEscrow, err)
+	}
+
+	if len(escrow.Nonce) != aesGCM.NonceSize() {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: invalid nonce size %d", ErrInvalidEscrow, len(escrow.Nonce))
+	}
+
+	plaintext, err := aesGCM.Open(nil, escrow.Nonce, escrow.Ciphertext, additionalData(escrow))
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: could not decrypt: %s", ErrInvalidEscrow, err)
+	}
+
+	var escrowed escrowedData
+	err = json.Unmarshal(plaintext, &escrowed)
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: could not unmarshal decryption data: %s", ErrInvalidEscrow, err)
+	}
+
+	err = CheckCommitment(escrow, &escrowed.Data, escrowed.Blinding)
+	if err != nil {
+		return nP.Data{}, nil, fmt.Errorf("arbiter/OpenEscrow - %w: %s", ErrInvalidEscrow, err)
+	}
+
+	return escrowed.Data, escrowed.Blinding, nil
+}
+
+// CheckCommitment returns an error if the decryption data and blinding value are not the ones the escrow commits to.
+func CheckCommitment(escrow *p2p.Escrow, data *nP.Data, blinding []byte) error {
+	err := data.CheckErr()
+	if err != nil {
+		return fmt.Errorf("arbiter/CheckCommitment - Invalid decryption data: %w", err)
+	}
+
+	if len(blinding) != constants.ArbiterBlindingLength {
+		return fmt.Errorf("arbiter/CheckCommitment - Invalid blinding length: %d. Expected: %d", len(blinding), constants.ArbiterBlindingLength)
+	}
+
+	if !bytes.Equal(commitment(data, blinding), escrow.Commitment) {
+		return errors.New("arbiter/CheckCommitment - The decryption data does not match the commitment")
+	}
+
+	return nil
+}
+
+// commitment hashes the decryption data with the blinding value. Every field is prefixed with its length so that
+// bytes cannot be moved from one field to another.
+func commitment(data *nP.Data, blinding []byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte(constants.ArbiterEscrowLabel))
+
+	length := make([]byte, 4)
+	for _, field := range [][]byte{data.GetPlainPassword(), data.GetSalt(), data.GetNonce(), blinding} {
+		binary.BigEndian.PutUint32(length, uint32(len(field)))
+		hash.Write(length)
+		hash.Write(field)
+	}
+
+	return hash.Sum(nil)
+}
+
+// additionalData binds the arbiter, request and commitment to the ciphertext so that they cannot be changed unnoticed.
+func additionalData(escrow *p2p.Escrow) []byte {
+	return []byte(constants.ArbiterEscrowLabel + "/" + escrow.ArbiterKeyID + "/" + hex.EncodeToString(escrow.RequestHash) + "/" + hex.EncodeToString(escrow.Commitment))
+}
+
+func newEscrowCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,117 @@
+package arbiter
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	nP "node/nonRepudiation"
+	"node/p2p"
+)
+
+func _newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate key: %s\n", err)
+	}
+
+	return key
+}
+
+func TestEscrow(t *testing.T) {
+	arbiterKey := _newKey(t)
+
+	rounds := nP.DefaultRounds()
+	requirement, err := nP.FakeChatterNonRepudiationRequirement(&rounds)
+	if err != nil {
+		t.Fatalf("TestEscrow - Could not create requirement: %s\n", err)
+	}
+	data := requirement.GetDecryptionValues()
+	signedRequest := p2p.SignedMessage{Content: []byte("request"), Signature: []byte("signature")}
+
+	escrow, err := NewEscrow(data, signedRequest, &arbiterKey.PublicKey)
+	if err != nil {
+		t.Fatalf("TestEscrow - Could not create escrow: %s\n", err)
+	}
+
+	opened, blinding, err := OpenEscrow(&escrow, arbiterKey)
+	if err != nil {
+		t.Fatalf("TestEscrow - Could not open escrow: %s\n", err)
+	} else if string(opened.GetPlainPassword()) != string(data.GetPlainPassword()) || string(opened.GetNonce()) != string(data.GetNonce()) {
+		t.Errorf("TestEscrow - Unexpected decryption data\n")
+	}
+
+	err = CheckCommitment(&escrow, &opened, blinding)
+	if err != nil {
+		t.Errorf("TestEscrow - The released data does not match the commitment: %s\n", err)
+	}
+
+	fakeData, _ := requirement.PopFakeData()
+	err = CheckCommitment(&escrow, &fakeData, blinding)
+	if err == nil {
+		t.Errorf("TestEscrow - Other data matches the commitment\n")
+	}
+
+	_, _, err = OpenEscrow(&escrow, _newKey(t))
+	if !errors.Is(err, ErrWrongArbiter) {
+		t.Errorf("TestEscrow - Opened with another key: %v\n", err)
+	}
+
+	// The request and commitment are bound to the ciphertext
+	tests := []struct {
+		name   string
+		tamper func(escrow *p2p.Escrow)
+	}{
+		{"request hash", func(escrow *p2p.Escrow) { escrow.RequestHash[0] ^= 0xff }},
+		{"commitment", func(escrow *p2p.Escrow) { escrow.Commitment[0] ^= 0xff }},
+		{"ciphertext", func(escrow *p2p.Escrow) { escrow.Ciphertext[0] ^= 0xff }},
+		{"wrapped key", func(escrow *p2p.Escrow) { escrow.WrappedKey[0] ^= 0xff }},
+	}
+
+	for _, test := range tests {
+		tampered := escrow
+		tampered.RequestHash = append([]byte(nil), escrow.RequestHash...)
+		tampered.Commitment = append([]byte(nil), escrow.Commitment...)
+		tampered.Ciphertext = append([]byte(nil), escrow.Ciphertext...)
+		tampered.WrappedKey = append([]byte(nil), escrow.WrappedKey...)
+		test.tamper(&tampered)
+
+		_, _, err = OpenEscrow(&tampered, arbiterKey)
+		if !errors.Is(err, ErrInvalidEscrow) {
+			t.Errorf("TestEscrow - %s: A changed escrow was opened: %v\n", test.name, err)
+		}
+	}
+}
+
+func TestPublicKeyFile(t *testing.T) {
+	directory := t.TempDir()
+
+	key, err := LoadOrCreateKey(filepath.Join(directory, "arbiter.pem"))
+	if err != nil {
+		t.Fatalf("TestPublicKeyFile - Could not create key: %s\n", err)
+	}
+
+	loadedKey, err := LoadOrCreateKey(filepath.Join(directory, "arbiter.pem"))
+	if err != nil || !loadedKey.Equal(key) {
+		t.Fatalf("TestPublicKeyFile - The key was not loaded again: %v\n", err)
+	}
+
+	err = WritePublicKey(filepath.Join(directory, "arbiter.pub.pem"), &key.PublicKey)
+	if err != nil {
+		t.Fatalf("TestPublicKeyFile - Could not write public key: %s\n", err)
+	}
+
+	publicKey, err := LoadPublicKey(filepath.Join(directory, "arbiter.pub.pem"))
+	if err != nil || !publicKey.Equal(&key.PublicKey) {
+		t.Errorf("TestPublicKeyFile - Unexpected public key: %v\n", err)
+	}
+
+	_, err = LoadPublicKey(filepath.Join(directory, "arbiter.pem"))
+	if err == nil {
+		t.Errorf("TestPublicKeyFile - Loaded the private key file as public key\n")
+	}
+}
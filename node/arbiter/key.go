@@ -0,0 +1,91 @@
+package arbiter
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"node/constants"
+	"node/logging"
+	nP "node/nonRepudiation"
+)
+
+// LoadOrCreateKey loads the arbiter's private key at path. If there is none, a key of constants.RSAKeySize bits is
+// created and stored.
+func LoadOrCreateKey(path string) (*rsa.PrivateKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(content)
+		if block == nil || block.Type != constants.PemKeyName {
+			return nil, fmt.Errorf("arbiter/LoadOrCreateKey - The key file does not contain a %s", constants.PemKeyName)
+		}
+
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("arbiter/LoadOrCreateKey - Could not parse key: %w", err)
+		}
+
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("arbiter/LoadOrCreateKey - Could not read key: %w", err)
+	}
+
+	key, err := nP.GenerateRSAPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("arbiter/LoadOrCreateKey - Could not create key: %w", err)
+	}
+
+	encoded := pem.EncodeToMemory(&pem.Block{Type: constants.PemKeyName, Bytes: x509.MarshalPKCS1PrivateKey(&key)})
+	err = ioutil.WriteFile(path, encoded, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("arbiter/LoadOrCreateKey - Could not write key: %w", err)
+	}
+
+	log.Info.Printf("Created a new arbiter key at %s\n", path)
+	return &key, nil
+}
+
+// WritePublicKey exports the public key so that listeners and requesters can be configured with it.
+func WritePublicKey(path string, publicKey *rsa.PublicKey) error {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("arbiter/WritePublicKey - Could not marshal public key: %w", err)
+	}
+
+	encoded := pem.EncodeToMemory(&pem.Block{Type: constants.PemPublicKeyName, Bytes: publicKeyBytes})
+	err = ioutil.WriteFile(path, encoded, 0o644)
+	if err != nil {
+		return fmt.Errorf("arbiter/WritePublicKey - Could not write public key: %w", err)
+	}
+
+	return nil
+}
+
+// LoadPublicKey loads a public key exported with WritePublicKey.
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("arbiter/LoadPublicKey - Could not read public key: %w", err)
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != constants.PemPublicKeyName {
+		return nil, fmt.Errorf("arbiter/LoadPublicKey - The key file does not contain a %s", constants.PemPublicKeyName)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("arbiter/LoadPublicKey - Could not parse public key: %w", err)
+	}
+
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("arbiter/LoadPublicKey - The public key is not an RSA key")
+	}
+
+	return publicKey, nil
+}
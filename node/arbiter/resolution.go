@@ -0,0 +1,224 @@
+package arbiter
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"node/codec"
+	"node/constants"
+	nP "node/nonRepudiation"
+	"node/p2p"
+)
+
+// TranscriptLength is the number of messages in a transcript: the listener's identity card and response, followed by
+// the requester's identity card and request. A resolved transcript is followed by the arbiter's resolution.
+const TranscriptLength = 4
+
+// Transcript is the verified content of the messages a requester stores if its exchange stopped early.
+type Transcript struct {
+	Listener  p2p.IdentityCard
+	Response  p2p.FirstMessage
+	Requester p2p.IdentityCard
+	Request   p2p.FirstMessage
+}
+
+// Resolution is the arbiter's decision on a transcript. The decryption data is only set if it was released.
+type Resolution struct {
+	// Verdict is constants.ArbiterVerdictReleased or constants.ArbiterVerdictInvalidEscrow
+	Verdict string `json:"verdict"`
+	// TranscriptHash binds the resolution to the transcript it was made for
+	TranscriptHash []byte   `json:"transcript_hash"`
+	Data           *nP.Data `json:"data,omitempty"`
+	Blinding       []byte   `json:"blinding,omitempty"`
+	// Reason explains why the escrow is invalid
+	Reason           string        `json:"reason,omitempty"`
+	ArbiterPublicKey rsa.PublicKey `json:"arbiter_public_key"`
+	TimeStamp        int64         `json:"timestamp"`
+}
+
+// VerifyTranscript verifies the signatures of a transcript and that the escrow in the response belongs to the request.
+// The escrow itself can only be checked by the arbiter.
+func VerifyTranscript(signedMessages []p2p.SignedMessage, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey) (Transcript, error) {
+	if len(signedMessages) != TranscriptLength {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - Expected %d messages, got %d", TranscriptLength, len(signedMessages))
+	}
+
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - %w", err)
+	}
+
+	response, listener, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], revoloriPublicKey, messageCodec)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - Could not verify the listener's messages: %w", err)
+	} else if response.Type != constants.MessageTypeListener {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - The second message is not a response (%d)", response.Type)
+	} else if response.Escrow == nil {
+		return Transcript{}, errors.New("arbiter/VerifyTranscript - The listener did not escrow the decryption data")
+	}
+
+	request, requester, err := p2p.ExtractAndVerifyMessages(signedMessages[2:], revoloriPublicKey, messageCodec)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - Could not verify the requester's messages: %w", err)
+	} else if request.Type != constants.MessageTypeRequester {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - The fourth message is not a request (%d)", request.Type)
+	}
+
+	if request.Arbiter != response.Escrow.ArbiterKeyID {
+		return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - The request names arbiter '%s', but the escrow is for '%s'", request.Arbiter, response.Escrow.ArbiterKeyID)
+	}
+
+	if !bytes.Equal(RequestHash(signedMessages[3]), response.Escrow.RequestHash) {
+		return Transcript{}, errors.New("arbiter/VerifyTranscript - The escrow was created for another request")
+	}
+
+	for _, firstMessage := range []p2p.FirstMessage{response, request} {
+		err = parameters.CheckHandshakeHash(firstMessage.Handshake)
+		if err != nil {
+			return Transcript{}, fmt.Errorf("arbiter/VerifyTranscript - %w", err)
+		}
+	}
+
+	return Transcript{
+		Listener:  listener,
+		Response:  response,
+		Requester: requester,
+		Request:   request,
+	}, nil
+}
+
+// Resolve verifies the transcript and opens its escrow with the arbiter's private key. If the escrowed data decrypts
+// the datum of the response, it is released, otherwise the escrow is declared invalid. The resolution is signed with
+// the arbiter's key. To check the escrowed data, the arbiter decrypts the datum, so it learns the datum like the
+// requester would have.
+func Resolve(signedMessages []p2p.SignedMessage, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey, privateKey *rsa.PrivateKey) (p2p.SignedMessage, Resolution, error) {
+	transcript, err := VerifyTranscript(signedMessages, parameters, revoloriPublicKey)
+	if err != nil {
+		return p2p.SignedMessage{}, Resolution{}, fmt.Errorf("arbiter/Resolve - %w", err)
+	}
+
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		return p2p.SignedMessage{}, Resolution{}, fmt.Errorf("arbiter/Resolve - %w", err)
+	}
+
+	resolution := Resolution{
+		Verdict:          constants.ArbiterVerdictReleased,
+		TranscriptHash:   hashTranscript(signedMessages),
+		ArbiterPublicKey: privateKey.PublicKey,
+		TimeStamp:        time.Now().Unix(),
+	}
+
+	data, blinding, err := OpenEscrow(transcript.Response.Escrow, privateKey)
+	if err == nil {
+		_, err = nP.DecryptMessage(&data, transcript.Response.Datum)
+		if err != nil {
+			err = fmt.Errorf("%w: the escrowed data does not decrypt the datum: %s", ErrInvalidEscrow, err)
+		}
+	}
+
+	if errors.Is(err, ErrInvalidEscrow) {
+		resolution.Verdict = constants.ArbiterVerdictInvalidEscrow
+		resolution.Reason = err.Error()
+	} else if err != nil {
+		return p2p.SignedMessage{}, Resolution{}, fmt.Errorf("arbiter/Resolve - %w", err)
+	} else {
+		resolution.Data = &data
+		resolution.Blinding = blinding
+	}
+
+	signedResolution, err := p2p.CreateSignedMessage(resolution, privateKey, messageCodec)
+	if err != nil {
+		return p2p.SignedMessage{}, Resolution{}, fmt.Errorf("arbiter/Resolve - Could not sign resolution: %w", err)
+	}
+
+	return signedResolution, resolution, nil
+}
+
+// VerifyResolution verifies a resolved transcript. The resolution has to be signed by the arbiter the escrow was
+// created for. If the decryption data was released, it is checked against the commitment of the listener and the
+// decrypted datum is returned.
+func VerifyResolution(signedMessages []p2p.SignedMessage, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey) (Transcript, Resolution, string, error) {
+	if len(signedMessages) != TranscriptLength+1 {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - Expected %d messages, got %d", TranscriptLength+1, len(signedMessages))
+	}
+
+	transcript, err := VerifyTranscript(signedMessages[:TranscriptLength], parameters, revoloriPublicKey)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - %w", err)
+	}
+
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - %w", err)
+	}
+
+	signedResolution := signedMessages[TranscriptLength]
+
+	var resolution Resolution
+	err = messageCodec.Unmarshal(signedResolution.Content, &resolution)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - Could not unmarshal resolution: %w", err)
+	}
+
+	keyID, err := KeyID(&resolution.ArbiterPublicKey)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - %w", err)
+	} else if keyID != transcript.Response.Escrow.ArbiterKeyID {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - %w: %s", ErrWrongArbiter, keyID)
+	}
+
+	err = signedResolution.VerifySignature(&resolution.ArbiterPublicKey)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - Could not verify the arbiter's signature: %w", err)
+	}
+
+	if !bytes.Equal(resolution.TranscriptHash, hashTranscript(signedMessages[:TranscriptLength])) {
+		return Transcript{}, Resolution{}, "", errors.New("arbiter/VerifyResolution - The resolution was made for another transcript")
+	}
+
+	switch resolution.Verdict {
+	case constants.ArbiterVerdictInvalidEscrow:
+		return transcript, resolution, "", nil
+	case constants.ArbiterVerdictReleased:
+	default:
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - Unknown verdict '%s'", resolution.Verdict)
+	}
+
+	if resolution.Data == nil {
+		return Transcript{}, Resolution{}, "", errors.New("arbiter/VerifyResolution - The released resolution does not contain decryption data")
+	}
+
+	err = CheckCommitment(transcript.Response.Escrow, resolution.Data, resolution.Blinding)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - %w", err)
+	}
+
+	decrypted, err := nP.DecryptMessage(resolution.Data, transcript.Response.Datum)
+	if err != nil {
+		return Transcript{}, Resolution{}, "", fmt.Errorf("arbiter/VerifyResolution - Could not decrypt the datum: %w", err)
+	}
+
+	return transcript, resolution, decrypted, nil
+}
+
+// hashTranscript hashes the content and signature of every message, each prefixed with its length.
+func hashTranscript(signedMessages []p2p.SignedMessage) []byte {
+	hash := sha256.New()
+
+	length := make([]byte, 4)
+	for _, message := range signedMessages {
+		for _, field := range [][]byte{message.Content, message.Signature} {
+			binary.BigEndian.PutUint32(length, uint32(len(field)))
+			hash.Write(length)
+			hash.Write(field)
+		}
+	}
+
+	return hash.Sum(nil)
+}
@@ -0,0 +1,196 @@
+package arbiter
+
+import (
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/codec"
+	"node/constants"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/revoloridev"
+)
+
+const _datum = "Requested datum: heartRate"
+
+// _transcript is a transcript with the keys it was created with.
+type _transcript struct {
+	messages          []p2p.SignedMessage
+	parameters        p2p.SessionParameters
+	revoloriPublicKey rsa.PublicKey
+}
+
+func _signedCard(t *testing.T, revolori *revoloridev.Server, ssoid string, key *rsa.PrivateKey) p2p.SignedMessage {
+	t.Helper()
+
+	revoloriSignedCard, err := revolori.Sign(ssoid, key.PublicKey)
+	if err != nil {
+		t.Fatalf("Could not sign identity card: %s\n", err)
+	}
+
+	cards, err := p2p.SignIdentityCards(revoloriSignedCard, key, peer.ID(""))
+	if err != nil {
+		t.Fatalf("Could not sign identity card: %s\n", err)
+	}
+
+	return cards[constants.CodecCBOR]
+}
+
+// _newTranscript creates the transcript of an exchange with the requirement whose escrow contains the data. If the data
+// does not decrypt the datum, the escrow is invalid.
+func _newTranscript(t *testing.T, arbiterKey *rsa.PrivateKey, requirement *nP.NonRepudiationRequirement, escrowData nP.Data) _transcript {
+	t.Helper()
+
+	revolori := revoloridev.NewServer(_newKey(t), nil)
+	listenerKey := _newKey(t)
+	requesterKey := _newKey(t)
+	messageCodec, _ := codec.Get(constants.CodecCBOR)
+
+	keyID, _ := KeyID(&arbiterKey.PublicKey)
+	request := p2p.FirstMessage{
+		Datum:         "heartRate",
+		Justification: "Treatment",
+		PublicKey:     _newKey(t).PublicKey,
+		Type:          constants.MessageTypeRequester,
+		Arbiter:       keyID,
+	}
+
+	signedRequest, err := p2p.CreateSignedMessage(request, requesterKey, messageCodec)
+	if err != nil {
+		t.Fatalf("Could not sign request: %s\n", err)
+	}
+
+	encryptedDatum, err := requirement.EncryptMessage([]byte(_datum))
+	if err != nil {
+		t.Fatalf("Could not encrypt datum: %s\n", err)
+	}
+
+	escrow, err := NewEscrow(escrowData, signedRequest, &arbiterKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Could not create escrow: %s\n", err)
+	}
+
+	conversationKey := requirement.GetPrivateKey()
+	response := p2p.FirstMessage{
+		Datum:     encryptedDatum,
+		PublicKey: conversationKey.PublicKey,
+		Type:      constants.MessageTypeListener,
+		Escrow:    &escrow,
+	}
+
+	signedResponse, err := p2p.CreateSignedMessage(response, listenerKey, messageCodec)
+	if err != nil {
+		t.Fatalf("Could not sign response: %s\n", err)
+	}
+
+	return _transcript{
+		messages: []p2p.SignedMessage{
+			_signedCard(t, revolori, "alice", listenerKey),
+			signedResponse,
+			_signedCard(t, revolori, "bob", requesterKey),
+			signedRequest,
+		},
+		parameters:        p2p.SessionParameters{Version: constants.LegacyProtocolVersion, Codec: constants.CodecCBOR},
+		revoloriPublicKey: revolori.PublicKey(),
+	}
+}
+
+// _fakeTranscript creates a transcript whose escrow contains data that does not decrypt the datum. It is much faster
+// to create than a transcript with a real requirement.
+func _fakeTranscript(t *testing.T, arbiterKey *rsa.PrivateKey) _transcript {
+	t.Helper()
+
+	rounds := nP.DefaultRounds()
+	requirement, err := nP.FakeChatterNonRepudiationRequirement(&rounds)
+	if err != nil {
+		t.Fatalf("Could not create requirement: %s\n", err)
+	}
+
+	data, err := requirement.PopFakeData()
+	if err != nil {
+		t.Fatalf("Could not pop fake data: %s\n", err)
+	}
+
+	return _newTranscript(t, arbiterKey, &requirement, data)
+}
+
+func TestResolve(t *testing.T) {
+	arbiterKey := _newKey(t)
+
+	rounds := nP.DefaultRounds()
+	requirement, err := nP.GenerateNonRepudiationRequirement(&rounds)
+	if err != nil {
+		t.Fatalf("TestResolve - Could not create requirement: %s\n", err)
+	}
+	transcript := _newTranscript(t, arbiterKey, &requirement, requirement.GetDecryptionValues())
+
+	signedResolution, resolution, err := Resolve(transcript.messages, transcript.parameters, &transcript.revoloriPublicKey, arbiterKey)
+	if err != nil {
+		t.Fatalf("TestResolve - Could not resolve: %s\n", err)
+	} else if resolution.Verdict != constants.ArbiterVerdictReleased {
+		t.Fatalf("TestResolve - Unexpected verdict '%s': %s\n", resolution.Verdict, resolution.Reason)
+	}
+
+	resolved := append(append([]p2p.SignedMessage(nil), transcript.messages...), signedResolution)
+	verified, _, decrypted, err := VerifyResolution(resolved, transcript.parameters, &transcript.revoloriPublicKey)
+	if err != nil {
+		t.Fatalf("TestResolve - Could not verify resolution: %s\n", err)
+	} else if decrypted != _datum || verified.Listener.SSOID != "alice" || verified.Requester.SSOID != "bob" {
+		t.Errorf("TestResolve - Unexpected resolution: '%s', '%s', '%s'\n", decrypted, verified.Listener.SSOID, verified.Requester.SSOID)
+	}
+
+	// The resolution only belongs to the transcript it was made for
+	other := _fakeTranscript(t, arbiterKey)
+	_, _, _, err = VerifyResolution(append(other.messages, signedResolution), other.parameters, &other.revoloriPublicKey)
+	if err == nil {
+		t.Errorf("TestResolve - The resolution was accepted for another transcript\n")
+	}
+
+	// Only the arbiter of the escrow can resolve the transcript
+	_, _, err = Resolve(transcript.messages, transcript.parameters, &transcript.revoloriPublicKey, _newKey(t))
+	if !errors.Is(err, ErrWrongArbiter) {
+		t.Errorf("TestResolve - Resolved by another arbiter: %v\n", err)
+	}
+}
+
+func TestResolveInvalidEscrow(t *testing.T) {
+	arbiterKey := _newKey(t)
+	transcript := _fakeTranscript(t, arbiterKey)
+
+	signedResolution, resolution, err := Resolve(transcript.messages, transcript.parameters, &transcript.revoloriPublicKey, arbiterKey)
+	if err != nil {
+		t.Fatalf("TestResolveInvalidEscrow - Could not resolve: %s\n", err)
+	} else if resolution.Verdict != constants.ArbiterVerdictInvalidEscrow || resolution.Data != nil {
+		t.Fatalf("TestResolveInvalidEscrow - Unexpected verdict '%s'\n", resolution.Verdict)
+	}
+
+	_, verified, decrypted, err := VerifyResolution(append(transcript.messages, signedResolution), transcript.parameters, &transcript.revoloriPublicKey)
+	if err != nil || verified.Verdict != constants.ArbiterVerdictInvalidEscrow || decrypted != "" {
+		t.Errorf("TestResolveInvalidEscrow - Unexpected resolution: '%s', '%s', %v\n", verified.Verdict, decrypted, err)
+	}
+}
+
+func TestVerifyTranscript(t *testing.T) {
+	arbiterKey := _newKey(t)
+	transcript := _fakeTranscript(t, arbiterKey)
+	other := _fakeTranscript(t, arbiterKey)
+
+	_, err := VerifyTranscript(transcript.messages, transcript.parameters, &transcript.revoloriPublicKey)
+	if err != nil {
+		t.Fatalf("TestVerifyTranscript - Could not verify transcript: %s\n", err)
+	}
+
+	// The escrow of the response is bound to the request
+	swapped := append(append([]p2p.SignedMessage(nil), transcript.messages[:3]...), other.messages[3])
+	_, err = VerifyTranscript(swapped, transcript.parameters, &transcript.revoloriPublicKey)
+	if err == nil {
+		t.Errorf("TestVerifyTranscript - A transcript with another request was accepted\n")
+	}
+
+	_, err = VerifyTranscript(transcript.messages[:3], transcript.parameters, &transcript.revoloriPublicKey)
+	if err == nil {
+		t.Errorf("TestVerifyTranscript - A transcript without a request was accepted\n")
+	}
+}
@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"node/constants"
+)
+
+// Codec encodes the messages of an exchange. The encoding of a value must be deterministic since signatures are
+// calculated over it.
+type Codec interface {
+	Name() string
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, value interface{}) error
+}
+
+type jsonCodec struct{}
+
+type cborCodec struct {
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+}
+
+// JSON is the codec of the legacy protocol.
+var JSON Codec = jsonCodec{}
+
+// CBOR uses the core deterministic encoding of RFC 8949. Field names are taken from the json tags.
+var CBOR Codec = newCBORCodec()
+
+// Names returns the names of all codecs ordered by preference.
+func Names() []string {
+	return []string{constants.CodecCBOR, constants.CodecJSON}
+}
+
+// Get returns the codec with the given name.
+func Get(name string) (Codec, error) {
+	switch name {
+	case constants.CodecJSON:
+		return JSON, nil
+	case constants.CodecCBOR:
+		return CBOR, nil
+	default:
+		return nil, fmt.Errorf("codec/Get - Unknown codec '%s'", name)
+	}
+}
+
+func (jsonCodec) Name() string {
+	return constants.CodecJSON
+}
+
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+func newCBORCodec() cborCodec {
+	encMode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("codec/newCBORCodec - Invalid encoding options: %s", err))
+	}
+
+	decMode, err := cbor.DecOptions{
+		DupMapKey:   cbor.DupMapKeyEnforcedAPF,
+		IndefLength: cbor.IndefLengthForbidden,
+	}.DecMode()
+	if err != nil {
+		panic(fmt.Sprintf("codec/newCBORCodec - Invalid decoding options: %s", err))
+	}
+
+	return cborCodec{
+		encMode: encMode,
+		decMode: decMode,
+	}
+}
+
+func (cborCodec) Name() string {
+	return constants.CodecCBOR
+}
+
+func (codec cborCodec) Marshal(value interface{}) ([]byte, error) {
+	return codec.encMode.Marshal(value)
+}
+
+func (codec cborCodec) Unmarshal(data []byte, value interface{}) error {
+	return codec.decMode.Unmarshal(data, value)
+}
@@ -0,0 +1,99 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"node/constants"
+)
+
+const codecRuns = 10
+
+type testMessage struct {
+	Datum     string            `json:"datum"`
+	PublicKey rsa.PublicKey     `json:"public_key"`
+	Values    map[string]int    `json:"values"`
+	Nested    map[string][]byte `json:"nested"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestRoundTrip - Could not generate rsa key: %s\n", err)
+	}
+
+	message := testMessage{
+		Datum:     "A datum with } and {",
+		PublicKey: privateKey.PublicKey,
+		Values:    map[string]int{"b": 2, "a": 1, "c": 3},
+		Nested:    map[string][]byte{"z": {1, 2}, "y": {3}},
+	}
+
+	for _, name := range Names() {
+		messageCodec, err := Get(name)
+		if err != nil {
+			t.Fatalf("TestRoundTrip - Could not get codec '%s': %s\n", name, err)
+		}
+
+		encoded, err := messageCodec.Marshal(message)
+		if err != nil {
+			t.Fatalf("TestRoundTrip - %s: Could not marshal: %s\n", name, err)
+		}
+
+		var decoded testMessage
+		err = messageCodec.Unmarshal(encoded, &decoded)
+		if err != nil {
+			t.Fatalf("TestRoundTrip - %s: Could not unmarshal: %s\n", name, err)
+		}
+
+		if decoded.Datum != message.Datum || !decoded.PublicKey.Equal(&message.PublicKey) || decoded.Values["b"] != 2 || !bytes.Equal(decoded.Nested["z"], []byte{1, 2}) {
+			t.Errorf("TestRoundTrip - %s: Decoded message differs: %+v\n", name, decoded)
+		}
+
+		// The encoding must not depend on the iteration order of maps
+		for i := 0; i < codecRuns; i++ {
+			again, err := messageCodec.Marshal(message)
+			if err != nil || !bytes.Equal(again, encoded) {
+				t.Fatalf("TestRoundTrip - %s: Encoding is not deterministic\n", name)
+			}
+		}
+	}
+}
+
+func TestCBORIsSmaller(t *testing.T) {
+	message := struct {
+		Content   []byte `json:"content"`
+		Signature []byte `json:"signature"`
+	}{
+		Content:   bytes.Repeat([]byte{0xab}, 512),
+		Signature: bytes.Repeat([]byte{0xcd}, 384),
+	}
+
+	jsonBytes, err := JSON.Marshal(message)
+	if err != nil {
+		t.Fatalf("TestCBORIsSmaller - Could not marshal JSON: %s\n", err)
+	}
+
+	cborBytes, err := CBOR.Marshal(message)
+	if err != nil {
+		t.Fatalf("TestCBORIsSmaller - Could not marshal CBOR: %s\n", err)
+	}
+
+	if len(cborBytes) >= len(jsonBytes) {
+		t.Errorf("TestCBORIsSmaller - CBOR (%d bytes) is not smaller than JSON (%d bytes)\n", len(cborBytes), len(jsonBytes))
+	}
+}
+
+func TestUnknownCodec(t *testing.T) {
+	_, err := Get("protobuf")
+	if err == nil {
+		t.Errorf("TestUnknownCodec - Got a codec for an unknown name\n")
+	}
+
+	_, err = Get(constants.CodecCBOR)
+	if err != nil {
+		t.Errorf("TestUnknownCodec - Could not get the CBOR codec: %s\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+package consent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CLIAsker prompts the owner on the terminal. Requests are asked one after the other.
+type CLIAsker struct {
+	output io.Writer
+	lines  chan string
+	mutex  sync.Mutex
+}
+
+// NewCLIAsker returns a CLIAsker reading from stdin.
+func NewCLIAsker() *CLIAsker {
+	return newCLIAsker(os.Stdin, os.Stdout)
+}
+
+func newCLIAsker(input io.Reader, output io.Writer) *CLIAsker {
+	asker := &CLIAsker{
+		output: output,
+		lines:  make(chan string),
+	}
+
+	// A single reader is needed since a read that timed out cannot be canceled
+	go func() {
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			asker.lines <- scanner.Text()
+		}
+
+		close(asker.lines)
+	}()
+
+	return asker
+}
+
+// Ask prints the request and waits for 'y' or 'n'.
+func (asker *CLIAsker) Ask(request Request, timeout time.Duration) (bool, error) {
+	asker.mutex.Lock()
+	defer asker.mutex.Unlock()
+
+	deadline := time.After(timeout)
+	// Drop answers that were typed after an earlier prompt timed out
+	for drained := false; !drained; {
+		select {
+		case <-asker.lines:
+		default:
+			drained = true
+		}
+	}
+
+	for {
+		fmt.Fprintf(asker.output, "%s\nAllow? [y/n] (%s left): ", request.String(), time.Until(request.Time.Add(timeout)).Round(time.Second))
+
+		select {
+		case line, ok := <-asker.lines:
+			if !ok {
+				return false, fmt.Errorf("consent/CLIAsker.Ask - stdin was closed")
+			}
+
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return true, nil
+			case "n", "no":
+				return false, nil
+			}
+		case <-deadline:
+			fmt.Fprintln(asker.output)
+			return false, ErrTimeOut
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"node/random"
+)
+
+const (
+	ModeNone    = "none"
+	ModeCLI     = "cli"
+	ModeHTTP    = "http"
+	ModeWebhook = "webhook"
+)
+
+// ErrTimeOut is returned if the owner did not answer within the timeout.
+var ErrTimeOut = errors.New("the data owner did not answer in time")
+
+// Request describes an exchange that waits for the owner's consent.
+type Request struct {
+	ID            string    `json:"id"`
+	SSOID         string    `json:"ssoid"`
+	Datum         string    `json:"datum"`
+	Justification string    `json:"justification"`
+	Time          time.Time `json:"time"`
+}
+
+// Asker asks the data owner whether an exchange may continue.
+type Asker interface {
+	// Ask blocks until the owner approved (true) or denied (false) the request or the timeout passed, in which case
+	// ErrTimeOut is returned.
+	Ask(request Request, timeout time.Duration) (bool, error)
+}
+
+// NewRequest returns a Request with a random ID.
+func NewRequest(ssoid string, datum string, justification string) Request {
+	return Request{
+		ID:            random.String(8),
+		SSOID:         ssoid,
+		Datum:         datum,
+		Justification: justification,
+		Time:          time.Now(),
+	}
+}
+
+// NewAsker returns the Asker for the given mode. The address is the listen address for ModeHTTP and the URL for
+// ModeWebhook. ModeNone returns nil.
+func NewAsker(mode string, address string) (Asker, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case ModeNone, "":
+		return nil, nil
+	case ModeCLI:
+		return NewCLIAsker(), nil
+	case ModeHTTP:
+		return NewHTTPAsker(address)
+	case ModeWebhook:
+		return NewWebhookAsker(address)
+	default:
+		return nil, fmt.Errorf("consent/NewAsker - Unknown consent mode '%s'", mode)
+	}
+}
+
+func (request *Request) String() string {
+	return fmt.Sprintf("'%s' requests '%s' because: %s", request.SSOID, request.Datum, request.Justification)
+}
@@ -0,0 +1,123 @@
+package consent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLIAsker(t *testing.T) {
+	asker := newCLIAsker(strings.NewReader("maybe\ny\nno\n"), io.Discard)
+	request := NewRequest("doctor@example.com", "heartRate", "For the treatment")
+
+	approved, err := asker.Ask(request, time.Second)
+	if err != nil || !approved {
+		t.Errorf("TestCLIAsker - Expected approval, got %t: %v\n", approved, err)
+	}
+
+	approved, err = asker.Ask(request, time.Second)
+	if err != nil || approved {
+		t.Errorf("TestCLIAsker - Expected denial, got %t: %v\n", approved, err)
+	}
+}
+
+func TestHTTPAsker(t *testing.T) {
+	asker, err := NewHTTPAsker("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("TestHTTPAsker - Could not start endpoint: %s\n", err)
+	}
+	defer asker.Close()
+
+	request := NewRequest("doctor@example.com", "heartRate", "For the treatment")
+
+	for _, answer := range []string{"approve", "deny"} {
+		go func(answer string) {
+			// Wait until the request is pending
+			for {
+				response, err := http.Get(fmt.Sprintf("http://%s/consent", asker.Address())) //nolint:noctx
+				if err != nil {
+					t.Errorf("TestHTTPAsker - Could not list requests: %s\n", err)
+					return
+				}
+
+				var pending []Request
+				err = json.NewDecoder(response.Body).Decode(&pending)
+				response.Body.Close()
+				if err == nil && len(pending) == 1 {
+					break
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			response, err := http.Post(fmt.Sprintf("http://%s/consent/%s/%s", asker.Address(), request.ID, answer), "", nil) //nolint:noctx
+			if err != nil {
+				t.Errorf("TestHTTPAsker - Could not answer request: %s\n", err)
+				return
+			}
+			response.Body.Close()
+		}(answer)
+
+		approved, err := asker.Ask(request, 5*time.Second)
+		if err != nil || approved != (answer == "approve") {
+			t.Errorf("TestHTTPAsker - Unexpected result for '%s': %t, %v\n", answer, approved, err)
+		}
+	}
+
+	_, err = asker.Ask(request, 50*time.Millisecond)
+	if err != ErrTimeOut { //nolint:errorlint
+		t.Errorf("TestHTTPAsker - Expected a time out, got: %v\n", err)
+	}
+}
+
+func TestWebhookAsker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var consentRequest Request
+		err := json.NewDecoder(request.Body).Decode(&consentRequest)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch consentRequest.SSOID {
+		case "slow@example.com":
+			time.Sleep(200 * time.Millisecond)
+		case "invalid@example.com":
+			_, _ = writer.Write([]byte(`{}`))
+			return
+		}
+
+		_, _ = fmt.Fprintf(writer, `{"approved": %t}`, consentRequest.SSOID == "doctor@example.com")
+	}))
+	defer server.Close()
+
+	asker, err := NewWebhookAsker(server.URL)
+	if err != nil {
+		t.Fatalf("TestWebhookAsker - Could not create asker: %s\n", err)
+	}
+
+	approved, err := asker.Ask(NewRequest("doctor@example.com", "heartRate", "Treatment"), time.Second)
+	if err != nil || !approved {
+		t.Errorf("TestWebhookAsker - Expected approval, got %t: %v\n", approved, err)
+	}
+
+	approved, err = asker.Ask(NewRequest("someone@example.com", "heartRate", "Treatment"), time.Second)
+	if err != nil || approved {
+		t.Errorf("TestWebhookAsker - Expected denial, got %t: %v\n", approved, err)
+	}
+
+	_, err = asker.Ask(NewRequest("invalid@example.com", "heartRate", "Treatment"), time.Second)
+	if err == nil {
+		t.Errorf("TestWebhookAsker - Accepted an answer without a decision\n")
+	}
+
+	_, err = asker.Ask(NewRequest("slow@example.com", "heartRate", "Treatment"), 50*time.Millisecond)
+	if err != ErrTimeOut { //nolint:errorlint
+		t.Errorf("TestWebhookAsker - Expected a time out, got: %v\n", err)
+	}
+}
@@ -0,0 +1,145 @@
+package consent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "node/logging"
+)
+
+// HTTPAsker serves pending requests on a local HTTP endpoint:
+//
+//	GET  /consent              lists the pending requests
+//	POST /consent/<id>/approve approves a request
+//	POST /consent/<id>/deny    denies a request
+type HTTPAsker struct {
+	listener net.Listener
+	pending  map[string]*pendingRequest
+	mutex    sync.Mutex
+}
+
+type pendingRequest struct {
+	request Request
+	answer  chan bool
+}
+
+// NewHTTPAsker starts the endpoint on the given address, e.g. "127.0.0.1:40080".
+func NewHTTPAsker(address string) (*HTTPAsker, error) {
+	if address == "" {
+		return nil, fmt.Errorf("consent/NewHTTPAsker - No listen address provided")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("consent/NewHTTPAsker - Could not listen on '%s': %w", address, err)
+	}
+
+	asker := &HTTPAsker{
+		listener: listener,
+		pending:  make(map[string]*pendingRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/consent", asker.handleList)
+	mux.HandleFunc("/consent/", asker.handleAnswer)
+
+	go func() {
+		err := http.Serve(listener, mux) //nolint:gosec
+		if err != nil {
+			log.Error.Printf("consent/NewHTTPAsker - Endpoint stopped: %s\n", err)
+		}
+	}()
+
+	return asker, nil
+}
+
+// Address returns the address the endpoint listens on.
+func (asker *HTTPAsker) Address() string {
+	return asker.listener.Addr().String()
+}
+
+// Close stops the endpoint.
+func (asker *HTTPAsker) Close() error {
+	return asker.listener.Close()
+}
+
+// Ask publishes the request on the endpoint and waits for an answer.
+func (asker *HTTPAsker) Ask(request Request, timeout time.Duration) (bool, error) {
+	pending := &pendingRequest{
+		request: request,
+		answer:  make(chan bool, 1),
+	}
+
+	asker.mutex.Lock()
+	asker.pending[request.ID] = pending
+	asker.mutex.Unlock()
+
+	defer func() {
+		asker.mutex.Lock()
+		delete(asker.pending, request.ID)
+		asker.mutex.Unlock()
+	}()
+
+	log.Info.Printf("Waiting for consent on http://%s/consent: %s\n", asker.Address(), request.String())
+
+	select {
+	case approved := <-pending.answer:
+		return approved, nil
+	case <-time.After(timeout):
+		return false, ErrTimeOut
+	}
+}
+
+func (asker *HTTPAsker) handleList(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	asker.mutex.Lock()
+	requests := make([]Request, 0, len(asker.pending))
+	for _, pending := range asker.pending {
+		requests = append(requests, pending.request)
+	}
+	asker.mutex.Unlock()
+
+	writer.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(writer).Encode(requests)
+	if err != nil {
+		log.Error.Printf("consent/HTTPAsker.handleList - Could not encode pending requests: %s\n", err)
+	}
+}
+
+func (asker *HTTPAsker) handleAnswer(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(request.URL.Path, "/consent/"), "/")
+	if len(parts) != 2 || (parts[1] != "approve" && parts[1] != "deny") {
+		http.NotFound(writer, request)
+		return
+	}
+
+	asker.mutex.Lock()
+	pending, ok := asker.pending[parts[0]]
+	asker.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(writer, request)
+		return
+	}
+
+	select {
+	case pending.answer <- parts[1] == "approve":
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(writer, "request was already answered", http.StatusConflict)
+	}
+}
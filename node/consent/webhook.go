@@ -0,0 +1,60 @@
+package consent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookAsker posts the request as JSON to a URL. The webhook answers with {"approved": true|false}.
+type WebhookAsker struct {
+	url string
+}
+
+type webhookAnswer struct {
+	Approved *bool `json:"approved"`
+}
+
+// NewWebhookAsker returns a WebhookAsker for the given URL.
+func NewWebhookAsker(webhookURL string) (*WebhookAsker, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("consent/NewWebhookAsker - Invalid webhook URL '%s'", webhookURL)
+	}
+
+	return &WebhookAsker{url: webhookURL}, nil
+}
+
+// Ask posts the request and waits for the webhook's answer.
+func (asker *WebhookAsker) Ask(request Request, timeout time.Duration) (bool, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return false, fmt.Errorf("consent/WebhookAsker.Ask - Could not marshal request: %w", err)
+	}
+
+	client := http.Client{Timeout: timeout}
+	response, err := client.Post(asker.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok && urlErr.Timeout() { //nolint:errorlint
+			return false, ErrTimeOut
+		}
+
+		return false, fmt.Errorf("consent/WebhookAsker.Ask - Could not call webhook: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("consent/WebhookAsker.Ask - Webhook returned status %d", response.StatusCode)
+	}
+
+	var answer webhookAnswer
+	err = json.NewDecoder(response.Body).Decode(&answer)
+	if err != nil || answer.Approved == nil {
+		return false, fmt.Errorf("consent/WebhookAsker.Ask - Invalid answer from webhook")
+	}
+
+	return *answer.Approved, nil
+}
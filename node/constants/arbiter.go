@@ -0,0 +1,19 @@
+package constants
+
+const (
+	// ArbiterKeyFilePath is the path to the private key of the arbiter.
+	ArbiterKeyFilePath = "./arbiter.pem"
+	// ArbiterPublicKeyFilePath is the path the arbiter's public key is exported to. Listeners and requesters are
+	// configured with this file.
+	ArbiterPublicKeyFilePath = "./arbiter.pub.pem"
+	// PemPublicKeyName is the pem file type of the arbiter's public key.
+	PemPublicKeyName = "PUBLIC KEY"
+	// ArbiterEscrowLabel prefixes the additional data and commitments of escrowed decryption data.
+	ArbiterEscrowLabel = "P3-arbiter-escrow"
+	// ArbiterBlindingLength is the length of the random value that hides the decryption data in the commitment.
+	ArbiterBlindingLength = 32
+	// ArbiterVerdictReleased and ArbiterVerdictInvalidEscrow are the verdicts of a resolution. The decryption data is
+	// only part of released resolutions.
+	ArbiterVerdictReleased      = "released"
+	ArbiterVerdictInvalidEscrow = "invalid-escrow"
+)
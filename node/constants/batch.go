@@ -0,0 +1,14 @@
+package constants
+
+import "time"
+
+const (
+	// BatchDirectory is where the batch store keeps the inclusion proofs of anchored usage logs.
+	BatchDirectory = "./batches/"
+	// DefaultBatchSize is the number of usage logs that are anchored at once.
+	DefaultBatchSize = 32
+	// DefaultBatchInterval is the longest time a usage log waits for its batch to be anchored.
+	DefaultBatchInterval = 30 * time.Second
+	// BatchAnchorVersion is the version of the transaction input that anchors a batch.
+	BatchAnchorVersion = 1
+)
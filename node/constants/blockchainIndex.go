@@ -0,0 +1,13 @@
+package constants
+
+import "time"
+
+const (
+	// BlockchainIndexPath is the SQLite database that indexes the usage logs on the blockchain.
+	BlockchainIndexPath = "blockchainIndex.db"
+	// IndexReorgDepth is the number of indexed blocks that are checked for a reorganization of the chain before the
+	// index is rebuilt.
+	IndexReorgDepth = 64
+	// IndexFollowInterval is the time between two syncs of an index that follows the chain.
+	IndexFollowInterval = 15 * time.Second
+)
@@ -0,0 +1,26 @@
+package constants
+
+import "time"
+
+const (
+	// DiscoveryMDNS searches for peers in the local network.
+	DiscoveryMDNS = "mdns"
+	// DiscoveryDHT searches for peers via the Kademlia DHT.
+	DiscoveryDHT = "dht"
+	// DiscoveryBoth uses mDNS and the DHT at the same time.
+	DiscoveryBoth = "both"
+	// DefaultRendezvousNamespace is the DHT namespace nodes advertise themselves under. Every deployment should use
+	// its own namespace.
+	DefaultRendezvousNamespace = "/P3/rendezvous/default"
+	// DHTProtocolPrefix separates the DHT of the nodes from the public IPFS DHT.
+	DHTProtocolPrefix = "/P3"
+	// DHTSearchInterval is the time between two searches of the rendezvous namespace.
+	DHTSearchInterval = 5 * time.Second
+)
+
+const (
+	// DeploymentKeyEnv is the environment variable with the deployment key. Rendezvous tokens are derived from it.
+	DeploymentKeyEnv = "P3_DEPLOYMENT_KEY"
+	// RendezvousTokenSize is the size of a rendezvous token in bytes.
+	RendezvousTokenSize = 16
+)
@@ -0,0 +1,17 @@
+package constants
+
+import "time"
+
+const (
+	// EthereumKeyFilePath is the path to the node-local key that signs the transactions of usage logs.
+	EthereumKeyFilePath = "./_ethereumKey"
+	// UsageLogRecipient receives the transactions of all usage logs, since sender and recipient carry no information.
+	UsageLogRecipient = "0x0000000000000000000000000000000000000000"
+	// DefaultConfirmations is the number of blocks including and following the block of a transaction before the usage
+	// log counts as stored.
+	DefaultConfirmations = 1
+	// ReceiptPollInterval is the time between two requests for the receipt of a transaction.
+	ReceiptPollInterval = 250 * time.Millisecond
+	// ReceiptTimeout is the maximum time to wait for a transaction to be confirmed.
+	ReceiptTimeout = 5 * time.Minute
+)
@@ -3,9 +3,12 @@ package constants
 type MessageType int8
 
 const (
-	MessageTypeRealExchange MessageType = 2
-	MessageTypeRequester    MessageType = 1
-	MessageTypeListener     MessageType = 0
-	MessageTypeFailure      MessageType = -1
-	MessageTypeFakeChatter  MessageType = -2
+	MessageTypeRealExchange   MessageType = 2
+	MessageTypeRequester      MessageType = 1
+	MessageTypeListener       MessageType = 0
+	MessageTypeFailure        MessageType = -1
+	MessageTypeFakeChatter    MessageType = -2
+	MessageTypeRefusal        MessageType = -3
+	MessageTypeDenied         MessageType = -4
+	MessageTypeConsentPending MessageType = -5
 )
@@ -0,0 +1,18 @@
+package constants
+
+const (
+	// ProtocolVersion is the protocol version this node negotiates. Its signed first messages carry a hash of both
+	// Hellos and the listener's response carries the rounds it announced. Version 1 is the legacy protocol without a
+	// handshake.
+	ProtocolVersion       = 3
+	LegacyProtocolVersion = 1
+
+	// SignatureRSAPKCS1v15SHA256 signs the SHA-256 hash of a message with RSA PKCS #1 v1.5.
+	SignatureRSAPKCS1v15SHA256 = "rsa-pkcs1v15-sha256"
+	// KDFBcryptBlake2s derives the AES key by hashing the bcrypt hash of the password with BLAKE2s.
+	KDFBcryptBlake2s = "bcrypt-blake2s"
+	// CodecJSON encodes messages as JSON.
+	CodecJSON = "json"
+	// CodecCBOR encodes messages with the deterministic CBOR encoding.
+	CodecCBOR = "cbor"
+)
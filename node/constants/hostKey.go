@@ -0,0 +1,12 @@
+package constants
+
+const (
+	// HostKeyFilePath is the path to the libp2p host key, which determines the peer ID.
+	HostKeyFilePath = "./_hostKey.pem"
+	// HostKeyPemName pem file type of the host key.
+	HostKeyPemName = "LIBP2P PRIVATE KEY"
+	// HostKeyRSA creates an RSA host key of RSAKeySize bits.
+	HostKeyRSA = "rsa"
+	// HostKeyEd25519 creates an Ed25519 host key.
+	HostKeyEd25519 = "ed25519"
+)
@@ -5,6 +5,14 @@ import "time"
 const (
 	// MaxWaitTime is the default maximum wait time for a peer to send data.
 	MaxWaitTime = 2 * time.Second
-	// P2PProtocolName is the name of the peer-to-peer protocol.
-	P2PProtocolName = "/P3/1.0.0"
+	// MaxConsentWaitTime is the maximum time a requester waits for the listener's response once the listener announced
+	// that it asks the data owner for consent.
+	MaxConsentWaitTime = 5 * time.Minute
+	// P2PProtocolName is the name of the peer-to-peer protocol. Messages are sent as length-prefixed frames.
+	P2PProtocolName = "/P3/2.0.0"
+	// P2PLegacyProtocolName is the name of the first protocol version, which delimits messages with '}'. It is still
+	// supported for compatibility with older nodes.
+	P2PLegacyProtocolName = "/P3/1.0.0"
+	// MaxFrameSize is the maximum size of a single message in bytes.
+	MaxFrameSize = 8 << 20
 )
@@ -1,7 +1,18 @@
 package constants
 
 const (
-	// MinimumRepetitions and MaxRepetitions refer to the message amount interval [25, 125].
-	MinimumRepetitions = 25
-	MaxRepetitions     = 126
+	// DefaultMinimumRepetitions and DefaultMaxRepetitions refer to the interval [25, 124] of fake rounds the listener
+	// draws from if no other rounds are configured. The uniform distribution over it gives a requester a chance of
+	// DefaultCheatingProbability to guess the round of the real decryption data.
+	DefaultMinimumRepetitions  = 25
+	DefaultMaxRepetitions      = 124
+	DefaultCheatingProbability = 0.01
+	// HighestRepetitions is the largest maximum a listener may announce. Every round is a round trip, so higher
+	// maximums let a listener stall the requester for a long time.
+	HighestRepetitions = 10000
+
+	// RoundDistributionUniform draws every number of fake rounds between the minimum and maximum with the same
+	// probability. RoundDistributionGeometric stops after every round above the minimum with the cheating probability.
+	RoundDistributionUniform   = "uniform"
+	RoundDistributionGeometric = "geometric"
 )
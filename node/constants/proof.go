@@ -0,0 +1,40 @@
+package constants
+
+const (
+	// ProofPassphraseEnv is the environment variable with the passphrase that encrypts stored exchanges. If it is not
+	// set, the passphrase is read from the terminal.
+	ProofPassphraseEnv = "P3_PROOF_PASSPHRASE"
+	// ProofKDFArgon2id derives the key of a stored exchange with Argon2id.
+	ProofKDFArgon2id = "argon2id"
+	// Argon2Time, Argon2Memory (KiB) and Argon2Threads are the Argon2id parameters of newly stored exchanges.
+	Argon2Time    = 2
+	Argon2Memory  = 19 * 1024
+	Argon2Threads = 1
+	// Argon2MaxTime, Argon2MaxMemory (KiB) and Argon2MaxThreads bound the parameters read from a stored exchange, so
+	// that a crafted file cannot make the key derivation run for hours or exhaust the memory.
+	Argon2MaxTime    = 16
+	Argon2MaxMemory  = 1024 * 1024
+	Argon2MaxThreads = 16
+	// ProofFileMode is the mode of stored exchanges and ProofDirectoryMode the one of their directory.
+	ProofFileMode      = 0o600
+	ProofDirectoryMode = 0o700
+)
+
+const (
+	// ProofVersion is the version of newly stored exchanges. Exchanges without a version were stored before the proof
+	// contained its metadata; their file name has to end with the pseudonym.
+	ProofVersion = 2
+	// ProofRoleListener and ProofRoleRequester name the party that stored an exchange.
+	ProofRoleListener  = "listener"
+	ProofRoleRequester = "requester"
+	// ProofRoleTranscript is stored by a requester whose exchange stopped before the decryption data arrived, if the
+	// listener escrowed it with an arbiter. ProofRoleResolved is the transcript with the arbiter's resolution.
+	ProofRoleTranscript = "transcript"
+	ProofRoleResolved   = "resolved"
+	// ProofRoleIncomplete is stored by a listener whose real exchange stopped after the encrypted datum was sent but
+	// before the requester acknowledged the real decryption data. It keeps the conversation key, so the data owner
+	// can still find the exchange, and shows what the requester acknowledged.
+	ProofRoleIncomplete = "incomplete"
+	// ProofTimeFormat is the time format at the start of the file name of stored exchanges.
+	ProofTimeFormat = "2006-01-02T15-04-05"
+)
@@ -0,0 +1,12 @@
+package constants
+
+const (
+	// RevoloriDevKeyFilePath is the path to the signing key of the revolori-dev server.
+	RevoloriDevKeyFilePath = "./_revoloriKey.pem"
+	// RevoloriDevUsersPath is the path to the users the revolori-dev server accepts.
+	RevoloriDevUsersPath = "./users.json"
+	// RevoloriDevAddress is the address the revolori-dev server listens on.
+	RevoloriDevAddress = "127.0.0.1:8080"
+	// RevoloriTokenCookie is the name of the cookie that carries a Revolori token.
+	RevoloriTokenCookie = "token"
+)
@@ -4,3 +4,12 @@ const (
 	RSAEncryptionLabel = "P3-RSA-OAEP"
 	RSAKeySize         = 4096
 )
+
+const (
+	// UsageLogVersionRSA encrypts every field of the usage log content with RSA-OAEP. Logs without a version use it.
+	UsageLogVersionRSA = 1
+	// UsageLogVersionHybrid encrypts the usage log content with AES-GCM under a random key wrapped with RSA-OAEP.
+	UsageLogVersionHybrid = 2
+	// UsageLogAdditionalData prefixes the additional data that binds the version and timestamp to the ciphertext.
+	UsageLogAdditionalData = "P3-usage-log"
+)
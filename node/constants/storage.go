@@ -1,6 +1,13 @@
 package constants
 
+import "time"
+
 const (
 	StorageOutputPath = "./storage/"
 	GethAddress       = "http://127.0.0.1:3334"
+	AddressBookPath   = "./addressBook.json"
+	SQLitePath        = "database.db"
 )
+
+// GethRequestTimeout is the maximum time a request to geth may take.
+const GethRequestTimeout = 30 * time.Second
@@ -0,0 +1,50 @@
+package datum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ProviderEcho      = "echo"
+	ProviderDirectory = "directory"
+	ProviderSQLite    = "sqlite"
+	ProviderHTTP      = "http"
+)
+
+// ErrDatumNotFound is returned by a DatumProvider if the requested datum does not exist.
+var ErrDatumNotFound = errors.New("the requested datum does not exist")
+
+// DatumProvider resolves the datum named in FirstMessage.Datum to the content that is sent to the data consumer.
+type DatumProvider interface { //nolint: revive
+	// GetDatum returns the content of the datum with the given name or ErrDatumNotFound if it does not exist.
+	GetDatum(name string) ([]byte, error)
+}
+
+// NewDatumProvider returns the provider of the given type. The meaning of source depends on the provider type: it is
+// the directory for ProviderDirectory, the database file for ProviderSQLite and the callback URL for ProviderHTTP.
+// The table is only used by ProviderSQLite.
+func NewDatumProvider(providerType string, source string, table string) (DatumProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(providerType)) {
+	case ProviderEcho:
+		return EchoProvider{}, nil
+	case ProviderDirectory:
+		return NewDirectoryProvider(source)
+	case ProviderSQLite:
+		return NewSQLiteProvider(source, table)
+	case ProviderHTTP:
+		return NewHTTPProvider(source)
+	default:
+		return nil, fmt.Errorf("datum/NewDatumProvider - Unknown provider type '%s'", providerType)
+	}
+}
+
+// EchoProvider answers every request with the name of the requested datum. It is the behaviour of the PoC and
+// should only be used for testing.
+type EchoProvider struct{}
+
+// GetDatum returns the requested datum's name prefixed with "Requested datum: ".
+func (provider EchoProvider) GetDatum(name string) ([]byte, error) {
+	return []byte("Requested datum: " + name), nil
+}
@@ -0,0 +1,124 @@
+package datum
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"node/random"
+)
+
+const dpRuns = 10
+
+func TestDirectoryProvider(t *testing.T) {
+	directory := t.TempDir()
+	provider, err := NewDatumProvider(ProviderDirectory, directory, "")
+	if err != nil {
+		t.Fatalf("TestDirectoryProvider - Could not create provider: %s\n", err)
+	}
+
+	for i := 0; i < dpRuns; i++ {
+		name := random.String(8)
+		content := []byte(random.String(random.PositiveIntFromRange(1, 256)))
+
+		err = os.WriteFile(filepath.Join(directory, name), content, 0o600)
+		if err != nil {
+			t.Fatalf("TestDirectoryProvider - Could not write datum: %s\n", err)
+		}
+
+		received, err := provider.GetDatum(name)
+		if err != nil {
+			t.Errorf("TestDirectoryProvider - Could not get datum: %s\n", err)
+		}
+
+		if !bytes.Equal(received, content) {
+			t.Errorf("TestDirectoryProvider - Datum does not match\nExpected: %s\nGot: %s\n", content, received)
+		}
+	}
+
+	for _, name := range []string{"", ".", "..", "missing", "../" + filepath.Base(directory), "/etc/passwd"} {
+		_, err = provider.GetDatum(name)
+		if !errors.Is(err, ErrDatumNotFound) {
+			t.Errorf("TestDirectoryProvider - Expected ErrDatumNotFound for '%s', got: %v\n", name, err)
+		}
+	}
+}
+
+func TestSQLiteProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("TestSQLiteProvider - Could not open db: %s\n", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE data (name TEXT PRIMARY KEY, content BLOB)")
+	if err != nil {
+		t.Fatalf("TestSQLiteProvider - Could not create table: %s\n", err)
+	}
+
+	_, err = db.Exec("INSERT INTO data (name, content) VALUES (?, ?)", "heartRate", []byte("72"))
+	if err != nil {
+		t.Fatalf("TestSQLiteProvider - Could not insert datum: %s\n", err)
+	}
+
+	_, err = NewDatumProvider(ProviderSQLite, path, "data; DROP TABLE data")
+	if err == nil {
+		t.Errorf("TestSQLiteProvider - Accepted an invalid table name\n")
+	}
+
+	provider, err := NewDatumProvider(ProviderSQLite, path, "data")
+	if err != nil {
+		t.Fatalf("TestSQLiteProvider - Could not create provider: %s\n", err)
+	}
+
+	received, err := provider.GetDatum("heartRate")
+	if err != nil || string(received) != "72" {
+		t.Errorf("TestSQLiteProvider - Expected '72', got '%s' (%v)\n", received, err)
+	}
+
+	_, err = provider.GetDatum("' OR '1'='1")
+	if !errors.Is(err, ErrDatumNotFound) {
+		t.Errorf("TestSQLiteProvider - Expected ErrDatumNotFound, got: %v\n", err)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("datum") {
+		case "steps":
+			_, _ = w.Write([]byte("10000"))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewDatumProvider(ProviderHTTP, server.URL, "")
+	if err != nil {
+		t.Fatalf("TestHTTPProvider - Could not create provider: %s\n", err)
+	}
+
+	received, err := provider.GetDatum("steps")
+	if err != nil || string(received) != "10000" {
+		t.Errorf("TestHTTPProvider - Expected '10000', got '%s' (%v)\n", received, err)
+	}
+
+	_, err = provider.GetDatum("missing")
+	if !errors.Is(err, ErrDatumNotFound) {
+		t.Errorf("TestHTTPProvider - Expected ErrDatumNotFound, got: %v\n", err)
+	}
+
+	_, err = provider.GetDatum("broken")
+	if err == nil || errors.Is(err, ErrDatumNotFound) {
+		t.Errorf("TestHTTPProvider - Expected a server error, got: %v\n", err)
+	}
+}
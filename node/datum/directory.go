@@ -0,0 +1,58 @@
+package datum
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryProvider serves every regular file in a directory as a datum. The file name is the datum's name.
+type DirectoryProvider struct {
+	path string
+}
+
+// NewDirectoryProvider returns a DirectoryProvider serving the files in path.
+func NewDirectoryProvider(path string) (*DirectoryProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("datum/NewDirectoryProvider - Could not open the datum directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("datum/NewDirectoryProvider - '%s' is not a directory", path)
+	}
+
+	return &DirectoryProvider{
+		path: path,
+	}, nil
+}
+
+// GetDatum reads the file with the given name. Names that point outside the directory are treated as non-existent.
+func (provider *DirectoryProvider) GetDatum(name string) ([]byte, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, ErrDatumNotFound
+	}
+
+	path := filepath.Join(provider.path, name)
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrDatumNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("datum/DirectoryProvider.GetDatum - Could not stat '%s': %w", name, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil, ErrDatumNotFound
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("datum/DirectoryProvider.GetDatum - Could not read '%s': %w", name, err)
+	}
+
+	return content, nil
+}
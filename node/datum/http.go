@@ -0,0 +1,64 @@
+package datum
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const httpProviderTimeout = 5 * time.Second
+
+// HTTPProvider asks a callback URL for the datum. The datum's name is passed as the query parameter "datum".
+// The callback answers with status 200 and the datum as body, or with status 404 if the datum does not exist.
+type HTTPProvider struct {
+	callback *url.URL
+	client   *http.Client
+}
+
+// NewHTTPProvider returns an HTTPProvider using the passed callback URL.
+func NewHTTPProvider(callback string) (*HTTPProvider, error) {
+	parsed, err := url.Parse(callback)
+	if err != nil {
+		return nil, fmt.Errorf("datum/NewHTTPProvider - Could not parse callback URL: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("datum/NewHTTPProvider - Unsupported scheme '%s'", parsed.Scheme)
+	}
+
+	return &HTTPProvider{
+		callback: parsed,
+		client:   &http.Client{Timeout: httpProviderTimeout},
+	}, nil
+}
+
+// GetDatum requests the datum from the callback URL.
+func (provider *HTTPProvider) GetDatum(name string) ([]byte, error) {
+	requestURL := *provider.callback
+	query := requestURL.Query()
+	query.Set("datum", name)
+	requestURL.RawQuery = query.Encode()
+
+	response, err := provider.client.Get(requestURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("datum/HTTPProvider.GetDatum - Could not make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrDatumNotFound
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("datum/HTTPProvider.GetDatum - Could not read body: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("datum/HTTPProvider.GetDatum - Status code of '%d' indicates failure. Body: %s", response.StatusCode, string(body))
+	}
+
+	return body, nil
+}
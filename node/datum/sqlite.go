@@ -0,0 +1,63 @@
+package datum
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	// Needed for sqlite3 driver.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The table name cannot be passed as a statement parameter, thus it is restricted to plain identifiers.
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLiteProvider serves data from a table with the columns name (text, primary key) and content (blob).
+type SQLiteProvider struct {
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+// NewSQLiteProvider opens the database at path and prepares the lookup in the given table.
+func NewSQLiteProvider(path string, table string) (*SQLiteProvider, error) {
+	if !tableNameRegex.MatchString(table) {
+		return nil, fmt.Errorf("datum/NewSQLiteProvider - Invalid table name '%s'", table)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("datum/NewSQLiteProvider - Could not open db: %w", err)
+	}
+
+	stmt, err := db.Prepare("SELECT content FROM " + table + " WHERE name = ?")
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("datum/NewSQLiteProvider - Could not prepare statement: %w", err)
+	}
+
+	return &SQLiteProvider{
+		db:   db,
+		stmt: stmt,
+	}, nil
+}
+
+// GetDatum returns the content of the row with the given name.
+func (provider *SQLiteProvider) GetDatum(name string) ([]byte, error) {
+	var content []byte
+
+	err := provider.stmt.QueryRow(name).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDatumNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("datum/SQLiteProvider.GetDatum - Could not query '%s': %w", name, err)
+	}
+
+	return content, nil
+}
+
+// Close closes the underlying database.
+func (provider *SQLiteProvider) Close() error {
+	_ = provider.stmt.Close()
+	return provider.db.Close()
+}
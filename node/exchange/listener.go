@@ -0,0 +1,499 @@
+// Package exchange implements both sides of the data exchange of the P3 protocol. The listener and requester binaries
+// only find peers and open streams; everything that happens on a stream is done here.
+package exchange
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"node/arbiter"
+	"node/consent"
+	"node/constants"
+	"node/datum"
+	"node/logging"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/policy"
+	"node/random"
+	"node/storage"
+)
+
+// ListenerOptions configures a Listener.
+type ListenerOptions struct {
+	RevoloriPublicKey rsa.PublicKey
+	// PrivateKey is the identity key that belongs to IdentityCards
+	PrivateKey    rsa.PrivateKey
+	IdentityCards p2p.SignedIdentityCards
+	DatumProvider datum.DatumProvider
+	// AccessPolicy decides which requests are served. Every verified requester is allowed if it is nil
+	AccessPolicy *policy.Engine
+	// ConsentAsker asks the data owner before a request is served. Requests are served without asking if it is nil
+	ConsentAsker   consent.Asker
+	ConsentTimeout time.Duration
+	// UsageLogStores receive the usage log of every real exchange
+	UsageLogStores  []storage.UsageLogStore
+	ProofPassphrase []byte
+	// ProofDirectory is where the proofs of non-repudiation are written to. Defaults to constants.StorageOutputPath
+	ProofDirectory string
+	// MaxWaitTime is how long the listener waits for each message of the requester. Defaults to constants.MaxWaitTime
+	MaxWaitTime time.Duration
+	// ArbiterPublicKey is the arbiter the decryption data is escrowed with if a requester asks for it. Nothing is
+	// escrowed if it is nil
+	ArbiterPublicKey *rsa.PublicKey
+	// Rounds are drawn from for every exchange and announced during the handshake. Defaults to nP.DefaultRounds
+	Rounds nP.Rounds
+}
+
+// Listener serves the requests of the data owner it was set up for.
+type Listener struct {
+	options ListenerOptions
+	// arbiterKeyID is empty if no arbiter is configured
+	arbiterKeyID string
+}
+
+// NewListener returns a listener with the given options.
+func NewListener(options ListenerOptions) *Listener {
+	if options.ProofDirectory == "" {
+		options.ProofDirectory = constants.StorageOutputPath
+	}
+
+	if options.MaxWaitTime == 0 {
+		options.MaxWaitTime = constants.MaxWaitTime
+	}
+
+	if !options.Rounds.IsAnnounced() {
+		options.Rounds = nP.DefaultRounds()
+	}
+
+	listener := &Listener{options: options}
+
+	if options.ArbiterPublicKey != nil {
+		keyID, err := arbiter.KeyID(options.ArbiterPublicKey)
+		if err != nil {
+			log.Error.Printf("exchange/NewListener - The decryption data will not be escrowed: %v\n", err)
+		}
+
+		listener.arbiterKeyID = keyID
+	}
+
+	return listener
+}
+
+// HandleStream runs the exchange with the requester on the other end of the stream. Real exchanges end with a stored
+// proof and usage log, fake chatter ends without either. Real exchanges that stop after the encrypted datum was sent
+// are recorded as incomplete. The connection ID only distinguishes the log messages.
+func (listener *Listener) HandleStream(rw *p2p.ReadWriter, connectionID int64) {
+	start := time.Now()
+	lastTimeStamp := start.Unix()
+	// Used to store all signed messages
+	signedMessages := make([]p2p.SignedMessage, 0)
+	// The non-repudiation requirement will be loaded when we know that the exchange is real
+	var requirement nP.NonRepudiationRequirement
+
+	// Agree on the protocol version and algorithms before the identity cards are exchanged
+	_, err := p2p.Handshake(rw, false, &listener.options.Rounds)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Handshake failed: %v\n", connectionID, err)
+		return
+	}
+
+	idVerificationStart := time.Now()
+	// Send own identity card
+	err = p2p.SendSignedIdentityCard(listener.options.IdentityCards, rw)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not send identity card: %v\n", connectionID, err)
+		return
+	}
+
+	// Parse consumer's identity card
+	signedIdentityCard, identityCard, isFakeChatter, err := p2p.ReceiveAndVerifySignedIdentityCard(rw, &listener.options.RevoloriPublicKey)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not parse identity card: %v\n", connectionID, err)
+		return
+	}
+	signedMessages = append(signedMessages, signedIdentityCard)
+	// Identity verification is complete
+	idVerificationDuration := time.Since(idVerificationStart)
+
+	newUsageStart := time.Now()
+	// Receive first message with included datum request
+	var firstMessageRequest p2p.FirstMessage
+	signedMessage, err := p2p.ReceiveAndVerifyFirstMessage(rw, &identityCard.PublicKey, &firstMessageRequest, isFakeChatter, listener.options.MaxWaitTime)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not parse signed first request: %v\n", connectionID, err)
+		return
+	}
+	signedMessages = append(signedMessages, signedMessage)
+
+	// Check if underlying request is valid
+	err = firstMessageRequest.CheckForContentAndJustification()
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Invalid first message: %v\n", connectionID, err)
+		return
+	}
+
+	parameters := rw.Parameters()
+	err = parameters.CheckHandshakeHash(firstMessageRequest.Handshake)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - The request does not belong to the handshake: %v\n", connectionID, err)
+		return
+	}
+
+	if isFakeChatter && firstMessageRequest.Type != constants.MessageTypeFakeChatter {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Identity card is marked as fake chatter (%d), but first message is not (%d)\n", connectionID, constants.MessageTypeFakeChatter, firstMessageRequest.Type)
+		return
+	}
+
+	// Set once the requester acknowledged the decryption data or the usage was logged without it
+	acknowledged := false
+	usageLogged := false
+
+	if !isFakeChatter && listener.options.AccessPolicy != nil {
+		decision := listener.options.AccessPolicy.Evaluate(policy.Request{
+			SSOID:         identityCard.SSOID,
+			Datum:         firstMessageRequest.Datum,
+			Justification: firstMessageRequest.Justification,
+			Time:          time.Now(),
+		})
+
+		if !decision.Allowed {
+			log.Info.Printf("(%d) Denied request of '%s' for datum '%s': %s\n", connectionID, identityCard.SSOID, firstMessageRequest.Datum, decision.Reason)
+			listener.deny(rw, firstMessageRequest.Datum, decision.Reason, connectionID)
+
+			return
+		}
+
+		// Only completed exchanges use up the requester's quota
+		defer func() {
+			if !acknowledged && !usageLogged {
+				listener.options.AccessPolicy.Release(decision)
+			}
+		}()
+	}
+
+	if !isFakeChatter && listener.options.ConsentAsker != nil {
+		approved, reason := listener.askForConsent(rw, identityCard.SSOID, &firstMessageRequest, connectionID)
+		if !approved {
+			log.Info.Printf("(%d) Denied request of '%s' for datum '%s': %s\n", connectionID, identityCard.SSOID, firstMessageRequest.Datum, reason)
+			listener.deny(rw, firstMessageRequest.Datum, reason, connectionID)
+
+			return
+		}
+	}
+
+	// Extract owner's public key that will be used to sign the following messages
+	consumerPublicKey := firstMessageRequest.PublicKey
+
+	var requestedDatum []byte
+	if isFakeChatter {
+		requestedDatum = []byte(random.String(random.PositiveIntFromRange(64, 512)))
+
+		requirement, err = nP.FakeChatterNonRepudiationRequirement(&listener.options.Rounds)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not generate fake requirement: %v\n", connectionID, err)
+			return
+		}
+	} else {
+		requestedDatum, err = listener.options.DatumProvider.GetDatum(firstMessageRequest.Datum)
+		if err != nil {
+			reason := "The requested datum does not exist"
+			if !errors.Is(err, datum.ErrDatumNotFound) {
+				reason = "The requested datum could not be loaded"
+				log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not load datum '%s': %v\n", connectionID, firstMessageRequest.Datum, err)
+			}
+
+			log.Info.Printf("(%d) Refusing request for datum '%s': %s\n", connectionID, firstMessageRequest.Datum, reason)
+
+			refusal := p2p.NewRefusal(firstMessageRequest.Datum, reason, listener.options.PrivateKey.PublicKey)
+			err = p2p.CreateAndSendSignedMessage(refusal, &listener.options.PrivateKey, rw)
+			if err != nil {
+				log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not send refusal: %v\n", connectionID, err)
+			}
+
+			return
+		}
+
+		requirement, err = nP.GenerateNonRepudiationRequirement(&listener.options.Rounds)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not generate real requirement: %v\n", connectionID, err)
+			return
+		}
+	}
+	privateKey := requirement.GetPrivateKey()
+
+	messageCipher, err := requirement.EncryptMessage(requestedDatum)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not encrypt message: %v\n", connectionID, err)
+		return
+	}
+
+	response := p2p.FirstMessage{
+		Datum:     messageCipher,
+		PublicKey: privateKey.PublicKey,
+		Type:      constants.MessageTypeListener,
+		Handshake: rw.HandshakeHash(),
+	}
+
+	// The verifier reads the rounds from the signed response rather than from the stored Hellos
+	if !parameters.IsLegacy() {
+		response.Rounds = &parameters.Rounds
+	}
+
+	if firstMessageRequest.Arbiter != "" {
+		response.Escrow = listener.escrow(&firstMessageRequest, signedMessages[1], &requirement, connectionID)
+	}
+
+	msgOnlyStart := time.Now()
+	// Create and send signed response
+	responseBytes, err := p2p.CreateSendAndReturnSignedMessage(response, &listener.options.PrivateKey, rw)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not send signed first message: %v\n", connectionID, err)
+		return
+	}
+	msgOnlyDuration := time.Since(msgOnlyStart)
+
+	// The requester may hold the datum from now on, so the owner keeps a record even if the exchange stops early
+	if !isFakeChatter {
+		defer func() {
+			if acknowledged {
+				return
+			}
+
+			listener.storeIncomplete(rw, signedMessages, responseBytes, &privateKey, identityCard.SSOID, connectionID)
+
+			// The requester can get the decryption data from the arbiter at any time, so the usage is logged now
+			if response.Escrow != nil {
+				usageLogged = listener.logEscrowedUsage(&firstMessageRequest, &privateKey.PublicKey, connectionID)
+			}
+		}()
+	}
+
+	var ack p2p.Acknowledgement
+
+	signedMessage, err = p2p.ReceiveAndVerifySignedMessage(rw, &consumerPublicKey, &ack, listener.options.MaxWaitTime)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Error handling acknowledgement for the encrypted data: %v\n", connectionID, err)
+		return
+	}
+
+	err = ack.CheckErr(0, lastTimeStamp, responseBytes)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.HandleStream - Invalid acknowledgement: %v\n", connectionID, err)
+		return
+	}
+
+	signedMessages = append(signedMessages, signedMessage)
+	lastTimeStamp = ack.TimeStamp
+
+	var data nP.Data
+	var msg []byte
+	currentID := 1
+	storeAck := false
+
+	for i := 0; i < requirement.GetRepetitions()+1; i++ {
+		if i < requirement.GetRepetitions() {
+			// Get a fake datum
+			data, err = requirement.PopFakeData()
+			if err != nil {
+				log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not pop fake data: %v\n", connectionID, err)
+				return
+			}
+		} else {
+			// Get the real decryption values
+			data = requirement.GetDecryptionValues()
+			storeAck = true
+		}
+		data.Round = currentID
+
+		msg, err = p2p.CreateSendAndReturnSignedMessage(data, &privateKey, rw)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - An error occurred when sending the signed message: %v\n", connectionID, err)
+			return
+		}
+
+		signedMessage, err = p2p.ReceiveAndVerifySignedMessage(rw, &consumerPublicKey, &ack, listener.options.MaxWaitTime)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - An error occurred when handling the received signed message: %v\n", connectionID, err)
+			return
+		}
+
+		// Check acknowledgment validity
+		err = ack.CheckErr(currentID, lastTimeStamp, msg)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Invalid acknowledgement: %v\n", connectionID, err)
+			return
+		}
+
+		lastTimeStamp = ack.TimeStamp
+
+		if storeAck {
+			signedMessages = append(signedMessages, signedMessage)
+			storeAck = false
+		}
+
+		currentID++
+	}
+	acknowledged = true
+
+	newUsageDuration := time.Since(newUsageStart)
+
+	if !isFakeChatter {
+		log.Info.Printf("(%d) Exchange ended successfully\n", connectionID)
+
+		proofStart := time.Now()
+		metadata := storage.ProofMetadata{Role: constants.ProofRoleListener, PeerSSOID: identityCard.SSOID, Parameters: rw.Parameters()}
+		err = storage.StoreExchangeIn(listener.options.ProofDirectory, signedMessages, &privateKey, &listener.options.PrivateKey.PublicKey, metadata, listener.options.ProofPassphrase)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not store data: %v\n", connectionID, err)
+			return
+		}
+		proofDuration := time.Since(proofStart)
+
+		usageLog, err := storage.NewUsageLog(firstMessageRequest.Justification, firstMessageRequest.Datum, &privateKey.PublicKey, &consumerPublicKey)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not create usage log: %v\n", connectionID, err)
+			return
+		}
+
+		exportSummary, err := listener.logUsage(usageLog, connectionID)
+		if err != nil {
+			log.Error.Printf("(%d) exchange/Listener.HandleStream - Could not export the usage log to every store: %v\n", connectionID, err)
+		}
+
+		log.Info.Printf("" +
+			fmt.Sprintf("(%d) Exchange summary\n", connectionID) +
+			fmt.Sprintf("\tDuration of entire exchange: %dms\n", time.Since(start).Milliseconds()) +
+			fmt.Sprintf("\tDuration of id verification: %dms\n", idVerificationDuration.Milliseconds()) +
+			// New usage protocol + breakdown
+			fmt.Sprintf("\tDuration of the new-usage protocol: %dms\n", newUsageDuration.Milliseconds()) +
+			fmt.Sprintf("\t\tNumber of rounds: %d\n", requirement.GetRepetitions()) +
+			fmt.Sprintf("\t\tDuration of only sending requested data: %dms\n", msgOnlyDuration.Milliseconds()) +
+			fmt.Sprintf("\t\tDuration of writing proof of non-repudiation: %dms", proofDuration.Milliseconds()) +
+			// Exports
+			exportSummary,
+		)
+	}
+}
+
+// logUsage appends the usage log to every store, even if some of them fail. It returns the export durations for the
+// exchange summary and the errors of the failed stores.
+func (listener *Listener) logUsage(usageLog storage.BlockchainPayload, connectionID int64) (string, error) {
+	exportSummary := ""
+	var errs []error
+	for _, store := range listener.options.UsageLogStores {
+		log.Info.Printf("(%d) Storing exchange in %s\n", connectionID, store.Name())
+		exportStart := time.Now()
+
+		err := store.Append(usageLog)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not export data to %s: %w", store.Name(), err))
+			exportSummary += fmt.Sprintf("\n\tExport to %s failed", store.Name())
+			continue
+		}
+
+		exportSummary += fmt.Sprintf("\n\tDuration of %s export: %dms", store.Name(), time.Since(exportStart).Milliseconds())
+	}
+
+	return exportSummary, errors.Join(errs...)
+}
+
+// logEscrowedUsage appends the usage log of an incomplete exchange whose decryption data was escrowed with the arbiter.
+// Like the log of a successful exchange, it carries the pseudonym of the conversation key, which is also kept in the
+// incomplete record. It returns whether the usage log was created.
+func (listener *Listener) logEscrowedUsage(request *p2p.FirstMessage, conversationKey *rsa.PublicKey, connectionID int64) bool {
+	usageLog, err := storage.NewUsageLog(request.Justification, request.Datum, conversationKey, &request.PublicKey)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.logEscrowedUsage - Could not create usage log: %v\n", connectionID, err)
+		return false
+	}
+
+	_, err = listener.logUsage(usageLog, connectionID)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.logEscrowedUsage - Could not export the usage log to every store: %v\n", connectionID, err)
+	}
+
+	log.Info.Printf("(%d) The decryption data of the incomplete exchange is escrowed with the arbiter; logged the usage\n", connectionID)
+
+	return true
+}
+
+// storeIncomplete stores the requester's identity card, its request, the signed response and the acknowledgement of
+// the response, if it was received, as the record of an exchange that stopped before the real decryption data was
+// acknowledged.
+func (listener *Listener) storeIncomplete(rw *p2p.ReadWriter, signedMessages []p2p.SignedMessage, responseBytes []byte, privateKey *rsa.PrivateKey, requesterSSOID string, connectionID int64) {
+	var signedResponse p2p.SignedMessage
+	err := rw.Codec().Unmarshal(responseBytes, &signedResponse)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.storeIncomplete - Could not decode the response: %v\n", connectionID, err)
+		return
+	}
+
+	record := make([]p2p.SignedMessage, 0, len(signedMessages)+1)
+	record = append(record, signedMessages[:2]...)
+	record = append(record, signedResponse)
+	record = append(record, signedMessages[2:]...)
+
+	metadata := storage.ProofMetadata{Role: constants.ProofRoleIncomplete, PeerSSOID: requesterSSOID, Parameters: rw.Parameters()}
+	err = storage.StoreExchangeIn(listener.options.ProofDirectory, record, privateKey, &listener.options.PrivateKey.PublicKey, metadata, listener.options.ProofPassphrase)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.storeIncomplete - Could not store the incomplete exchange: %v\n", connectionID, err)
+		return
+	}
+
+	log.Info.Printf("(%d) The exchange with '%s' stopped before the decryption data was acknowledged; stored it as incomplete\n", connectionID, requesterSSOID)
+}
+
+// escrow returns the real decryption data escrowed with the arbiter the requester asked for, or nil if it is not the
+// configured arbiter. Fake chatter is escrowed as well so that it looks like a real exchange.
+func (listener *Listener) escrow(request *p2p.FirstMessage, signedRequest p2p.SignedMessage, requirement *nP.NonRepudiationRequirement, connectionID int64) *p2p.Escrow {
+	if listener.arbiterKeyID == "" || request.Arbiter != listener.arbiterKeyID {
+		log.Info.Printf("(%d) The requester asked for the unknown arbiter '%s'; the decryption data is not escrowed\n", connectionID, request.Arbiter)
+		return nil
+	}
+
+	escrow, err := arbiter.NewEscrow(requirement.GetDecryptionValues(), signedRequest, listener.options.ArbiterPublicKey)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.escrow - Could not escrow the decryption data: %v\n", connectionID, err)
+		return nil
+	}
+
+	return &escrow
+}
+
+// deny tells the requester that the request was denied for the reason.
+func (listener *Listener) deny(rw *p2p.ReadWriter, requestedDatum string, reason string, connectionID int64) {
+	denial := p2p.NewDenial(requestedDatum, reason, listener.options.PrivateKey.PublicKey)
+	err := p2p.CreateAndSendSignedMessage(denial, &listener.options.PrivateKey, rw)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.deny - Could not send denial: %v\n", connectionID, err)
+	}
+}
+
+// askForConsent tells the requester that the owner is being asked and waits for the owner's answer.
+// It returns whether the request was approved and, if not, the reason.
+func (listener *Listener) askForConsent(rw *p2p.ReadWriter, ssoid string, request *p2p.FirstMessage, connectionID int64) (bool, string) {
+	pending := p2p.NewConsentPending(request.Datum, listener.options.PrivateKey.PublicKey)
+	err := p2p.CreateAndSendSignedMessage(pending, &listener.options.PrivateKey, rw)
+	if err != nil {
+		log.Error.Printf("(%d) exchange/Listener.askForConsent - Could not send consent notice: %v\n", connectionID, err)
+		return false, "The data owner could not be asked for consent"
+	}
+
+	approved, err := listener.options.ConsentAsker.Ask(consent.NewRequest(ssoid, request.Datum, request.Justification), listener.options.ConsentTimeout)
+	if err != nil {
+		if !errors.Is(err, consent.ErrTimeOut) {
+			log.Error.Printf("(%d) exchange/Listener.askForConsent - Could not ask for consent: %v\n", connectionID, err)
+			return false, "The data owner could not be asked for consent"
+		}
+
+		return false, "The data owner did not answer in time"
+	}
+
+	if !approved {
+		return false, "The data owner did not consent"
+	}
+
+	return true, ""
+}
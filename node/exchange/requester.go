@@ -0,0 +1,635 @@
+package exchange
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	libPeer "github.com/libp2p/go-libp2p-core/peer"
+	"node"
+	"node/arbiter"
+	"node/constants"
+	"node/logging"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/random"
+	"node/storage"
+)
+
+// MinFakeConnectionCount is the number of fake exchanges after which FakeDone is signalled.
+const MinFakeConnectionCount = 5
+
+// Result is the outcome of the real exchange of a Requester. Value is the datum on success and the listener's reason
+// if it refused or denied the request.
+type Result struct {
+	Value                  string
+	Success                bool
+	SearchRestarts         int
+	ExchangeDuration       time.Duration
+	LoadIDCardDuration     time.Duration
+	HostCreationDuration   time.Duration
+	PeerSearchDuration     time.Duration
+	IDVerificationDuration time.Duration
+	NewUsageMsgDuration    time.Duration
+	DecryptionDuration     time.Duration
+	ProofDuration          time.Duration
+	// Resolvable is set if the exchange stopped early after the listener escrowed the decryption data. The transcript
+	// that the arbiter resolves was stored instead of a proof
+	Resolvable bool
+}
+
+// RequesterOptions configures a Requester.
+type RequesterOptions struct {
+	RevoloriPublicKey rsa.PublicKey
+	// PrivateKey is the identity key that belongs to IdentityCards
+	PrivateKey    rsa.PrivateKey
+	IdentityCards p2p.SignedIdentityCards
+	// SSOID is the listener the datum is requested from
+	SSOID         string
+	Justification string
+	Datum         string
+	// EnableFakeChatter runs fake exchanges with every other listener the requester is connected to
+	EnableFakeChatter bool
+	ProofPassphrase   []byte
+	// ProofDirectory is where the proof of non-repudiation is written to. Defaults to constants.StorageOutputPath
+	ProofDirectory string
+	// AddressBook remembers where the listener was reached. It is not updated if it is nil
+	AddressBook *p2p.AddressBook
+	// MaxWaitTime is how long the requester waits for each message of the listener, and thrice as long for the
+	// encrypted datum. Defaults to constants.MaxWaitTime
+	MaxWaitTime time.Duration
+	// ArbiterPublicKey asks the listener to escrow the decryption data with the arbiter. No escrow is asked for if it
+	// is nil
+	ArbiterPublicKey *rsa.PublicKey
+}
+
+// Requester requests one datum from one listener. Streams to other listeners are used for fake chatter.
+type Requester struct {
+	options RequesterOptions
+	// arbiterKeyID is empty if no arbiter is configured
+	arbiterKeyID string
+
+	done     chan Result
+	fakeDone chan bool
+	failed   chan bool
+
+	// foundMutex protects foundCorrectPeer, which is set while a real exchange runs or after it succeeded
+	foundMutex       sync.Mutex
+	foundCorrectPeer bool
+
+	fakeConnections int32
+}
+
+// NewRequester returns a requester with the given options.
+func NewRequester(options RequesterOptions) *Requester {
+	if options.ProofDirectory == "" {
+		options.ProofDirectory = constants.StorageOutputPath
+	}
+
+	if options.MaxWaitTime == 0 {
+		options.MaxWaitTime = constants.MaxWaitTime
+	}
+
+	requester := &Requester{
+		options:  options,
+		done:     make(chan Result, 1),
+		fakeDone: make(chan bool, 1),
+		failed:   make(chan bool, 1),
+	}
+
+	if options.ArbiterPublicKey != nil {
+		keyID, err := arbiter.KeyID(options.ArbiterPublicKey)
+		if err != nil {
+			log.Error.Printf("exchange/NewRequester - The decryption data will not be escrowed: %s\n", err)
+		}
+
+		requester.arbiterKeyID = keyID
+	}
+
+	return requester
+}
+
+// Done receives the result of the real exchange once it ended, or of the search if Finish was called.
+func (requester *Requester) Done() <-chan Result {
+	return requester.done
+}
+
+// FakeDone is signalled once MinFakeConnectionCount fake exchanges were completed.
+func (requester *Requester) FakeDone() <-chan bool {
+	return requester.fakeDone
+}
+
+// Failed is signalled if the real exchange failed in a way that searching again may fix.
+func (requester *Requester) Failed() <-chan bool {
+	return requester.failed
+}
+
+// FakeConnections returns the number of completed fake exchanges.
+func (requester *Requester) FakeConnections() int32 {
+	return atomic.LoadInt32(&requester.fakeConnections)
+}
+
+// Finish ends the requester with the result, e.g., once the search gave up. Only the first result is kept.
+func (requester *Requester) Finish(result Result) {
+	select {
+	case requester.done <- result:
+	default:
+	}
+}
+
+// HandleStream verifies the listener on the other end of the stream. If it is the requested one, the real exchange
+// runs, otherwise fake chatter if it is enabled. ret carries the durations measured before the stream was opened.
+func (requester *Requester) HandleStream(rw *p2p.ReadWriter, remote libPeer.AddrInfo, peerStart time.Time, peerSearchStart time.Time, ret Result) {
+	if requester.hasFoundCorrectPeer() && !requester.options.EnableFakeChatter {
+		return
+	}
+
+	// This slice will be used to store all signed messages
+	signedMessages := make([]p2p.SignedMessage, 0)
+
+	// Agree on the protocol version and algorithms before the identity cards are exchanged
+	_, err := p2p.Handshake(rw, true, nil)
+	if err != nil {
+		log.Error.Printf("exchange/Requester.HandleStream - Handshake failed: %s\n", err)
+		return
+	}
+
+	// Parse owner's identity card
+	idVerificationStart := time.Now()
+	signedIdentityCard, listenerIdentityCard, isFakeChatter, err := p2p.ReceiveAndVerifySignedIdentityCard(rw, &requester.options.RevoloriPublicKey)
+	if err != nil {
+		log.Error.Printf("exchange/Requester.HandleStream - Could not parse identity card: %s\n", err)
+		return
+	} else if isFakeChatter {
+		log.Error.Printf("exchange/Requester.HandleStream - Owner send ID Card marked as fake chatter?\n")
+		return
+	}
+	signedMessages = append(signedMessages, signedIdentityCard)
+
+	if listenerIdentityCard.SSOID != requester.options.SSOID {
+		if requester.options.EnableFakeChatter {
+			requester.fakeChatter(rw, &listenerIdentityCard)
+		}
+
+		return
+	} else if requester.claimCorrectPeer() {
+		// If this check did not exist, a single data request could lead to multiple usage logs.
+		ret.PeerSearchDuration = time.Since(peerSearchStart)
+		requester.realExchange(rw, remote, &listenerIdentityCard, signedMessages, idVerificationStart, peerStart, ret)
+	}
+}
+
+func (requester *Requester) hasFoundCorrectPeer() bool {
+	requester.foundMutex.Lock()
+	defer requester.foundMutex.Unlock()
+
+	return requester.foundCorrectPeer
+}
+
+// claimCorrectPeer returns true if no other stream runs or ran the real exchange.
+func (requester *Requester) claimCorrectPeer() bool {
+	requester.foundMutex.Lock()
+	defer requester.foundMutex.Unlock()
+
+	if requester.foundCorrectPeer {
+		return false
+	}
+
+	requester.foundCorrectPeer = true
+	return true
+}
+
+func (requester *Requester) realExchange(rw *p2p.ReadWriter, remote libPeer.AddrInfo, listenerIdentityCard *p2p.IdentityCard, signedMessages []p2p.SignedMessage, idVerificationStart time.Time, peerStart time.Time, ret Result) {
+	log.Info.Printf("Found the correct SSOID (%s)!\n", requester.options.SSOID)
+	log.Info.Println("Starting message exchange")
+
+	// Send my (consumer's) identity card
+	err := p2p.SendSignedIdentityCard(requester.options.IdentityCards, rw)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Could not send identity card: %s\n", err)
+		return
+	}
+
+	// Identity verification is complete
+	idVerificationDuration := time.Since(idVerificationStart)
+	log.Info.Println("Identity verification ended successfully")
+
+	// Random RSA key pair that will be used to sign messages after the identity verification
+	newUsageStart := time.Now()
+	privateKey, err := nP.GenerateRSAPrivateKey()
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Could not generate private rsa key\n")
+		return
+	}
+
+	// Send datum request
+	request := p2p.FirstMessage{
+		Datum:         requester.options.Datum,
+		Justification: requester.options.Justification,
+		PublicKey:     privateKey.PublicKey,
+		Type:          constants.MessageTypeRequester,
+		Arbiter:       requester.arbiterKeyID,
+		Handshake:     rw.HandshakeHash(),
+	}
+
+	// The signed request is kept since the escrow is bound to it
+	signedRequest, err := p2p.CreateSignedMessage(request, &requester.options.PrivateKey, rw.Codec())
+	if err == nil {
+		err = p2p.SendSignedMessage(signedRequest, rw)
+	}
+
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Could not send signed first message: %s\n", err)
+		return
+	}
+
+	// Receive the response with the encrypted message
+	// Increased wait longer in case that the encryption or file I/O take some time
+	var firstMessageResponse p2p.FirstMessage
+	signedMessage, err := p2p.ReceiveAndVerifySignedMessage(rw, &listenerIdentityCard.PublicKey, &firstMessageResponse, requester.options.MaxWaitTime*3)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Error receiving the first message: %s\n", err)
+		return
+	}
+
+	if firstMessageResponse.IsConsentPending() {
+		// The listener asks the data owner first, which can take much longer
+		log.Info.Printf("Waiting for the data owner's consent for '%s'\n", firstMessageResponse.Datum)
+
+		firstMessageResponse = p2p.FirstMessage{}
+		signedMessage, err = p2p.ReceiveAndVerifySignedMessage(rw, &listenerIdentityCard.PublicKey, &firstMessageResponse, constants.MaxConsentWaitTime)
+		if err != nil {
+			requester.cleanUpAfterFailure()
+			log.Error.Printf("exchange/Requester.realExchange - Error receiving the first message after consent: %s\n", err)
+			return
+		}
+	}
+
+	if firstMessageResponse.IsRefusal() || firstMessageResponse.IsDenial() {
+		// The listener does not serve the datum. Searching again would not change that.
+		log.Error.Printf("exchange/Requester.realExchange - The listener refused the request for '%s': %s\n", firstMessageResponse.Datum, firstMessageResponse.Justification)
+		ret.Value = firstMessageResponse.Justification
+		ret.Success = false
+		ret.ExchangeDuration = time.Since(peerStart)
+		requester.Finish(ret)
+		return
+	}
+
+	err = firstMessageResponse.CheckForContent()
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Invalid first message: %s\n", err)
+		return
+	}
+
+	parameters := rw.Parameters()
+	err = parameters.CheckHandshakeHash(firstMessageResponse.Handshake)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - The response does not belong to the handshake: %s\n", err)
+		return
+	}
+
+	rounds, err := parameters.SignedRounds(&firstMessageResponse)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Invalid rounds in the response: %s\n", err)
+		return
+	}
+	signedMessages = append(signedMessages, signedMessage)
+	isEscrowed := requester.checkEscrow(&firstMessageResponse, signedRequest)
+
+	// Extract owner's public key that will be used to verify the following messages
+	ownerPublicKey := firstMessageResponse.PublicKey
+
+	// Send acknowledgment for the encrypted data
+	ack, err := createAck(rw, signedMessage, 0)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Could not create first acknowledgement: %s\n", err)
+		return
+	}
+
+	err = p2p.CreateAndSendSignedMessage(ack, &privateKey, rw)
+	if err != nil {
+		requester.cleanUpAfterFailure()
+		log.Error.Printf("exchange/Requester.realExchange - Could not send first acknowledgement: %s\n", err)
+		return
+	}
+
+	// Store all data
+	var data nP.Data
+	var latestSignedMessage p2p.SignedMessage
+
+	for currentID := 1; ; currentID++ {
+		// Read data
+		signedMessage, err = p2p.ReceiveAndVerifySignedMessage(rw, &ownerPublicKey, &data, requester.options.MaxWaitTime)
+		if err != nil {
+			break
+		}
+
+		// Check data validity
+		err = data.CheckErr()
+		if err != nil {
+			requester.cleanUpAfterFailure()
+			log.Error.Printf("exchange/Requester.realExchange - Received invalid data: %s\n", err)
+			return
+		}
+
+		// The listener has to keep to the rounds it announced
+		err = rounds.CheckRound(currentID)
+		if err == nil && rounds.IsAnnounced() && data.Round != currentID {
+			err = fmt.Errorf("the data of round %d is marked as round %d", currentID, data.Round)
+		}
+
+		if err != nil {
+			requester.cleanUpAfterFailure()
+			log.Error.Printf("exchange/Requester.realExchange - The listener does not keep to its rounds: %s\n", err)
+			return
+		}
+
+		latestSignedMessage = signedMessage
+
+		// Send an acknowledgment
+		ack, err = createAck(rw, signedMessage, currentID)
+		if err != nil {
+			requester.cleanUpAfterFailure()
+			log.Error.Printf("exchange/Requester.realExchange - Failed to create an acknowledgement: %s\n", err)
+			return
+		}
+
+		// The data of this round was received, so it is used for decryption even if the listener is gone
+		err = p2p.CreateAndSendSignedMessage(ack, &privateKey, rw)
+		if err != nil {
+			log.Error.Printf("exchange/Requester.realExchange - Failed to send acknowledgment: %s\n", err)
+			break
+		}
+	}
+
+	newUsageMsgDuration := time.Since(newUsageStart)
+
+	// Check if last error was a timeout
+	_, ok := err.(*node.TimeOutError) //nolint:errorlint,ifshort
+	if !ok {
+		// Some other error happened
+		log.Info.Printf("exchange/Requester.realExchange - An error occurred handling the received signed message: %s\n", err)
+		log.Info.Printf("exchange/Requester.realExchange - Attempting to decypher anyway\n")
+	} else {
+		log.Info.Printf("exchange/Requester.realExchange - Experienced a time out. Trying to decrypt the message")
+	}
+
+	// Attempt to decrypt the message using the last data struct
+	decryptionStart := time.Now()
+	plaintext, err := nP.DecryptMessage(&data, firstMessageResponse.Datum)
+	if err != nil {
+		// The listener already stored the exchange, so it must not be repeated
+		log.Error.Printf("exchange/Requester.realExchange - Could not decrypt encrypted message: %s; Protocol failed!\n", err)
+		if isEscrowed {
+			ret.Resolvable = requester.storeTranscript(rw, signedMessages, signedRequest, &privateKey, listenerIdentityCard.SSOID)
+		}
+
+		ret.ExchangeDuration = time.Since(peerStart)
+		requester.Finish(ret)
+		return
+	}
+	decryptionDuration := time.Since(decryptionStart)
+
+	log.Info.Printf("Successfully completed; Message is: '%s'\n", plaintext)
+
+	err = rounds.CheckLastRound(data.Round)
+	if err != nil {
+		log.Error.Printf("exchange/Requester.realExchange - The listener did not keep to its rounds, the proof will not verify: %s\n", err)
+	}
+
+	signedMessages = append(signedMessages, latestSignedMessage)
+
+	proofStart := time.Now()
+	metadata := storage.ProofMetadata{Role: constants.ProofRoleRequester, PeerSSOID: listenerIdentityCard.SSOID, Parameters: rw.Parameters()}
+	err = storage.StoreExchangeIn(requester.options.ProofDirectory, signedMessages, &privateKey, &requester.options.PrivateKey.PublicKey, metadata, requester.options.ProofPassphrase)
+	if err != nil {
+		log.Error.Printf("exchange/Requester.realExchange - Could not store data: %s\n", err)
+		ret.ExchangeDuration = time.Since(peerStart)
+		requester.Finish(ret)
+		return
+	}
+	proofDuration := time.Since(proofStart)
+
+	// Remember where the listener was reached so that it can be dialed directly next time
+	if requester.options.AddressBook != nil {
+		err = requester.options.AddressBook.Remember(requester.options.SSOID, remote)
+		if err != nil {
+			log.Error.Printf("exchange/Requester.realExchange - Could not update the address book: %s\n", err)
+		}
+	}
+
+	ret.Value = plaintext
+	ret.Success = true
+	ret.ExchangeDuration = time.Since(peerStart)
+	ret.IDVerificationDuration = idVerificationDuration
+	ret.NewUsageMsgDuration = newUsageMsgDuration
+	ret.DecryptionDuration = decryptionDuration
+	ret.ProofDuration = proofDuration
+
+	requester.Finish(ret)
+}
+
+// checkEscrow returns true if the listener escrowed the decryption data with the arbiter the requester asked for. The
+// exchange goes on without an escrow otherwise, but cannot be resolved if it stops early.
+func (requester *Requester) checkEscrow(response *p2p.FirstMessage, signedRequest p2p.SignedMessage) bool {
+	if requester.arbiterKeyID == "" {
+		return false
+	}
+
+	switch {
+	case response.Escrow == nil:
+		log.Info.Printf("exchange/Requester.checkEscrow - The listener did not escrow the decryption data\n")
+	case response.Escrow.ArbiterKeyID != requester.arbiterKeyID:
+		log.Error.Printf("exchange/Requester.checkEscrow - The decryption data was escrowed with another arbiter: %s\n", response.Escrow.ArbiterKeyID)
+	case !bytes.Equal(response.Escrow.RequestHash, arbiter.RequestHash(signedRequest)):
+		log.Error.Printf("exchange/Requester.checkEscrow - The escrow was created for another request\n")
+	default:
+		return true
+	}
+
+	return false
+}
+
+// storeTranscript stores the listener's identity card and response together with the own identity card and request,
+// which is what the arbiter needs to release the escrowed decryption data. It returns true if the transcript was
+// stored.
+func (requester *Requester) storeTranscript(rw *p2p.ReadWriter, signedMessages []p2p.SignedMessage, signedRequest p2p.SignedMessage, privateKey *rsa.PrivateKey, listenerSSOID string) bool {
+	transcript := make([]p2p.SignedMessage, 0, arbiter.TranscriptLength)
+	transcript = append(transcript, signedMessages...)
+	transcript = append(transcript, requester.options.IdentityCards[rw.Codec().Name()], signedRequest)
+
+	metadata := storage.ProofMetadata{Role: constants.ProofRoleTranscript, PeerSSOID: listenerSSOID, Parameters: rw.Parameters()}
+	err := storage.StoreExchangeIn(requester.options.ProofDirectory, transcript, privateKey, &requester.options.PrivateKey.PublicKey, metadata, requester.options.ProofPassphrase)
+	if err != nil {
+		log.Error.Printf("exchange/Requester.storeTranscript - Could not store transcript: %s\n", err)
+		return false
+	}
+
+	log.Info.Printf("Stored the transcript of the exchange in %s; it can be resolved by the arbiter\n", requester.options.ProofDirectory)
+	return true
+}
+
+// cleanUpAfterFailure lets another stream run the real exchange and signals Failed so that the search restarts.
+func (requester *Requester) cleanUpAfterFailure() {
+	requester.foundMutex.Lock()
+	requester.foundCorrectPeer = false
+	requester.foundMutex.Unlock()
+
+	select {
+	case requester.failed <- true:
+	default:
+	}
+}
+
+func (requester *Requester) fakeChatter(rw *p2p.ReadWriter, listenerIdentityCard *p2p.IdentityCard) {
+	debugFakeChatter := false
+
+	// Random RSA key pair that will be used to sign all messages
+	privateKey, err := nP.GenerateRSAPrivateKey()
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not generate RSA key: %s\n", err)
+		}
+
+		return
+	}
+
+	// Send an empty identity card
+	err = p2p.SendEmptyIdentityCard(&privateKey, rw)
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not send empty ID card: %s\n", err)
+		}
+
+		return
+	}
+
+	// Send datum request
+	request := p2p.FirstMessage{
+		Datum:         random.String(random.PositiveIntFromRange(16, 64)),
+		Justification: "FakeChatter",
+		PublicKey:     privateKey.PublicKey,
+		Type:          constants.MessageTypeFakeChatter,
+		// Fake chatter asks for the arbiter as well so that it looks like a real exchange
+		Arbiter:   requester.arbiterKeyID,
+		Handshake: rw.HandshakeHash(),
+	}
+
+	err = p2p.CreateAndSendSignedMessage(request, &privateKey, rw)
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not send datum request: %s\n", err)
+		}
+
+		return
+	}
+
+	// Receive the response with the encrypted message
+	// Increased wait longer in case that the encryption or file I/O take some time
+	var firstMessageResponse p2p.FirstMessage
+	signedMessage, err := p2p.ReceiveAndVerifySignedMessage(rw, &listenerIdentityCard.PublicKey, &firstMessageResponse, requester.options.MaxWaitTime*3)
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not handle received first message: %s\n", err)
+		}
+
+		return
+	}
+
+	err = firstMessageResponse.CheckForContent()
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - First message has invalid content: %s\n", err)
+		}
+
+		return
+	}
+
+	// Extract owner's public key that will be used to verify the following messages
+	ownerPublicKey := firstMessageResponse.PublicKey
+
+	// Send acknowledgment for the encrypted data
+	ack, err := createAck(rw, signedMessage, 0)
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not create ack for fist message: %s\n", err)
+		}
+
+		return
+	}
+
+	err = p2p.CreateAndSendSignedMessage(ack, &privateKey, rw)
+	if err != nil {
+		if debugFakeChatter {
+			log.Error.Printf("exchange/Requester.fakeChatter - Could not send ack for first message: %s\n", err)
+		}
+
+		return
+	}
+
+	// Store all data
+	var data nP.Data
+	for currentID := 1; ; currentID++ {
+		// Read data
+		signedMessage, err = p2p.ReceiveAndVerifySignedMessage(rw, &ownerPublicKey, &data, requester.options.MaxWaitTime)
+		if err != nil {
+			_, isTimeOutError := err.(*node.TimeOutError) //nolint:errorlint,ifshort
+			if !isTimeOutError && debugFakeChatter {
+				log.Error.Printf("exchange/Requester.fakeChatter - Could not handle fake decryption data: %s\n", err)
+			}
+
+			break
+		}
+
+		// Send an acknowledgment
+		ack, err = createAck(rw, signedMessage, currentID)
+		if err != nil {
+			if debugFakeChatter {
+				log.Error.Printf("exchange/Requester.fakeChatter - Could not create ack for fake decryption data: %s\n", err)
+			}
+
+			return
+		}
+
+		err = p2p.CreateAndSendSignedMessage(ack, &privateKey, rw)
+		if err != nil {
+			if debugFakeChatter {
+				log.Error.Printf("exchange/Requester.fakeChatter - Could not create ack for fake decryption data: %s\n", err)
+			}
+
+			return
+		}
+	}
+
+	if atomic.AddInt32(&requester.fakeConnections, 1) == MinFakeConnectionCount {
+		select {
+		case requester.fakeDone <- true:
+		default:
+		}
+	}
+}
+
+func createAck(rw *p2p.ReadWriter, signedMessage p2p.SignedMessage, currentID int) (p2p.Acknowledgement, error) {
+	ackContent, err := rw.Codec().Marshal(signedMessage)
+	if err != nil {
+		return p2p.Acknowledgement{}, errors.New("exchange/createAck - Could not marshal received signed message")
+	}
+
+	return p2p.Acknowledgement{
+		ID:        currentID,
+		TimeStamp: time.Now().Unix(),
+		Content:   ackContent,
+	}, nil
+}
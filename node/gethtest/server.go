@@ -0,0 +1,577 @@
+// Package gethtest provides an in-process stand-in for the JSON-RPC API of geth, so code that talks to geth can be
+// tested without a running node.
+package gethtest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// ChainID is the chain ID of every Server.
+	ChainID = 1337
+	// GasPrice is the gas price in wei returned by eth_gasPrice.
+	GasPrice = 1_000_000_000
+)
+
+// BlockReward is credited to the etherbase for every block that is mined while the miner runs.
+var BlockReward = new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18))
+
+// Error codes of the errors returned by the Server.
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Fault changes the responses to a method. It applies to the next Times requests, or to all requests if Times is 0.
+type Fault struct {
+	// Error is returned instead of the result
+	Error *RPCError
+	// WrongID answers with a different ID than the one of the request
+	WrongID bool
+	// StatusCode answers with this HTTP status instead of 200
+	StatusCode int
+	// Delay is waited before the response is sent
+	Delay time.Duration
+	Times int
+}
+
+type request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      int               `json:"id"`
+}
+
+type response struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Result  interface{} `json:"result"`
+	Error   *RPCError   `json:"error,omitempty"`
+}
+
+type block struct {
+	number       uint64
+	hash         common.Hash
+	parentHash   common.Hash
+	transactions []*types.Transaction
+}
+
+// Server keeps an in-memory chain and answers the JSON-RPC methods the storage package uses. Transactions are not
+// executed; they only cost their intrinsic gas. While the miner runs, every request mines a block, so waiting for
+// funds or confirmations makes progress.
+type Server struct {
+	// URL is the endpoint of the server
+	URL string
+
+	server *httptest.Server
+	signer types.Signer
+
+	mutex        sync.Mutex
+	blocks       []*block
+	pending      []*types.Transaction
+	balances     map[common.Address]*big.Int
+	nonces       map[common.Address]uint64
+	transactions map[common.Hash]*types.Transaction
+	// included maps a transaction to the number of its block
+	included  map[common.Hash]uint64
+	etherbase common.Address
+	mining    bool
+	// forks changes the hashes of blocks that replace rewound ones
+	forks  uint64
+	faults map[string]*Fault
+	calls  map[string]int
+}
+
+// NewServer starts a server with the genesis block. Close has to be called to stop it.
+func NewServer() *Server {
+	server := &Server{
+		signer:       types.LatestSignerForChainID(big.NewInt(ChainID)),
+		balances:     make(map[common.Address]*big.Int),
+		nonces:       make(map[common.Address]uint64),
+		transactions: make(map[common.Hash]*types.Transaction),
+		included:     make(map[common.Hash]uint64),
+		faults:       make(map[string]*Fault),
+		calls:        make(map[string]int),
+	}
+
+	server.blocks = []*block{{number: 0, hash: server.blockHash(0, common.Hash{}, nil)}}
+	server.server = httptest.NewServer(http.HandlerFunc(server.handle))
+	server.URL = server.server.URL
+
+	return server
+}
+
+// Close stops the server.
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// Fund adds amount wei to the balance of the address.
+func (server *Server) Fund(address common.Address, amount *big.Int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.balances[address] = new(big.Int).Add(server.balance(address), amount)
+}
+
+// Mine mines a block with the pending transactions, whether the miner runs or not.
+func (server *Server) Mine() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.mine()
+}
+
+// Rewind removes the last count blocks, which simulates a reorganization once new blocks are mined. The transactions
+// of the removed blocks become pending again.
+func (server *Server) Rewind(count int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if count >= len(server.blocks) {
+		count = len(server.blocks) - 1
+	}
+
+	removed := server.blocks[len(server.blocks)-count:]
+	server.blocks = server.blocks[:len(server.blocks)-count]
+	server.forks++
+
+	readded := make([]*types.Transaction, 0)
+	for _, removedBlock := range removed {
+		for _, transaction := range removedBlock.transactions {
+			delete(server.included, transaction.Hash())
+			readded = append(readded, transaction)
+		}
+	}
+	server.pending = append(readded, server.pending...)
+}
+
+// InjectFault applies the fault to the following requests of the method. It replaces an earlier fault of the method.
+func (server *Server) InjectFault(method string, fault Fault) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.faults[method] = &fault
+}
+
+// ClearFaults removes all faults.
+func (server *Server) ClearFaults() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.faults = make(map[string]*Fault)
+}
+
+// Calls returns the number of requests of the method.
+func (server *Server) Calls(method string) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.calls[method]
+}
+
+// BlockNumber returns the number of the latest block.
+func (server *Server) BlockNumber() uint64 {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.latest().number
+}
+
+// Mining returns whether the miner runs.
+func (server *Server) Mining() bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.mining
+}
+
+// ErrNoTransaction is returned by Transaction if the server does not know the transaction.
+var ErrNoTransaction = errors.New("unknown transaction")
+
+// Transaction returns the transaction with the hash and the number of its block. The number is 0 for pending
+// transactions.
+func (server *Server) Transaction(hash common.Hash) (*types.Transaction, uint64, error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	transaction, ok := server.transactions[hash]
+	if !ok {
+		return nil, 0, ErrNoTransaction
+	}
+
+	return transaction, server.included[hash], nil
+}
+
+func (server *Server) handle(writer http.ResponseWriter, httpRequest *http.Request) {
+	body, err := ioutil.ReadAll(httpRequest.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req request
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fault := server.takeFault(req.Method)
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+
+	if fault.StatusCode != 0 {
+		http.Error(writer, "injected fault", fault.StatusCode)
+		return
+	}
+
+	resp := response{Jsonrpc: "2.0", ID: req.ID}
+	if fault.Error != nil {
+		resp.Error = fault.Error
+	} else {
+		resp.Result, resp.Error = server.call(req.Method, req.Params)
+	}
+
+	if fault.WrongID {
+		resp.ID++
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(resp)
+}
+
+// takeFault counts the request and returns the fault that applies to it.
+func (server *Server) takeFault(method string) Fault {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.calls[method]++
+
+	fault, ok := server.faults[method]
+	if !ok {
+		return Fault{}
+	}
+
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(server.faults, method)
+		}
+	}
+
+	return *fault
+}
+
+func (server *Server) call(method string, params []json.RawMessage) (interface{}, *RPCError) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.mining {
+		server.mine()
+	}
+
+	switch method {
+	case "eth_chainId":
+		return hexutil.EncodeUint64(ChainID), nil
+	case "eth_blockNumber":
+		return hexutil.EncodeUint64(server.latest().number), nil
+	case "eth_gasPrice":
+		return hexutil.EncodeUint64(GasPrice), nil
+	case "eth_estimateGas":
+		return server.estimateGas(params)
+	case "eth_getBalance":
+		var address common.Address
+		var tag string
+		if err := decodeParams(params, &address, &tag); err != nil {
+			return nil, err
+		}
+		return (*hexutil.Big)(server.balance(address)), nil
+	case "eth_getTransactionCount":
+		var address common.Address
+		var tag string
+		if err := decodeParams(params, &address, &tag); err != nil {
+			return nil, err
+		}
+		nonce := server.nonces[address]
+		if tag == "pending" {
+			nonce = server.pendingNonce(address)
+		}
+		return hexutil.EncodeUint64(nonce), nil
+	case "eth_sendRawTransaction":
+		return server.sendRawTransaction(params)
+	case "eth_pendingTransactions":
+		pending := make([]map[string]interface{}, len(server.pending))
+		for i, transaction := range server.pending {
+			pending[i] = server.marshalTransaction(transaction)
+		}
+		return pending, nil
+	case "eth_getTransactionByHash":
+		var hash common.Hash
+		if err := decodeParams(params, &hash); err != nil {
+			return nil, err
+		}
+		transaction, ok := server.transactions[hash]
+		if !ok {
+			return nil, nil
+		}
+		return server.marshalTransaction(transaction), nil
+	case "eth_getTransactionReceipt":
+		return server.receipt(params)
+	case "eth_getBlockByNumber":
+		return server.blockByNumber(params)
+	case "miner_setEtherbase":
+		if err := decodeParams(params, &server.etherbase); err != nil {
+			return nil, err
+		}
+		return true, nil
+	case "miner_start":
+		server.mining = true
+		return nil, nil
+	case "miner_stop":
+		server.mining = false
+		return nil, nil
+	default:
+		return nil, &RPCError{Code: codeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", method)}
+	}
+}
+
+func (server *Server) estimateGas(params []json.RawMessage) (interface{}, *RPCError) {
+	var message struct {
+		To   *common.Address `json:"to"`
+		Data hexutil.Bytes   `json:"data"`
+	}
+	if err := decodeParams(params, &message); err != nil {
+		return nil, err
+	}
+
+	gas, err := core.IntrinsicGas(message.Data, nil, message.To == nil, true, true)
+	if err != nil {
+		return nil, &RPCError{Code: codeServerError, Message: err.Error()}
+	}
+
+	return hexutil.EncodeUint64(gas), nil
+}
+
+func (server *Server) sendRawTransaction(params []json.RawMessage) (interface{}, *RPCError) {
+	var raw hexutil.Bytes
+	if err := decodeParams(params, &raw); err != nil {
+		return nil, err
+	}
+
+	transaction := new(types.Transaction)
+	err := transaction.UnmarshalBinary(raw)
+	if err != nil {
+		return nil, &RPCError{Code: codeServerError, Message: err.Error()}
+	}
+
+	sender, err := types.Sender(server.signer, transaction)
+	if err != nil {
+		return nil, &RPCError{Code: codeServerError, Message: "invalid sender: " + err.Error()}
+	}
+
+	if transaction.Nonce() < server.pendingNonce(sender) {
+		return nil, &RPCError{Code: codeServerError, Message: "nonce too low"}
+	} else if transaction.Nonce() > server.pendingNonce(sender) {
+		return nil, &RPCError{Code: codeServerError, Message: "nonce too high"}
+	}
+
+	if server.balance(sender).Cmp(transaction.Cost()) < 0 {
+		return nil, &RPCError{Code: codeServerError, Message: "insufficient funds for gas * price + value"}
+	}
+
+	server.pending = append(server.pending, transaction)
+	server.transactions[transaction.Hash()] = transaction
+
+	return transaction.Hash(), nil
+}
+
+func (server *Server) receipt(params []json.RawMessage) (interface{}, *RPCError) {
+	var hash common.Hash
+	if err := decodeParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	number, ok := server.included[hash]
+	if !ok {
+		return nil, nil
+	}
+
+	transaction := server.transactions[hash]
+	receipt := map[string]interface{}{
+		"transactionHash": hash,
+		"blockNumber":     hexutil.EncodeUint64(number),
+		"blockHash":       server.blocks[number].hash,
+		"status":          hexutil.EncodeUint64(types.ReceiptStatusSuccessful),
+		"contractAddress": nil,
+	}
+
+	if transaction.To() == nil {
+		sender, _ := types.Sender(server.signer, transaction)
+		receipt["contractAddress"] = crypto.CreateAddress(sender, transaction.Nonce())
+	}
+
+	return receipt, nil
+}
+
+func (server *Server) blockByNumber(params []json.RawMessage) (interface{}, *RPCError) {
+	var tag string
+	var full bool
+	if err := decodeParams(params, &tag, &full); err != nil {
+		return nil, err
+	}
+
+	number := server.latest().number
+	if tag != "latest" && tag != "pending" {
+		parsed, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		number = parsed
+	}
+
+	if number >= uint64(len(server.blocks)) {
+		return nil, nil
+	}
+
+	found := server.blocks[number]
+	transactions := make([]interface{}, len(found.transactions))
+	for i, transaction := range found.transactions {
+		if full {
+			transactions[i] = server.marshalTransaction(transaction)
+		} else {
+			transactions[i] = transaction.Hash()
+		}
+	}
+
+	return map[string]interface{}{
+		"number":       hexutil.EncodeUint64(found.number),
+		"hash":         found.hash,
+		"parentHash":   found.parentHash,
+		"transactions": transactions,
+	}, nil
+}
+
+func (server *Server) marshalTransaction(transaction *types.Transaction) map[string]interface{} {
+	sender, _ := types.Sender(server.signer, transaction)
+	marshalled := map[string]interface{}{
+		"hash":        transaction.Hash(),
+		"from":        sender,
+		"to":          transaction.To(),
+		"nonce":       hexutil.EncodeUint64(transaction.Nonce()),
+		"gas":         hexutil.EncodeUint64(transaction.Gas()),
+		"gasPrice":    (*hexutil.Big)(transaction.GasPrice()),
+		"value":       (*hexutil.Big)(transaction.Value()),
+		"input":       hexutil.Bytes(transaction.Data()),
+		"blockNumber": nil,
+	}
+
+	if number, ok := server.included[transaction.Hash()]; ok {
+		marshalled["blockNumber"] = hexutil.EncodeUint64(number)
+	}
+
+	return marshalled
+}
+
+// mine must be called with the mutex locked.
+func (server *Server) mine() {
+	parent := server.latest()
+	mined := &block{number: parent.number + 1, parentHash: parent.hash}
+
+	for _, transaction := range server.pending {
+		sender, _ := types.Sender(server.signer, transaction)
+		if transaction.Nonce() != server.nonces[sender] || server.balance(sender).Cmp(transaction.Cost()) < 0 {
+			// Transactions that became invalid are dropped
+			continue
+		}
+
+		server.balances[sender] = new(big.Int).Sub(server.balance(sender), transaction.Cost())
+		server.nonces[sender]++
+		server.included[transaction.Hash()] = mined.number
+		mined.transactions = append(mined.transactions, transaction)
+	}
+	server.pending = nil
+
+	if server.mining {
+		server.balances[server.etherbase] = new(big.Int).Add(server.balance(server.etherbase), BlockReward)
+	}
+
+	mined.hash = server.blockHash(mined.number, mined.parentHash, mined.transactions)
+	server.blocks = append(server.blocks, mined)
+}
+
+func (server *Server) blockHash(number uint64, parentHash common.Hash, transactions []*types.Transaction) common.Hash {
+	data := make([]byte, 16, 16+len(parentHash)+len(transactions)*common.HashLength)
+	binary.BigEndian.PutUint64(data, number)
+	binary.BigEndian.PutUint64(data[8:], server.forks)
+	data = append(data, parentHash.Bytes()...)
+	for _, transaction := range transactions {
+		data = append(data, transaction.Hash().Bytes()...)
+	}
+
+	return crypto.Keccak256Hash(data)
+}
+
+func (server *Server) latest() *block {
+	return server.blocks[len(server.blocks)-1]
+}
+
+func (server *Server) balance(address common.Address) *big.Int {
+	balance, ok := server.balances[address]
+	if !ok {
+		return new(big.Int)
+	}
+
+	return balance
+}
+
+func (server *Server) pendingNonce(address common.Address) uint64 {
+	nonce := server.nonces[address]
+	for _, transaction := range server.pending {
+		sender, _ := types.Sender(server.signer, transaction)
+		if sender == address {
+			nonce++
+		}
+	}
+
+	return nonce
+}
+
+// decodeParams decodes the positional parameters into targets. Missing parameters keep their value.
+func decodeParams(params []json.RawMessage, targets ...interface{}) *RPCError {
+	if len(params) > len(targets) {
+		return &RPCError{Code: codeInvalidParams, Message: fmt.Sprintf("too many arguments, want at most %d", len(targets))}
+	}
+
+	for i, param := range params {
+		err := json.Unmarshal(param, targets[i])
+		if err != nil {
+			return &RPCError{Code: codeInvalidParams, Message: fmt.Sprintf("invalid argument %d: %s", i, err)}
+		}
+	}
+
+	return nil
+}
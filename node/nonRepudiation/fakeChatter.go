@@ -1,11 +1,8 @@
 package nonRepudiationRequirement
 
 import (
-	"crypto/rand"
 	"fmt"
-	"math/big"
 
-	"node/constants"
 	eR "node/encryption"
 	"node/logging"
 )
@@ -16,15 +13,14 @@ import (
 // For fake chatter, the exchanged datum is a randomly generated string, thus there is no information to be gained by
 // a cheating data consumer. The connection between the data consumer and the data owner is encrypted, thus an
 // eavesdropping attacker would not be able to notice that the encryption requirement is hard coded.
-func FakeChatterNonRepudiationRequirement() (NonRepudiationRequirement, error) {
+// The rounds should be the ones of real exchanges so that fake chatter cannot be told apart by its length.
+func FakeChatterNonRepudiationRequirement(rounds *Rounds) (NonRepudiationRequirement, error) {
 	encryptionRequirement := eR.FakeChatterEncryptionRequirement()
 
-	repetitionsBig, err := rand.Int(rand.Reader, big.NewInt(constants.MaxRepetitions-constants.MinimumRepetitions))
+	repetitions, err := rounds.Draw()
 	if err != nil {
-		return NonRepudiationRequirement{}, fmt.Errorf("nonRepudiation/FakeChatterNonRepudiationRequirement - Could not generate repetitions: %w", err)
+		return NonRepudiationRequirement{}, fmt.Errorf("nonRepudiation/FakeChatterNonRepudiationRequirement - %w", err)
 	}
-	// Cast the big int to int64 to int
-	repetitions := constants.MinimumRepetitions + int(repetitionsBig.Int64())
 
 	falseData := make([]Data, 0, repetitions)
 
@@ -6,7 +6,6 @@ import (
 	"crypto/rsa"
 	"errors"
 	"fmt"
-	"math/big"
 
 	"golang.org/x/crypto/blake2s"
 	"node/constants"
@@ -48,19 +47,18 @@ func DecryptMessage(decryptionData *Data, encryptedMessage string) (string, erro
 	return decrypted, nil
 }
 
-// GenerateNonRepudiationRequirement returns a filled NonRepudiationRequirement struct.
-func GenerateNonRepudiationRequirement() (NonRepudiationRequirement, error) {
+// GenerateNonRepudiationRequirement returns a filled NonRepudiationRequirement struct whose number of fake rounds is
+// drawn from the rounds.
+func GenerateNonRepudiationRequirement(rounds *Rounds) (NonRepudiationRequirement, error) {
 	encryptionRequirement, err := eR.GenerateEncryptionRequirement()
 	if err != nil {
 		return NonRepudiationRequirement{}, fmt.Errorf("nonRepudiation/GenerateNonRepudiationRequirement - %w", err)
 	}
 
-	repetitionsBig, err := rand.Int(rand.Reader, big.NewInt(constants.MaxRepetitions-constants.MinimumRepetitions))
+	repetitions, err := rounds.Draw()
 	if err != nil {
-		return NonRepudiationRequirement{}, fmt.Errorf("nonRepudiation/GenerateNonRepudiationRequirement - Could not generate repetitions: %w", err)
+		return NonRepudiationRequirement{}, fmt.Errorf("nonRepudiation/GenerateNonRepudiationRequirement - %w", err)
 	}
-	// Cast the big int to int64 to int
-	repetitions := constants.MinimumRepetitions + int(repetitionsBig.Int64())
 
 	falseData := make([]Data, 0, repetitions)
 
@@ -22,6 +22,9 @@ type Data struct {
 	PlainPassword []byte `json:"plain_password"`
 	Salt          []byte `json:"salt"`
 	Nonce         []byte `json:"nonce"`
+	// Round is the round the data is sent in. It is set by listeners that announce their rounds, so that a stored
+	// exchange shows how many rounds it took
+	Round int `json:"round,omitempty"`
 }
 
 // EncryptMessage takes a byte array and returns the encrypted hash in a hex representation or error on failure.
@@ -20,8 +20,10 @@ func TestGenerateRSAPrivateKey(t *testing.T) {
 }
 
 func TestEnDecryption(t *testing.T) {
+	rounds := DefaultRounds()
+
 	for i := 0; i < nrRuns; i++ {
-		nRR, err := GenerateNonRepudiationRequirement()
+		nRR, err := GenerateNonRepudiationRequirement(&rounds)
 		if err != nil {
 			t.Fatalf("TestEnDecryption - Could not generate non repudiation requirement: %s\n", err)
 		}
@@ -47,16 +49,18 @@ func TestEnDecryption(t *testing.T) {
 }
 
 func TestGenerateNonRepudiationRequirement(t *testing.T) {
+	rounds := DefaultRounds()
+
 	for i := 0; i < nrRuns*10; i++ {
-		nRR, err := GenerateNonRepudiationRequirement()
+		nRR, err := GenerateNonRepudiationRequirement(&rounds)
 		if err != nil {
 			t.Fatalf("TestGenerateNonRepudiationRequirement - Could not generate non repudiation requirement: %s\n", err)
 		}
 
 		// Assumption that encryptionRequirement is valid due to its testing
 
-		if nRR.repetitions < constants.MinimumRepetitions || nRR.repetitions > constants.MaxRepetitions {
-			t.Errorf("TestGenerateNonRepudiationRequirement - Invalid repetition count: %d, expected a value in [%d, %d]\n", nRR.repetitions, constants.MinimumRepetitions, constants.MaxRepetitions)
+		if nRR.repetitions < constants.DefaultMinimumRepetitions || nRR.repetitions > constants.DefaultMaxRepetitions {
+			t.Errorf("TestGenerateNonRepudiationRequirement - Invalid repetition count: %d, expected a value in [%d, %d]\n", nRR.repetitions, constants.DefaultMinimumRepetitions, constants.DefaultMaxRepetitions)
 		}
 
 		if len(nRR.fakeData) != nRR.repetitions {
@@ -0,0 +1,177 @@
+package nonRepudiationRequirement
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+
+	"node/constants"
+	"node/logging"
+)
+
+// roundTolerance absorbs rounding errors when cheating probabilities are compared.
+const roundTolerance = 1e-9
+
+// Rounds describe how many fake rounds the listener sends before the real decryption data. They are announced by the
+// listener during the handshake, so the requester knows the bounds and the verifier can check a stored exchange.
+// Rounds that were not announced are empty.
+//
+// A requester that wants to cheat has to withhold the acknowledgement of the real decryption data. Since it cannot
+// decrypt the datum before the acknowledgement is due, it has to guess the round. CheatingProbability is the chance
+// of the best guess.
+type Rounds struct {
+	Distribution        string  `json:"distribution"`
+	Minimum             int     `json:"minimum"`
+	Maximum             int     `json:"maximum"`
+	CheatingProbability float64 `json:"cheating_probability"`
+}
+
+// DefaultRounds returns the uniform distribution over [constants.DefaultMinimumRepetitions,
+// constants.DefaultMaxRepetitions].
+func DefaultRounds() Rounds {
+	return Rounds{
+		Distribution:        constants.RoundDistributionUniform,
+		Minimum:             constants.DefaultMinimumRepetitions,
+		Maximum:             constants.DefaultMaxRepetitions,
+		CheatingProbability: constants.DefaultCheatingProbability,
+	}
+}
+
+// NewRounds returns rounds that keep the cheating probability with as few rounds as the distribution allows. The
+// uniform distribution only uses as much of [minimum, maximum] as it needs, the geometric distribution stops after
+// every round above the minimum with the cheating probability and at the maximum at the latest. If the uniform
+// distribution needs a lower maximum than the given one, the lower maximum is used and logged.
+func NewRounds(distribution string, minimum int, maximum int, cheatingProbability float64) (Rounds, error) {
+	rounds := Rounds{
+		Distribution:        distribution,
+		Minimum:             minimum,
+		Maximum:             maximum,
+		CheatingProbability: cheatingProbability,
+	}
+
+	if distribution == constants.RoundDistributionUniform && cheatingProbability > 0 {
+		needed := minimum + int(math.Ceil(1/cheatingProbability-roundTolerance)) - 1
+		if needed > maximum {
+			return Rounds{}, fmt.Errorf("nonRepudiation/NewRounds - A cheating probability of %g needs a maximum of at least %d, got %d", cheatingProbability, needed, maximum)
+		}
+
+		if needed < maximum {
+			log.Info.Printf("nonRepudiation/NewRounds - A cheating probability of %g only needs a maximum of %d, using it instead of %d\n", cheatingProbability, needed, maximum)
+		}
+
+		rounds.Maximum = needed
+	}
+
+	err := rounds.CheckErr()
+	if err != nil {
+		return Rounds{}, fmt.Errorf("nonRepudiation/NewRounds - %w", err)
+	}
+
+	return rounds, nil
+}
+
+// IsAnnounced returns true if the rounds were announced by the listener.
+func (rounds *Rounds) IsAnnounced() bool {
+	return *rounds != Rounds{}
+}
+
+// CheckErr returns an error if the bounds are invalid or the distribution exceeds the cheating probability.
+func (rounds *Rounds) CheckErr() error {
+	if rounds.Distribution != constants.RoundDistributionUniform && rounds.Distribution != constants.RoundDistributionGeometric {
+		return fmt.Errorf("Rounds.CheckErr - Unknown distribution '%s'", rounds.Distribution)
+	}
+
+	if rounds.Minimum < 0 || rounds.Minimum > rounds.Maximum || rounds.Maximum > constants.HighestRepetitions {
+		return fmt.Errorf("Rounds.CheckErr - Invalid bounds [%d, %d]. Expected 0 <= minimum <= maximum <= %d", rounds.Minimum, rounds.Maximum, constants.HighestRepetitions)
+	}
+
+	if rounds.CheatingProbability <= 0 || rounds.CheatingProbability > 1 {
+		return fmt.Errorf("Rounds.CheckErr - Invalid cheating probability %g. Expected a value in (0, 1]", rounds.CheatingProbability)
+	}
+
+	if rounds.actualCheatingProbability() > rounds.CheatingProbability+roundTolerance {
+		return fmt.Errorf("Rounds.CheckErr - The %s distribution over [%d, %d] has a cheating probability of %g, which exceeds %g", rounds.Distribution, rounds.Minimum, rounds.Maximum, rounds.actualCheatingProbability(), rounds.CheatingProbability)
+	}
+
+	return nil
+}
+
+// Draw returns a random number of fake rounds.
+func (rounds *Rounds) Draw() (int, error) {
+	if rounds.Distribution == constants.RoundDistributionGeometric {
+		repetitions := rounds.Minimum
+		for repetitions < rounds.Maximum {
+			stop, err := randomFloat()
+			if err != nil {
+				return 0, fmt.Errorf("Rounds.Draw - Could not generate repetitions: %w", err)
+			}
+
+			if stop < rounds.CheatingProbability {
+				break
+			}
+
+			repetitions++
+		}
+
+		return repetitions, nil
+	}
+
+	repetitionsBig, err := rand.Int(rand.Reader, big.NewInt(int64(rounds.Maximum-rounds.Minimum+1)))
+	if err != nil {
+		return 0, fmt.Errorf("Rounds.Draw - Could not generate repetitions: %w", err)
+	}
+
+	// Cast the big int to int64 to int
+	return rounds.Minimum + int(repetitionsBig.Int64()), nil
+}
+
+// CheckRound returns an error if the rounds do not allow decryption data in the round. Rounds are counted from 1, so
+// the real decryption data of an exchange with n fake rounds is sent in round n+1. Every round is allowed if the
+// rounds were not announced.
+func (rounds *Rounds) CheckRound(round int) error {
+	if !rounds.IsAnnounced() {
+		return nil
+	}
+
+	if round < 1 || round > rounds.Maximum+1 {
+		return fmt.Errorf("Rounds.CheckRound - Round %d is not in [1, %d]", round, rounds.Maximum+1)
+	}
+
+	return nil
+}
+
+// CheckLastRound returns an error if the rounds do not allow the real decryption data in the round.
+func (rounds *Rounds) CheckLastRound(round int) error {
+	if !rounds.IsAnnounced() {
+		return nil
+	}
+
+	if round < rounds.Minimum+1 || round > rounds.Maximum+1 {
+		return fmt.Errorf("Rounds.CheckLastRound - The real decryption data was sent in round %d, which is not in [%d, %d]", round, rounds.Minimum+1, rounds.Maximum+1)
+	}
+
+	return nil
+}
+
+// actualCheatingProbability returns the probability of the most likely number of fake rounds.
+func (rounds *Rounds) actualCheatingProbability() float64 {
+	if rounds.Distribution == constants.RoundDistributionGeometric {
+		// The maximum gets all remaining probability
+		return math.Max(rounds.CheatingProbability, math.Pow(1-rounds.CheatingProbability, float64(rounds.Maximum-rounds.Minimum)))
+	}
+
+	return 1 / float64(rounds.Maximum-rounds.Minimum+1)
+}
+
+// randomFloat returns a uniformly distributed float in [0, 1).
+func randomFloat() (float64, error) {
+	const precision = 1 << 53
+
+	value, err := rand.Int(rand.Reader, big.NewInt(precision))
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(value.Int64()) / precision, nil
+}
@@ -0,0 +1,101 @@
+package nonRepudiationRequirement
+
+import (
+	"testing"
+
+	"node/constants"
+)
+
+func TestNewRounds(t *testing.T) {
+	tests := []struct {
+		distribution        string
+		minimum             int
+		maximum             int
+		cheatingProbability float64
+		expectedMaximum     int
+		valid               bool
+	}{
+		{constants.RoundDistributionUniform, 25, 500, 0.01, 124, true},
+		{constants.RoundDistributionUniform, 10, 10, 1, 10, true},
+		{constants.RoundDistributionUniform, 25, 100, 0.01, 0, false},
+		{constants.RoundDistributionGeometric, 5, 500, 0.05, 500, true},
+		{constants.RoundDistributionGeometric, 5, 20, 0.05, 0, false},
+		{constants.RoundDistributionUniform, 30, 20, 0.5, 0, false},
+		{constants.RoundDistributionGeometric, 1, constants.HighestRepetitions + 1, 0.5, 0, false},
+		{constants.RoundDistributionUniform, 25, 124, 0, 0, false},
+		{"poisson", 25, 124, 0.01, 0, false},
+	}
+
+	for _, test := range tests {
+		rounds, err := NewRounds(test.distribution, test.minimum, test.maximum, test.cheatingProbability)
+		if test.valid && err != nil {
+			t.Errorf("TestNewRounds - Could not create rounds %+v: %s\n", test, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("TestNewRounds - Created invalid rounds %+v\n", test)
+		} else if test.valid && rounds.Maximum != test.expectedMaximum {
+			t.Errorf("TestNewRounds - Unexpected maximum %d, expected %d\n", rounds.Maximum, test.expectedMaximum)
+		}
+	}
+
+	rounds := DefaultRounds()
+	err := rounds.CheckErr()
+	if err != nil {
+		t.Errorf("TestNewRounds - The default rounds are invalid: %s\n", err)
+	}
+}
+
+func TestDrawRounds(t *testing.T) {
+	for _, distribution := range []string{constants.RoundDistributionUniform, constants.RoundDistributionGeometric} {
+		rounds, err := NewRounds(distribution, 3, 200, 0.1)
+		if err != nil {
+			t.Fatalf("TestDrawRounds - Could not create rounds: %s\n", err)
+		}
+
+		for i := 0; i < nrRuns*10; i++ {
+			repetitions, err := rounds.Draw()
+			if err != nil {
+				t.Fatalf("TestDrawRounds - Could not draw: %s\n", err)
+			}
+
+			err = rounds.CheckLastRound(repetitions + 1)
+			if err != nil {
+				t.Errorf("TestDrawRounds - %s: %s\n", distribution, err)
+			}
+		}
+	}
+}
+
+func TestCheckRound(t *testing.T) {
+	rounds := DefaultRounds()
+
+	tests := []struct {
+		round      int
+		validRound bool
+		validLast  bool
+	}{
+		{0, false, false},
+		{1, true, false},
+		{constants.DefaultMinimumRepetitions, true, false},
+		{constants.DefaultMinimumRepetitions + 1, true, true},
+		{constants.DefaultMaxRepetitions + 1, true, true},
+		{constants.DefaultMaxRepetitions + 2, false, false},
+	}
+
+	for _, test := range tests {
+		err := rounds.CheckRound(test.round)
+		if (err == nil) != test.validRound {
+			t.Errorf("TestCheckRound - Round %d: %v\n", test.round, err)
+		}
+
+		err = rounds.CheckLastRound(test.round)
+		if (err == nil) != test.validLast {
+			t.Errorf("TestCheckRound - Last round %d: %v\n", test.round, err)
+		}
+	}
+
+	// Rounds that were not announced allow every round
+	unannounced := Rounds{}
+	if unannounced.CheckRound(0) != nil || unannounced.CheckLastRound(1000) != nil {
+		t.Errorf("TestCheckRound - Rounds that were not announced were checked\n")
+	}
+}
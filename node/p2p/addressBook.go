@@ -0,0 +1,166 @@
+package p2p
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// AddressBook maps SSOIDs to the address a listener was last reached at. It is stored as JSON.
+type AddressBook struct {
+	path    string
+	entries map[string]AddressBookEntry
+	mutex   sync.Mutex
+}
+
+type AddressBookEntry struct {
+	PeerID    string    `json:"peer_id"`
+	Addresses []string  `json:"addresses"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// LoadAddressBook loads the address book at the given path. A missing file results in an empty address book.
+func LoadAddressBook(path string) (*AddressBook, error) {
+	book := &AddressBook{
+		path:    path,
+		entries: make(map[string]AddressBookEntry),
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return book, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("node/LoadAddressBook - Could not read address book: %w", err)
+	}
+
+	err = json.Unmarshal(content, &book.entries)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadAddressBook - Could not unmarshal address book: %w", err)
+	}
+
+	return book, nil
+}
+
+// Lookup returns the address of the listener with the given SSOID.
+func (book *AddressBook) Lookup(ssoid string) (peer.AddrInfo, bool) {
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	entry, ok := book.entries[ssoid]
+	if !ok {
+		return peer.AddrInfo{}, false
+	}
+
+	info, err := entry.addrInfo()
+	if err != nil {
+		return peer.AddrInfo{}, false
+	}
+
+	return info, true
+}
+
+// LookupPeerID returns the addresses stored for the given peer ID.
+func (book *AddressBook) LookupPeerID(id peer.ID) (peer.AddrInfo, bool) {
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	for _, entry := range book.entries {
+		if entry.PeerID != id.String() {
+			continue
+		}
+
+		info, err := entry.addrInfo()
+		if err == nil {
+			return info, true
+		}
+	}
+
+	return peer.AddrInfo{}, false
+}
+
+// Remember stores the address of the listener with the given SSOID and writes the address book to disk.
+func (book *AddressBook) Remember(ssoid string, info peer.AddrInfo) error {
+	addresses := make([]string, 0, len(info.Addrs))
+	for _, address := range info.Addrs {
+		addresses = append(addresses, address.String())
+	}
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	book.entries[ssoid] = AddressBookEntry{
+		PeerID:    info.ID.String(),
+		Addresses: addresses,
+		LastSeen:  time.Now(),
+	}
+
+	content, err := json.MarshalIndent(book.entries, "", "\t")
+	if err != nil {
+		return fmt.Errorf("node/AddressBook.Remember - Could not marshal address book: %w", err)
+	}
+
+	err = os.WriteFile(book.path, content, 0o600)
+	if err != nil {
+		return fmt.Errorf("node/AddressBook.Remember - Could not write address book: %w", err)
+	}
+
+	return nil
+}
+
+// ParsePeer parses a multiaddr that ends in /p2p/<peer ID> or a bare peer ID. A bare peer ID is resolved with the
+// address book.
+func ParsePeer(target string, book *AddressBook) (peer.AddrInfo, error) {
+	target = strings.TrimSpace(target)
+
+	if strings.HasPrefix(target, "/") {
+		address, err := multiaddr.NewMultiaddr(target)
+		if err != nil {
+			return peer.AddrInfo{}, fmt.Errorf("node/ParsePeer - Invalid multiaddr '%s': %w", target, err)
+		}
+
+		info, err := peer.AddrInfoFromP2pAddr(address)
+		if err != nil {
+			return peer.AddrInfo{}, fmt.Errorf("node/ParsePeer - The multiaddr '%s' does not contain a peer ID: %w", target, err)
+		}
+
+		return *info, nil
+	}
+
+	id, err := peer.Decode(target)
+	if err != nil {
+		return peer.AddrInfo{}, fmt.Errorf("node/ParsePeer - Invalid peer ID '%s': %w", target, err)
+	}
+
+	info, ok := book.LookupPeerID(id)
+	if !ok {
+		return peer.AddrInfo{}, fmt.Errorf("node/ParsePeer - The address book has no address for peer '%s'", target)
+	}
+
+	return info, nil
+}
+
+func (entry *AddressBookEntry) addrInfo() (peer.AddrInfo, error) {
+	id, err := peer.Decode(entry.PeerID)
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+
+	info := peer.AddrInfo{ID: id}
+	for _, address := range entry.Addresses {
+		parsed, err := multiaddr.NewMultiaddr(address)
+		if err != nil {
+			return peer.AddrInfo{}, err
+		}
+
+		info.Addrs = append(info.Addrs, parsed)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,64 @@
+package p2p
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestAddressBook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addressBook.json")
+
+	book, err := LoadAddressBook(path)
+	if err != nil {
+		t.Fatalf("TestAddressBook - Could not load a missing address book: %s\n", err)
+	}
+
+	_, publicKey, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("TestAddressBook - Could not generate key: %s\n", err)
+	}
+
+	id, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("TestAddressBook - Could not derive peer ID: %s\n", err)
+	}
+
+	address, _ := multiaddr.NewMultiaddr("/ip4/10.0.0.2/tcp/40000")
+	err = book.Remember("listener@example.com", peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{address}})
+	if err != nil {
+		t.Fatalf("TestAddressBook - Could not remember address: %s\n", err)
+	}
+
+	// The entry has to survive a reload
+	book, err = LoadAddressBook(path)
+	if err != nil {
+		t.Fatalf("TestAddressBook - Could not reload address book: %s\n", err)
+	}
+
+	info, ok := book.Lookup("listener@example.com")
+	if !ok || info.ID != id || len(info.Addrs) != 1 || !info.Addrs[0].Equal(address) {
+		t.Errorf("TestAddressBook - Unexpected entry: %v, %t\n", info, ok)
+	}
+
+	// A bare peer ID is resolved with the address book
+	info, err = ParsePeer(id.String(), book)
+	if err != nil || len(info.Addrs) != 1 {
+		t.Errorf("TestAddressBook - Could not resolve peer ID: %v\n", err)
+	}
+
+	info, err = ParsePeer("/ip4/10.0.0.3/tcp/40000/p2p/"+id.String(), book)
+	if err != nil || info.ID != id || info.Addrs[0].String() != "/ip4/10.0.0.3/tcp/40000" {
+		t.Errorf("TestAddressBook - Could not parse multiaddr: %v, %v\n", info, err)
+	}
+
+	for _, invalid := range []string{"/ip4/10.0.0.3/tcp/40000", "not a peer", "/p2p/QmInvalid"} {
+		_, err = ParsePeer(invalid, book)
+		if err == nil {
+			t.Errorf("TestAddressBook - Accepted invalid target '%s'\n", invalid)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package p2p
 
 import (
-	"crypto/rand"
 	"fmt"
 	"net"
 	"strconv"
@@ -10,22 +9,12 @@ import (
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/multiformats/go-multiaddr"
-	"node/constants"
-	"node/logging"
 )
 
 // Adapted from https://github.com/libp2p/go-libp2p/tree/v0.16.0/examples/chat-with-mdns
 
-// MakeHost returns a p2p host.
-func MakeHost(port int) (host.Host, error) {
-	randomness := rand.Reader
-	// Creates a new RSA key pair for this host.
-	prvKey, _, err := crypto.GenerateKeyPairWithReader(crypto.RSA, constants.RSAKeySize, randomness)
-	if err != nil {
-		log.Info.Println(err)
-		return nil, err
-	}
-
+// MakeHost returns a p2p host whose peer ID is derived from the host key.
+func MakeHost(port int, hostKey crypto.PrivKey) (host.Host, error) {
 	if !portIsFree(port) {
 		return nil, fmt.Errorf("node/MakeHost - Port %d is already in use", port)
 	}
@@ -37,7 +26,16 @@ func MakeHost(port int) (host.Host, error) {
 	// Other options can be added here.
 	return libp2p.New(
 		libp2p.ListenAddrs(sourceMultiAddr),
-		libp2p.Identity(prvKey),
+		libp2p.Identity(hostKey),
+	)
+}
+
+// MakeLoopbackHost returns a p2p host that only listens on a free port of the loopback interface. It is meant for
+// nodes that run in the same process.
+func MakeLoopbackHost(hostKey crypto.PrivKey) (host.Host, error) {
+	return libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.Identity(hostKey),
 	)
 }
 
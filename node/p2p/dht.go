@@ -0,0 +1,194 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	discovery "github.com/libp2p/go-libp2p-discovery"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"node/constants"
+	"node/logging"
+)
+
+// DHTDiscovery finds peers that advertise themselves under a rendezvous namespace of the Kademlia DHT.
+type DHTDiscovery struct {
+	host      host.Host
+	dht       *dht.IpfsDHT
+	routing   *discovery.RoutingDiscovery
+	namespace string
+}
+
+// NewDHTDiscovery joins the DHT via the bootstrap peers. Without bootstrap peers, the host only serves as a bootstrap
+// peer for others.
+func NewDHTDiscovery(ctx context.Context, peerhost host.Host, bootstrapPeers []peer.AddrInfo, namespace string) (*DHTDiscovery, error) {
+	kademlia, err := dht.New(ctx, peerhost,
+		dht.Mode(dht.ModeServer),
+		dht.ProtocolPrefix(constants.DHTProtocolPrefix),
+		dht.BootstrapPeers(bootstrapPeers...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("node/NewDHTDiscovery - Could not create DHT: %w", err)
+	}
+
+	connected := 0
+	for _, bootstrapPeer := range bootstrapPeers {
+		err = peerhost.Connect(ctx, bootstrapPeer)
+		if err != nil {
+			log.Info.Printf("Could not connect to bootstrap peer %s: %s\n", bootstrapPeer.ID, err)
+			continue
+		}
+
+		connected++
+	}
+
+	if len(bootstrapPeers) > 0 && connected == 0 {
+		_ = kademlia.Close()
+		return nil, fmt.Errorf("node/NewDHTDiscovery - Could not connect to any bootstrap peer")
+	}
+
+	err = kademlia.Bootstrap(ctx)
+	if err != nil {
+		_ = kademlia.Close()
+		return nil, fmt.Errorf("node/NewDHTDiscovery - Could not bootstrap DHT: %w", err)
+	}
+
+	return &DHTDiscovery{
+		host:      peerhost,
+		dht:       kademlia,
+		routing:   discovery.NewRoutingDiscovery(kademlia),
+		namespace: namespace,
+	}, nil
+}
+
+// Advertise announces the host under the rendezvous namespace until the context is cancelled.
+func (d *DHTDiscovery) Advertise(ctx context.Context) {
+	discovery.Advertise(ctx, d.routing, d.namespace)
+}
+
+// AdvertiseToken announces the host under the rendezvous token until the context is cancelled.
+func (d *DHTDiscovery) AdvertiseToken(ctx context.Context, token string) {
+	discovery.Advertise(ctx, d.routing, d.tokenNamespace(token))
+}
+
+// FindPeers searches the rendezvous namespace until the context is cancelled. Every peer is sent once.
+func (d *DHTDiscovery) FindPeers(ctx context.Context) chan peer.AddrInfo {
+	return d.findPeersIn(ctx, d.namespace)
+}
+
+// FindToken searches for the peers that advertise the rendezvous token until the context is cancelled.
+func (d *DHTDiscovery) FindToken(ctx context.Context, token string) chan peer.AddrInfo {
+	return d.findPeersIn(ctx, d.tokenNamespace(token))
+}
+
+func (d *DHTDiscovery) tokenNamespace(token string) string {
+	return d.namespace + "/" + token
+}
+
+func (d *DHTDiscovery) findPeersIn(ctx context.Context, namespace string) chan peer.AddrInfo {
+	peerChan := make(chan peer.AddrInfo, 512)
+
+	go func() {
+		seen := make(map[peer.ID]bool)
+
+		for {
+			found, err := d.routing.FindPeers(ctx, namespace)
+			if err != nil {
+				log.Info.Printf("node/DHTDiscovery.FindPeers - %s\n", err)
+			} else {
+				for info := range found {
+					if info.ID == d.host.ID() || len(info.Addrs) == 0 || seen[info.ID] {
+						continue
+					}
+
+					seen[info.ID] = true
+					select {
+					case peerChan <- info:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-time.After(constants.DHTSearchInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return peerChan
+}
+
+// Close leaves the DHT.
+func (d *DHTDiscovery) Close() error {
+	return d.dht.Close()
+}
+
+// ParseBootstrapPeers parses a comma-separated list of multiaddrs that end in /p2p/<peer ID>.
+func ParseBootstrapPeers(list string) ([]peer.AddrInfo, error) {
+	var bootstrapPeers []peer.AddrInfo
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(entry, "/") {
+			return nil, fmt.Errorf("node/ParseBootstrapPeers - '%s' is not a multiaddr", entry)
+		}
+
+		info, err := ParsePeer(entry, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		bootstrapPeers = append(bootstrapPeers, info)
+	}
+
+	return bootstrapPeers, nil
+}
+
+// Discover returns the peers found by the given discovery mechanism. dhtDiscovery is only used for
+// constants.DiscoveryDHT and constants.DiscoveryBoth.
+func Discover(ctx context.Context, peerhost host.Host, mode string, dhtDiscovery *DHTDiscovery) (chan peer.AddrInfo, error) {
+	switch mode {
+	case constants.DiscoveryMDNS:
+		return InitMDNS(peerhost), nil
+	case constants.DiscoveryDHT:
+		return dhtDiscovery.FindPeers(ctx), nil
+	case constants.DiscoveryBoth:
+		return MergePeerChans(ctx, InitMDNS(peerhost), dhtDiscovery.FindPeers(ctx)), nil
+	default:
+		return nil, fmt.Errorf("node/Discover - Unknown discovery mechanism '%s'", mode)
+	}
+}
+
+// MergePeerChans forwards the peers of all channels into one until the context is cancelled.
+func MergePeerChans(ctx context.Context, peerChans ...chan peer.AddrInfo) chan peer.AddrInfo {
+	merged := make(chan peer.AddrInfo, 512)
+
+	for _, peerChan := range peerChans {
+		go func(peerChan chan peer.AddrInfo) {
+			for {
+				select {
+				case info := <-peerChan:
+					select {
+					case merged <- info:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(peerChan)
+	}
+
+	return merged
+}
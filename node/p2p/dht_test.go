@@ -0,0 +1,91 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/constants"
+)
+
+func newLocalHost(t *testing.T) host.Host {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("TestDHTDiscovery - Could not create host: %s\n", err)
+	}
+
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func TestDHTDiscovery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	namespace := "/P3/rendezvous/test"
+
+	// The bootstrap peer only serves the DHT
+	bootstrapHost := newLocalHost(t)
+	bootstrap, err := NewDHTDiscovery(ctx, bootstrapHost, nil, namespace)
+	if err != nil {
+		t.Fatalf("TestDHTDiscovery - Could not start bootstrap peer: %s\n", err)
+	}
+	defer bootstrap.Close()
+
+	bootstrapPeers := []peer.AddrInfo{{ID: bootstrapHost.ID(), Addrs: bootstrapHost.Addrs()}}
+
+	listenerHost := newLocalHost(t)
+	listener, err := NewDHTDiscovery(ctx, listenerHost, bootstrapPeers, namespace)
+	if err != nil {
+		t.Fatalf("TestDHTDiscovery - Could not start listener: %s\n", err)
+	}
+	defer listener.Close()
+	listener.Advertise(ctx)
+
+	requesterHost := newLocalHost(t)
+	requester, err := NewDHTDiscovery(ctx, requesterHost, bootstrapPeers, namespace)
+	if err != nil {
+		t.Fatalf("TestDHTDiscovery - Could not start requester: %s\n", err)
+	}
+	defer requester.Close()
+
+	peerChan, err := Discover(ctx, requesterHost, constants.DiscoveryDHT, requester)
+	if err != nil {
+		t.Fatalf("TestDHTDiscovery - Could not start search: %s\n", err)
+	}
+
+	for {
+		select {
+		case info := <-peerChan:
+			if info.ID == listenerHost.ID() {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatalf("TestDHTDiscovery - The listener was not found\n")
+		}
+	}
+}
+
+func TestParseBootstrapPeers(t *testing.T) {
+	id := "QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"
+
+	bootstrapPeers, err := ParseBootstrapPeers("/ip4/10.0.0.1/tcp/4001/p2p/" + id + ", /dns4/example.com/tcp/4001/p2p/" + id)
+	if err != nil || len(bootstrapPeers) != 2 {
+		t.Errorf("TestParseBootstrapPeers - Could not parse bootstrap peers: %v, %v\n", bootstrapPeers, err)
+	}
+
+	bootstrapPeers, err = ParseBootstrapPeers("")
+	if err != nil || len(bootstrapPeers) != 0 {
+		t.Errorf("TestParseBootstrapPeers - An empty list should be valid: %v, %v\n", bootstrapPeers, err)
+	}
+
+	for _, invalid := range []string{id, "/ip4/10.0.0.1/tcp/4001"} {
+		_, err = ParseBootstrapPeers(invalid)
+		if err == nil {
+			t.Errorf("TestParseBootstrapPeers - '%s' should be invalid\n", invalid)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package p2p
+
+// Escrow is the real decryption data of an exchange encrypted to an arbiter. The listener adds it to its response if
+// the requester asked for the arbiter. If the listener stops before the real decryption data was sent, the arbiter can
+// release it to the requester.
+//
+// The decryption data and a random blinding value are encrypted with AES-GCM under a key wrapped with RSA-OAEP. The
+// commitment is a hash of both, so that anyone can check the released data against the signed response. The hash of
+// the signed request binds the escrow to the exchange it was created for.
+type Escrow struct {
+	// ArbiterKeyID is the pseudonym of the arbiter's public key
+	ArbiterKeyID string `json:"arbiter_key_id"`
+	RequestHash  []byte `json:"request_hash"`
+	Commitment   []byte `json:"commitment"`
+	WrappedKey   []byte `json:"wrapped_key"`
+	Nonce        []byte `json:"nonce"`
+	Ciphertext   []byte `json:"ciphertext"`
+}
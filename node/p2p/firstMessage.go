@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"node/constants"
+	nP "node/nonRepudiation"
 )
 
 type FirstMessage struct {
@@ -13,6 +14,14 @@ type FirstMessage struct {
 	Justification string                `json:"justification"`
 	PublicKey     rsa.PublicKey         `json:"public_key"`
 	Type          constants.MessageType `json:"type"`
+	// Arbiter is the key ID of the arbiter the requester wants the decryption data to be escrowed with
+	Arbiter string `json:"arbiter,omitempty"`
+	// Escrow is only set in the listener's response if the requester asked for an arbiter the listener trusts
+	Escrow *Escrow `json:"escrow,omitempty"`
+	// Handshake is the SessionParameters.HandshakeHash of the session, so the signature covers the negotiation
+	Handshake []byte `json:"handshake,omitempty"`
+	// Rounds are only set in the listener's response and repeat the rounds it announced in its Hello
+	Rounds *nP.Rounds `json:"rounds,omitempty"`
 }
 
 // CheckForContent verifies that the struct's fields are not empty.
@@ -33,6 +42,53 @@ func (message *FirstMessage) CheckForContent() error {
 	return nil
 }
 
+// NewRefusal returns a FirstMessage that informs the requester that the listener will not serve the requested datum.
+// The reason is stored in the justification field.
+func NewRefusal(datum string, reason string, publicKey rsa.PublicKey) FirstMessage {
+	return FirstMessage{
+		Datum:         datum,
+		Justification: reason,
+		PublicKey:     publicKey,
+		Type:          constants.MessageTypeRefusal,
+	}
+}
+
+// NewDenial returns a FirstMessage that informs the requester that the listener's access policy denies the request.
+// The reason is stored in the justification field.
+func NewDenial(datum string, reason string, publicKey rsa.PublicKey) FirstMessage {
+	return FirstMessage{
+		Datum:         datum,
+		Justification: reason,
+		PublicKey:     publicKey,
+		Type:          constants.MessageTypeDenied,
+	}
+}
+
+// NewConsentPending returns a FirstMessage that informs the requester that the listener waits for the data owner's
+// consent. The actual response follows within constants.MaxConsentWaitTime.
+func NewConsentPending(datum string, publicKey rsa.PublicKey) FirstMessage {
+	return FirstMessage{
+		Datum:     datum,
+		PublicKey: publicKey,
+		Type:      constants.MessageTypeConsentPending,
+	}
+}
+
+// IsRefusal returns true if the listener refused to serve the requested datum.
+func (message *FirstMessage) IsRefusal() bool {
+	return message.Type == constants.MessageTypeRefusal
+}
+
+// IsDenial returns true if the listener's access policy denied the request.
+func (message *FirstMessage) IsDenial() bool {
+	return message.Type == constants.MessageTypeDenied
+}
+
+// IsConsentPending returns true if the listener waits for the data owner's consent.
+func (message *FirstMessage) IsConsentPending() bool {
+	return message.Type == constants.MessageTypeConsentPending
+}
+
 func (message *FirstMessage) CheckForContentAndJustification() error {
 	err := message.CheckForContent()
 	if err != nil {
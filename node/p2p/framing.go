@@ -0,0 +1,160 @@
+package p2p
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node"
+	"node/codec"
+	"node/constants"
+)
+
+// ErrFrameTooLarge is returned if a frame exceeds constants.MaxFrameSize.
+var ErrFrameTooLarge = errors.New("frame exceeds the maximum frame size")
+
+// ReadWriter is a buffered stream together with the framing used on it. Streams of constants.P2PProtocolName use
+// length-prefixed frames, streams of constants.P2PLegacyProtocolName use '}'-delimited messages.
+type ReadWriter struct {
+	*bufio.ReadWriter
+	legacy     bool
+	parameters SessionParameters
+	codec      codec.Codec
+	remotePeer peer.ID
+}
+
+// NewReadWriter wraps the stream and selects the framing based on the negotiated protocol ID.
+func NewReadWriter(stream io.ReadWriter, protocolID string) *ReadWriter {
+	rw := &ReadWriter{
+		ReadWriter: bufio.NewReadWriter(bufio.NewReader(stream), bufio.NewWriter(stream)),
+		legacy:     protocolID == constants.P2PLegacyProtocolName,
+		codec:      codec.JSON,
+	}
+
+	if rw.legacy {
+		rw.parameters = LegacyParameters()
+	}
+
+	if libp2pStream, ok := stream.(network.Stream); ok {
+		rw.remotePeer = libp2pStream.Conn().RemotePeer()
+	}
+
+	return rw
+}
+
+// IsLegacy returns true if the stream uses the '}'-delimited framing of constants.P2PLegacyProtocolName.
+func (rw *ReadWriter) IsLegacy() bool {
+	return rw.legacy
+}
+
+// Parameters returns the parameters negotiated by Handshake.
+func (rw *ReadWriter) Parameters() SessionParameters {
+	return rw.parameters
+}
+
+// HandshakeHash returns the hash of the Hellos that the first messages of the session carry.
+func (rw *ReadWriter) HandshakeHash() []byte {
+	return rw.parameters.HandshakeHash()
+}
+
+// Codec returns the codec of the session. Until the handshake is done, it is codec.JSON.
+func (rw *ReadWriter) Codec() codec.Codec {
+	return rw.codec
+}
+
+// WriteFrame writes the data prefixed with its length as an unsigned varint and flushes the ReadWriter.
+func WriteFrame(rw *bufio.ReadWriter, data []byte) error {
+	if len(data) > constants.MaxFrameSize {
+		return fmt.Errorf("node/WriteFrame - Could not write %d bytes: %w", len(data), ErrFrameTooLarge)
+	}
+
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, uint64(len(data)))
+
+	_, err := rw.Write(prefix[:n])
+	if err != nil {
+		return fmt.Errorf("node/WriteFrame - Could not write length prefix: %w", err)
+	}
+
+	_, err = rw.Write(data)
+	if err != nil {
+		return fmt.Errorf("node/WriteFrame - Could not write frame: %w", err)
+	}
+
+	err = rw.Flush()
+	if err != nil {
+		return fmt.Errorf("node/WriteFrame - Could not flush ReadWriter: %w", err)
+	}
+
+	return nil
+}
+
+// ReadFrame reads one length-prefixed frame. If no waitTime is passed then constants.MaxWaitTime is used.
+func ReadFrame(rw *bufio.ReadWriter, waitTime ...time.Duration) ([]byte, error) {
+	timeOut := constants.MaxWaitTime
+	if len(waitTime) > 0 {
+		timeOut = waitTime[0]
+	}
+
+	channelOutput := make(chan []byte, 1)
+	channelError := make(chan error, 1)
+
+	go readFrame(rw, channelOutput, channelError)
+
+	select {
+	case frame := <-channelOutput:
+		return frame, nil
+	case err := <-channelError:
+		return nil, fmt.Errorf("node/ReadFrame - Could not read frame: %w", err)
+	case <-time.After(timeOut):
+		return nil, &node.TimeOutError{
+			MaxWaitTime: timeOut,
+		}
+	}
+}
+
+func readFrame(rw *bufio.ReadWriter, outChan chan []byte, errorChan chan error) {
+	length, err := binary.ReadUvarint(rw)
+	if err != nil {
+		errorChan <- err
+		return
+	}
+
+	if length > constants.MaxFrameSize {
+		errorChan <- fmt.Errorf("announced %d bytes: %w", length, ErrFrameTooLarge)
+		return
+	}
+
+	frame := make([]byte, length)
+	_, err = io.ReadFull(rw, frame)
+	if err != nil {
+		errorChan <- err
+		return
+	}
+
+	outChan <- frame
+}
+
+// readMessage reads one message using the framing of the stream.
+func readMessage(rw *ReadWriter, waitTime ...time.Duration) ([]byte, error) {
+	if rw.legacy {
+		received, err := ReadLine(rw.ReadWriter, '}', waitTime...)
+		return []byte(received), err
+	}
+
+	return ReadFrame(rw.ReadWriter, waitTime...)
+}
+
+// writeMessage writes one message using the framing of the stream.
+func writeMessage(rw *ReadWriter, data []byte) error {
+	if rw.legacy {
+		return Write(rw.ReadWriter, string(data))
+	}
+
+	return WriteFrame(rw.ReadWriter, data)
+}
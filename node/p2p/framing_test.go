@@ -0,0 +1,122 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"node"
+	"node/constants"
+	"node/random"
+)
+
+type nestedMessage struct {
+	Text  string `json:"text"`
+	Inner struct {
+		Values []string `json:"values"`
+	} `json:"inner"`
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestFrameRoundTrip - Could not generate rsa key: %s\n", err)
+	}
+
+	for i := 0; i < runs; i++ {
+		var buffer bytes.Buffer
+		rw := NewReadWriter(&buffer, constants.P2PProtocolName)
+
+		message := nestedMessage{Text: "Justification with } and {" + random.String(random.PositiveIntFromRange(16, 256))}
+		message.Inner.Values = []string{"}", "{}", random.String(32)}
+
+		err = CreateAndSendSignedMessage(message, privateKey, rw)
+		if err != nil {
+			t.Fatalf("TestFrameRoundTrip - Could not send message: %s\n", err)
+		}
+
+		var received nestedMessage
+		_, err = ReceiveAndVerifySignedMessage(rw, &privateKey.PublicKey, &received)
+		if err != nil {
+			t.Fatalf("TestFrameRoundTrip - Could not receive message: %s\n", err)
+		}
+
+		if received.Text != message.Text || len(received.Inner.Values) != len(message.Inner.Values) {
+			t.Errorf("TestFrameRoundTrip - Received message differs: %+v != %+v\n", received, message)
+		}
+	}
+}
+
+func TestLegacyFraming(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestLegacyFraming - Could not generate rsa key: %s\n", err)
+	}
+
+	var buffer bytes.Buffer
+	rw := NewReadWriter(&buffer, constants.P2PLegacyProtocolName)
+	if !rw.IsLegacy() {
+		t.Fatalf("TestLegacyFraming - Stream of %s does not use the legacy framing\n", constants.P2PLegacyProtocolName)
+	}
+
+	ack := Acknowledgement{ID: 1, TimeStamp: time.Now().Unix()}
+	err = CreateAndSendSignedMessage(ack, privateKey, rw)
+	if err != nil {
+		t.Fatalf("TestLegacyFraming - Could not send message: %s\n", err)
+	}
+
+	if buffer.Bytes()[0] != '{' {
+		t.Errorf("TestLegacyFraming - Legacy message is prefixed\n")
+	}
+
+	var received Acknowledgement
+	_, err = ReceiveAndVerifySignedMessage(rw, &privateKey.PublicKey, &received)
+	if err != nil {
+		t.Fatalf("TestLegacyFraming - Could not receive message: %s\n", err)
+	}
+
+	if received.ID != ack.ID {
+		t.Errorf("TestLegacyFraming - Received ID %d instead of %d\n", received.ID, ack.ID)
+	}
+}
+
+func TestFrameLimits(t *testing.T) {
+	var buffer bytes.Buffer
+	rw := NewReadWriter(&buffer, constants.P2PProtocolName)
+
+	err := WriteFrame(rw.ReadWriter, make([]byte, constants.MaxFrameSize+1))
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("TestFrameLimits - Wrote a frame that is too large: %v\n", err)
+	}
+
+	// A peer announcing a frame that is too large must not make us allocate it
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, constants.MaxFrameSize+1)
+	buffer.Write(prefix[:n])
+
+	_, err = ReadFrame(rw.ReadWriter, time.Second)
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("TestFrameLimits - Read a frame that is too large: %v\n", err)
+	}
+
+	_, err = ReadFrame(NewReadWriter(blockingReader{}, constants.P2PProtocolName).ReadWriter, 10*time.Millisecond)
+	var timeOutError *node.TimeOutError
+	if !errors.As(err, &timeOutError) {
+		t.Errorf("TestFrameLimits - Expected a time out, got: %v\n", err)
+	}
+}
+
+// blockingReader never returns any data.
+type blockingReader struct{}
+
+func (blockingReader) Read([]byte) (int, error) {
+	select {}
+}
+
+func (blockingReader) Write(data []byte) (int, error) {
+	return len(data), nil
+}
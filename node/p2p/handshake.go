@@ -0,0 +1,312 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"node/codec"
+	"node/constants"
+	nP "node/nonRepudiation"
+)
+
+// ErrNoCommonParameters is returned if the peers do not share a protocol version or algorithm.
+var ErrNoCommonParameters = errors.New("the peers do not support a common set of parameters")
+
+// Hello advertises the protocol versions and algorithms a node supports. The lists are ordered by preference. Only
+// the listener announces its rounds.
+type Hello struct {
+	Versions   []int      `json:"versions"`
+	Signatures []string   `json:"signatures"`
+	KDFs       []string   `json:"kdfs"`
+	Codecs     []string   `json:"codecs"`
+	Rounds     *nP.Rounds `json:"rounds,omitempty"`
+}
+
+// SessionParameters are the parameters chosen during the handshake. They are stored with the proof of an exchange.
+// Rounds are empty if the listener did not announce them. The Hellos are recorded as they were sent, so a verifier can
+// recalculate the negotiation and the hash that is signed in the first messages.
+type SessionParameters struct {
+	Version        int       `json:"version"`
+	Signature      string    `json:"signature"`
+	KDF            string    `json:"kdf"`
+	Codec          string    `json:"codec"`
+	Rounds         nP.Rounds `json:"rounds"`
+	RequesterHello string    `json:"requester_hello,omitempty"`
+	ListenerHello  string    `json:"listener_hello,omitempty"`
+}
+
+// OwnHello returns the Hello of this node.
+func OwnHello() Hello {
+	return Hello{
+		Versions:   []int{constants.ProtocolVersion},
+		Signatures: []string{constants.SignatureRSAPKCS1v15SHA256},
+		KDFs:       []string{constants.KDFBcryptBlake2s},
+		Codecs:     codec.Names(),
+	}
+}
+
+// LegacyParameters returns the parameters of constants.P2PLegacyProtocolName, which has no handshake.
+func LegacyParameters() SessionParameters {
+	return SessionParameters{
+		Version:   constants.LegacyProtocolVersion,
+		Signature: constants.SignatureRSAPKCS1v15SHA256,
+		KDF:       constants.KDFBcryptBlake2s,
+		Codec:     constants.CodecJSON,
+	}
+}
+
+// Negotiate chooses the highest common version and, for every algorithm, the first entry of the requester's list
+// that the listener supports as well. The rounds are the ones the listener announced. Both sides calculate the same
+// result.
+func Negotiate(requester Hello, listener Hello) (SessionParameters, error) {
+	var parameters SessionParameters
+
+	for _, version := range requester.Versions {
+		if version > parameters.Version && containsInt(listener.Versions, version) {
+			parameters.Version = version
+		}
+	}
+
+	parameters.Signature = firstCommon(requester.Signatures, listener.Signatures)
+	parameters.KDF = firstCommon(requester.KDFs, listener.KDFs)
+	parameters.Codec = firstCommon(requester.Codecs, listener.Codecs)
+
+	if parameters.Version == 0 || parameters.Signature == "" || parameters.KDF == "" || parameters.Codec == "" {
+		return SessionParameters{}, fmt.Errorf("node/Negotiate - %w: %+v", ErrNoCommonParameters, parameters)
+	}
+
+	if listener.Rounds != nil {
+		err := listener.Rounds.CheckErr()
+		if err != nil {
+			return SessionParameters{}, fmt.Errorf("node/Negotiate - The listener announced invalid rounds: %w", err)
+		}
+
+		parameters.Rounds = *listener.Rounds
+	}
+
+	return parameters, nil
+}
+
+// Handshake exchanges Hello messages with the peer and stores the negotiated parameters in the ReadWriter. The
+// requester sends its Hello first and passes no rounds, the listener announces the rounds it draws from. Streams of
+// constants.P2PLegacyProtocolName have no handshake.
+func Handshake(rw *ReadWriter, isRequester bool, rounds *nP.Rounds) (SessionParameters, error) {
+	if rw.legacy {
+		return rw.parameters, nil
+	}
+
+	own := OwnHello()
+	own.Rounds = rounds
+	var peer Hello
+	var ownRaw, peerRaw []byte
+	var err error
+
+	if isRequester {
+		ownRaw, err = sendHello(rw, own)
+		if err == nil {
+			peer, peerRaw, err = receiveHello(rw)
+		}
+	} else {
+		peer, peerRaw, err = receiveHello(rw)
+		if err == nil {
+			ownRaw, err = sendHello(rw, own)
+		}
+	}
+
+	if err != nil {
+		return SessionParameters{}, fmt.Errorf("node/Handshake - %w", err)
+	}
+
+	if isRequester {
+		rw.parameters, err = Negotiate(own, peer)
+		rw.parameters.RequesterHello, rw.parameters.ListenerHello = string(ownRaw), string(peerRaw)
+	} else {
+		rw.parameters, err = Negotiate(peer, own)
+		rw.parameters.RequesterHello, rw.parameters.ListenerHello = string(peerRaw), string(ownRaw)
+	}
+
+	if err != nil {
+		return SessionParameters{}, err
+	}
+
+	rw.codec, err = codec.Get(rw.parameters.Codec)
+
+	return rw.parameters, err
+}
+
+// CheckSupported returns an error if this node cannot verify an exchange that used the parameters.
+func (parameters *SessionParameters) CheckSupported() error {
+	own := OwnHello()
+
+	if !parameters.IsLegacy() && !containsInt(own.Versions, parameters.Version) {
+		return fmt.Errorf("node/CheckSupported - Unsupported protocol version %d", parameters.Version)
+	} else if !containsString(own.Signatures, parameters.Signature) {
+		return fmt.Errorf("node/CheckSupported - Unsupported signature algorithm '%s'", parameters.Signature)
+	} else if !containsString(own.KDFs, parameters.KDF) {
+		return fmt.Errorf("node/CheckSupported - Unsupported KDF '%s'", parameters.KDF)
+	} else if !containsString(own.Codecs, parameters.Codec) {
+		return fmt.Errorf("node/CheckSupported - Unsupported codec '%s'", parameters.Codec)
+	}
+
+	if parameters.Rounds.IsAnnounced() {
+		err := parameters.Rounds.CheckErr()
+		if err != nil {
+			return fmt.Errorf("node/CheckSupported - Invalid rounds: %w", err)
+		}
+	}
+
+	return parameters.checkHellos()
+}
+
+// HandshakeHash returns the hash of both Hellos that the first messages of the exchange carry. Legacy sessions have no
+// handshake, so it is nil for them.
+func (parameters *SessionParameters) HandshakeHash() []byte {
+	if parameters.IsLegacy() {
+		return nil
+	}
+
+	hash := sha256.New()
+
+	length := make([]byte, 4)
+	for _, hello := range []string{parameters.RequesterHello, parameters.ListenerHello} {
+		binary.BigEndian.PutUint32(length, uint32(len(hello)))
+		hash.Write(length)
+		hash.Write([]byte(hello))
+	}
+
+	return hash.Sum(nil)
+}
+
+// CheckHandshakeHash returns an error if the hash a peer signed in its first message does not belong to the Hellos of
+// the session.
+func (parameters *SessionParameters) CheckHandshakeHash(signed []byte) error {
+	if !bytes.Equal(signed, parameters.HandshakeHash()) {
+		return errors.New("node/CheckHandshakeHash - The signed handshake hash does not match the Hellos of the session")
+	}
+
+	return nil
+}
+
+// SignedRounds returns the rounds the listener signed in its response, which have to be the negotiated ones. Legacy
+// sessions have no rounds.
+func (parameters *SessionParameters) SignedRounds(response *FirstMessage) (nP.Rounds, error) {
+	if parameters.IsLegacy() {
+		if response.Rounds != nil {
+			return nP.Rounds{}, errors.New("node/SignedRounds - The response of a legacy session contains rounds")
+		}
+
+		return parameters.Rounds, nil
+	}
+
+	if response.Rounds == nil {
+		return nP.Rounds{}, errors.New("node/SignedRounds - The response does not contain the rounds")
+	}
+
+	err := response.Rounds.CheckErr()
+	if err != nil {
+		return nP.Rounds{}, fmt.Errorf("node/SignedRounds - Invalid rounds: %w", err)
+	} else if *response.Rounds != parameters.Rounds {
+		return nP.Rounds{}, fmt.Errorf("node/SignedRounds - The response contains the rounds %+v, but %+v were announced", *response.Rounds, parameters.Rounds)
+	}
+
+	return *response.Rounds, nil
+}
+
+// IsLegacy returns true if the session used constants.P2PLegacyProtocolName, which has no handshake.
+func (parameters *SessionParameters) IsLegacy() bool {
+	return parameters.Version == constants.LegacyProtocolVersion
+}
+
+// checkHellos recalculates the negotiation from the recorded Hellos. Every session but a legacy one must have recorded
+// them.
+func (parameters *SessionParameters) checkHellos() error {
+	if parameters.RequesterHello == "" && parameters.ListenerHello == "" {
+		if !parameters.IsLegacy() {
+			return errors.New("node/CheckSupported - The Hellos of the session are missing")
+		}
+
+		return nil
+	}
+
+	var requester, listener Hello
+	err := json.Unmarshal([]byte(parameters.RequesterHello), &requester)
+	if err != nil {
+		return fmt.Errorf("node/CheckSupported - Could not unmarshal the requester's hello: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(parameters.ListenerHello), &listener)
+	if err != nil {
+		return fmt.Errorf("node/CheckSupported - Could not unmarshal the listener's hello: %w", err)
+	}
+
+	negotiated, err := Negotiate(requester, listener)
+	if err != nil {
+		return fmt.Errorf("node/CheckSupported - %w", err)
+	}
+
+	negotiated.RequesterHello, negotiated.ListenerHello = parameters.RequesterHello, parameters.ListenerHello
+	if negotiated != *parameters {
+		return errors.New("node/CheckSupported - The parameters were not negotiated from the recorded Hellos")
+	}
+
+	return nil
+}
+
+func sendHello(rw *ReadWriter, hello Hello) ([]byte, error) {
+	js, err := json.Marshal(hello)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal hello: %w", err)
+	}
+
+	return js, writeMessage(rw, js)
+}
+
+func receiveHello(rw *ReadWriter) (Hello, []byte, error) {
+	received, err := readMessage(rw, 10*time.Second)
+	if err != nil {
+		return Hello{}, nil, fmt.Errorf("could not read hello: %w", err)
+	}
+
+	var hello Hello
+	err = json.Unmarshal(received, &hello)
+	if err != nil {
+		return Hello{}, nil, fmt.Errorf("could not unmarshal hello: %w", err)
+	}
+
+	return hello, received, nil
+}
+
+func firstCommon(preferred []string, supported []string) string {
+	for _, entry := range preferred {
+		if containsString(supported, entry) {
+			return entry
+		}
+	}
+
+	return ""
+}
+
+func containsInt(list []int, value int) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
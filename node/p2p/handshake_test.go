@@ -0,0 +1,205 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net"
+	"testing"
+
+	"node/constants"
+	nP "node/nonRepudiation"
+)
+
+func TestNegotiate(t *testing.T) {
+	requester := Hello{
+		Versions:   []int{2, 3},
+		Signatures: []string{"ed25519", constants.SignatureRSAPKCS1v15SHA256},
+		KDFs:       []string{constants.KDFBcryptBlake2s},
+		Codecs:     []string{"cbor", constants.CodecJSON},
+	}
+
+	parameters, err := Negotiate(requester, OwnHello())
+	if err != nil {
+		t.Fatalf("TestNegotiate - Could not negotiate: %s\n", err)
+	}
+
+	expected := SessionParameters{
+		Version:   constants.ProtocolVersion,
+		Signature: constants.SignatureRSAPKCS1v15SHA256,
+		KDF:       constants.KDFBcryptBlake2s,
+		Codec:     constants.CodecCBOR,
+	}
+	if parameters != expected {
+		t.Errorf("TestNegotiate - Expected %+v, got %+v\n", expected, parameters)
+	}
+
+	// The listener's rounds are part of the parameters if they are valid
+	listener := OwnHello()
+	rounds := nP.DefaultRounds()
+	listener.Rounds = &rounds
+
+	parameters, err = Negotiate(requester, listener)
+	if err != nil || parameters.Rounds != rounds {
+		t.Errorf("TestNegotiate - Unexpected rounds %+v: %v\n", parameters.Rounds, err)
+	}
+
+	rounds.Maximum = rounds.Minimum
+	_, err = Negotiate(requester, listener)
+	if err == nil {
+		t.Errorf("TestNegotiate - Negotiated rounds that exceed the cheating probability\n")
+	}
+
+	// Only the current version is negotiated, so a peer cannot fall back to a session without the handshake hash
+	_, err = Negotiate(Hello{Versions: []int{2}, Signatures: requester.Signatures, KDFs: requester.KDFs, Codecs: requester.Codecs}, OwnHello())
+	if !errors.Is(err, ErrNoCommonParameters) {
+		t.Errorf("TestNegotiate - Negotiated version 2: %v\n", err)
+	}
+
+	requester.Codecs = []string{"protobuf"}
+	_, err = Negotiate(requester, OwnHello())
+	if !errors.Is(err, ErrNoCommonParameters) {
+		t.Errorf("TestNegotiate - Negotiated without a common codec: %v\n", err)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	requesterConn, listenerConn := net.Pipe()
+	defer requesterConn.Close()
+	defer listenerConn.Close()
+
+	requesterRW := NewReadWriter(requesterConn, constants.P2PProtocolName)
+	listenerRW := NewReadWriter(listenerConn, constants.P2PProtocolName)
+
+	rounds := nP.DefaultRounds()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Handshake(listenerRW, false, &rounds)
+		done <- err
+	}()
+
+	parameters, err := Handshake(requesterRW, true, nil)
+	if err != nil {
+		t.Fatalf("TestHandshake - Requester handshake failed: %s\n", err)
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("TestHandshake - Listener handshake failed: %s\n", err)
+	}
+
+	if parameters != listenerRW.Parameters() {
+		t.Errorf("TestHandshake - Peers chose different parameters: %+v != %+v\n", parameters, listenerRW.Parameters())
+	}
+
+	if parameters.Rounds != rounds {
+		t.Errorf("TestHandshake - The listener's rounds were not announced: %+v\n", parameters.Rounds)
+	}
+
+	// The recorded Hellos reproduce the negotiation and their hash is what the first messages carry
+	err = parameters.CheckSupported()
+	if err != nil {
+		t.Errorf("TestHandshake - The negotiated parameters are not supported: %s\n", err)
+	}
+
+	err = parameters.CheckHandshakeHash(requesterRW.HandshakeHash())
+	if err != nil || len(requesterRW.HandshakeHash()) == 0 {
+		t.Errorf("TestHandshake - Unexpected handshake hash: %v\n", err)
+	}
+
+	tampered := []func(*SessionParameters){
+		func(p *SessionParameters) { p.Codec = constants.CodecJSON },
+		func(p *SessionParameters) { p.Rounds = nP.Rounds{} },
+		func(p *SessionParameters) { p.RequesterHello, p.ListenerHello = "", "" },
+		func(p *SessionParameters) { p.ListenerHello = p.RequesterHello },
+	}
+
+	for i, tamper := range tampered {
+		changed := parameters
+		tamper(&changed)
+
+		if changed.CheckSupported() == nil {
+			t.Errorf("TestHandshake - Case %d: Tampered parameters are supported: %+v\n", i, changed)
+		}
+	}
+
+	// Relabelling a recorded session as legacy does not remove the hash from the signed first messages
+	downgraded := parameters
+	downgraded.Version = constants.LegacyProtocolVersion
+	downgraded.RequesterHello, downgraded.ListenerHello = "", ""
+	if downgraded.CheckSupported() != nil || downgraded.CheckHandshakeHash(parameters.HandshakeHash()) == nil {
+		t.Errorf("TestHandshake - The hash of the session matches a downgraded session\n")
+	}
+
+	if requesterRW.Codec().Name() != constants.CodecCBOR {
+		t.Errorf("TestHandshake - Expected the session to use %s, got %s\n", constants.CodecCBOR, requesterRW.Codec().Name())
+	}
+
+	// Messages of the session are encoded with the negotiated codec
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestHandshake - Could not generate rsa key: %s\n", err)
+	}
+
+	request := NewRefusal("heartRate", "Justification with }", privateKey.PublicKey)
+	go func() {
+		done <- CreateAndSendSignedMessage(request, privateKey, requesterRW)
+	}()
+
+	var received FirstMessage
+	signedMessage, err := ReceiveAndVerifySignedMessage(listenerRW, &privateKey.PublicKey, &received)
+	if err != nil || <-done != nil {
+		t.Fatalf("TestHandshake - Could not exchange a message: %v\n", err)
+	}
+
+	if received.Justification != request.Justification || !received.PublicKey.Equal(&request.PublicKey) {
+		t.Errorf("TestHandshake - Received message differs: %+v\n", received)
+	}
+
+	if signedMessage.Content[0] == '{' {
+		t.Errorf("TestHandshake - Message was encoded as JSON\n")
+	}
+
+	legacy := NewReadWriter(requesterConn, constants.P2PLegacyProtocolName)
+	parameters, err = Handshake(legacy, true, nil)
+	if err != nil || parameters != LegacyParameters() {
+		t.Errorf("TestHandshake - Legacy stream did not use the legacy parameters: %+v, %v\n", parameters, err)
+	}
+}
+
+func TestSignedRounds(t *testing.T) {
+	rounds := nP.DefaultRounds()
+	parameters := SessionParameters{Version: constants.ProtocolVersion, Rounds: rounds}
+
+	signed, err := parameters.SignedRounds(&FirstMessage{Rounds: &rounds})
+	if err != nil || signed != rounds {
+		t.Errorf("TestSignedRounds - Unexpected rounds %+v: %v\n", signed, err)
+	}
+
+	otherRounds := rounds
+	otherRounds.Maximum++
+	legacy := SessionParameters{Version: constants.LegacyProtocolVersion, Rounds: rounds}
+
+	tests := []struct {
+		parameters SessionParameters
+		response   FirstMessage
+	}{
+		{parameters, FirstMessage{}},
+		{parameters, FirstMessage{Rounds: &otherRounds}},
+		{parameters, FirstMessage{Rounds: &nP.Rounds{}}},
+		{legacy, FirstMessage{Rounds: &rounds}},
+	}
+
+	for i, test := range tests {
+		_, err = test.parameters.SignedRounds(&test.response)
+		if err == nil {
+			t.Errorf("TestSignedRounds - Case %d: Accepted the rounds of the response\n", i)
+		}
+	}
+
+	// Legacy sessions have no signed rounds
+	signed, err = legacy.SignedRounds(&FirstMessage{})
+	if err != nil || signed != rounds {
+		t.Errorf("TestSignedRounds - Unexpected rounds of an earlier version %+v: %v\n", signed, err)
+	}
+}
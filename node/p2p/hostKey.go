@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"node/constants"
+	"node/logging"
+)
+
+// LoadOrCreateHostKey loads the libp2p host key at the given path. If there is none, a key of the given type is
+// created and stored. An existing key is always used, even if it has a different type, so that the peer ID stays the
+// same.
+func LoadOrCreateHostKey(path string, keyType string) (crypto.PrivKey, error) {
+	content, err := os.ReadFile(path)
+	if err == nil {
+		return parseHostKey(content)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Could not read host key: %w", err)
+	}
+
+	var privateKey crypto.PrivKey
+	switch keyType {
+	case constants.HostKeyRSA:
+		privateKey, _, err = crypto.GenerateKeyPairWithReader(crypto.RSA, constants.RSAKeySize, rand.Reader)
+	case constants.HostKeyEd25519:
+		privateKey, _, err = crypto.GenerateEd25519Key(rand.Reader)
+	default:
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Unknown host key type '%s'", keyType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Could not create host key: %w", err)
+	}
+
+	marshalled, err := crypto.MarshalPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Could not marshal host key: %w", err)
+	}
+
+	encoded := pem.EncodeToMemory(&pem.Block{Type: constants.HostKeyPemName, Bytes: marshalled})
+	err = os.WriteFile(path, encoded, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Could not write host key: %w", err)
+	}
+
+	log.Info.Printf("Created a new %s host key\n", keyType)
+	return privateKey, nil
+}
+
+func parseHostKey(content []byte) (crypto.PrivKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != constants.HostKeyPemName {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - The host key file does not contain a %s", constants.HostKeyPemName)
+	}
+
+	privateKey, err := crypto.UnmarshalPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateHostKey - Could not unmarshal host key: %w", err)
+	}
+
+	return privateKey, nil
+}
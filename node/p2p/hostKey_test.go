@@ -0,0 +1,68 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/codec"
+	"node/constants"
+)
+
+func TestLoadOrCreateHostKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_hostKey.pem")
+
+	created, err := LoadOrCreateHostKey(path, constants.HostKeyEd25519)
+	if err != nil {
+		t.Fatalf("TestLoadOrCreateHostKey - Could not create host key: %s\n", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("TestLoadOrCreateHostKey - The host key should only be readable by the owner: %v, %v\n", info, err)
+	}
+
+	// The stored key wins over the requested type so that the peer ID stays the same
+	loaded, err := LoadOrCreateHostKey(path, constants.HostKeyRSA)
+	if err != nil {
+		t.Fatalf("TestLoadOrCreateHostKey - Could not load host key: %s\n", err)
+	}
+
+	if !created.Equals(loaded) || loaded.Type() != crypto.Ed25519 {
+		t.Errorf("TestLoadOrCreateHostKey - The loaded key differs from the created one\n")
+	}
+
+	_, err = LoadOrCreateHostKey(filepath.Join(t.TempDir(), "_hostKey.pem"), "dsa")
+	if err == nil {
+		t.Errorf("TestLoadOrCreateHostKey - An unknown key type should be rejected\n")
+	}
+}
+
+func TestBoundPeerID(t *testing.T) {
+	_, publicKey, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("TestBoundPeerID - Could not generate key: %s\n", err)
+	}
+
+	id, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("TestBoundPeerID - Could not derive peer ID: %s\n", err)
+	}
+
+	for _, messageCodec := range []codec.Codec{codec.JSON, codec.CBOR} {
+		bound, _ := messageCodec.Marshal(ExtendedSignedMessage{Type: constants.MessageTypeRealExchange, PeerID: id.String()})
+		boundID, err := BoundPeerID(SignedMessage{Content: bound}, messageCodec)
+		if err != nil || boundID != id {
+			t.Errorf("TestBoundPeerID - Unexpected peer ID with %s: %s, %v\n", messageCodec.Name(), boundID, err)
+		}
+
+		// Cards of older nodes are not bound
+		unbound, _ := messageCodec.Marshal(ExtendedSignedMessage{Type: constants.MessageTypeRealExchange})
+		_, err = BoundPeerID(SignedMessage{Content: unbound}, messageCodec)
+		if err == nil {
+			t.Errorf("TestBoundPeerID - An unbound card should be rejected with %s\n", messageCodec.Name())
+		}
+	}
+}
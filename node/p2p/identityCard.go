@@ -1,24 +1,52 @@
 package p2p
 
 import (
-	"bufio"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/codec"
 	"node/constants"
 )
 
+// ErrUnboundIdentityCard is returned if an identity card is not bound to a libp2p peer ID.
+var ErrUnboundIdentityCard = errors.New("the identity card is not bound to a peer")
+
 type IdentityCard struct {
 	SSOID     string        `json:"ssoid"`
 	PublicKey rsa.PublicKey `json:"public_key"`
 }
 
-// LoadSignedIdentityCard loads an identity card from storage and signs it with the passed private key. The resulting
-// signed message is then returned.
-func LoadSignedIdentityCard(privateKey *rsa.PrivateKey) (SignedMessage, error) {
+// SignedIdentityCards maps the name of each codec to the own identity card signed with that codec.
+type SignedIdentityCards map[string]SignedMessage
+
+// LoadSignedIdentityCards signs the identity card for every codec so that it does not have to be signed per exchange.
+// The cards are bound to the given libp2p peer ID.
+func LoadSignedIdentityCards(privateKey *rsa.PrivateKey, peerID peer.ID) (SignedIdentityCards, error) {
+	cards := make(SignedIdentityCards)
+
+	for _, name := range codec.Names() {
+		messageCodec, err := codec.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		cards[name], err = LoadSignedIdentityCard(privateKey, peerID, messageCodec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cards, nil
+}
+
+// LoadSignedIdentityCard loads an identity card from storage, binds it to the peer ID and signs it with the passed
+// private key. The resulting signed message is then returned.
+func LoadSignedIdentityCard(privateKey *rsa.PrivateKey, peerID peer.ID, messageCodec codec.Codec) (SignedMessage, error) {
 	fileContent, err := ioutil.ReadFile(constants.IdentityFilePath)
 	if err != nil {
 		return SignedMessage{}, fmt.Errorf("node/LoadSignedIdentityCard - Could not read identity file: %w", err)
@@ -30,22 +58,57 @@ func LoadSignedIdentityCard(privateKey *rsa.PrivateKey) (SignedMessage, error) {
 		return SignedMessage{}, fmt.Errorf("node/LoadSignedIdentityCard - Could not unmarshal signed identity card: %w", err)
 	}
 
+	msg, err := signIdentityCard(tmp, privateKey, peerID, messageCodec)
+	if err != nil {
+		return SignedMessage{}, fmt.Errorf("node/LoadSignedIdentityCard - %w", err)
+	}
+
+	return msg, nil
+}
+
+// SignIdentityCards is LoadSignedIdentityCards for an identity card signed by Revolori that is not read from storage.
+func SignIdentityCards(revoloriSignedCard SignedMessage, privateKey *rsa.PrivateKey, peerID peer.ID) (SignedIdentityCards, error) {
+	cards := make(SignedIdentityCards)
+
+	for _, name := range codec.Names() {
+		messageCodec, err := codec.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		cards[name], err = signIdentityCard(revoloriSignedCard, privateKey, peerID, messageCodec)
+		if err != nil {
+			return nil, fmt.Errorf("node/SignIdentityCards - %w", err)
+		}
+	}
+
+	return cards, nil
+}
+
+// signIdentityCard binds the identity card signed by Revolori to the peer ID and signs it with the private key.
+func signIdentityCard(revoloriSignedCard SignedMessage, privateKey *rsa.PrivateKey, peerID peer.ID, messageCodec codec.Codec) (SignedMessage, error) {
 	signedCard := ExtendedSignedMessage{
-		Content:   tmp.Content,
-		Signature: tmp.Signature,
+		Content:   revoloriSignedCard.Content,
+		Signature: revoloriSignedCard.Signature,
 		Type:      constants.MessageTypeRealExchange,
+		PeerID:    peerID.String(),
 	}
 
-	msg, err := CreateSignedMessage(signedCard, privateKey)
+	msg, err := CreateSignedMessage(signedCard, privateKey, messageCodec)
 	if err != nil {
-		return SignedMessage{}, fmt.Errorf("node/LoadSignedIdentityCard - Could not send signed identity card: %w", err)
+		return SignedMessage{}, fmt.Errorf("could not sign identity card: %w", err)
 	}
 
 	return msg, nil
 }
 
-// SendSignedIdentityCard receives a pre-signed identity card and writes it to the passed ReadWriter.
-func SendSignedIdentityCard(signedCard SignedMessage, rw *bufio.ReadWriter) error {
+// SendSignedIdentityCard picks the pre-signed identity card for the codec of the ReadWriter and writes it.
+func SendSignedIdentityCard(signedCards SignedIdentityCards, rw *ReadWriter) error {
+	signedCard, ok := signedCards[rw.codec.Name()]
+	if !ok {
+		return fmt.Errorf("node/SendSignedIdentityCard - No identity card signed for codec '%s'", rw.codec.Name())
+	}
+
 	err := SendSignedMessage(signedCard, rw)
 	if err != nil {
 		return fmt.Errorf("node/SendSignedIdentityCard - Could not send signed identity card: %w", err)
@@ -56,7 +119,7 @@ func SendSignedIdentityCard(signedCard SignedMessage, rw *bufio.ReadWriter) erro
 
 // SendEmptyIdentityCard creates and sends an empty SignedMessage to inform the other party that the current exchange is
 // not a real exchange but fake chatter.
-func SendEmptyIdentityCard(privateKey *rsa.PrivateKey, rw *bufio.ReadWriter) error {
+func SendEmptyIdentityCard(privateKey *rsa.PrivateKey, rw *ReadWriter) error {
 	signedCard := ExtendedSignedMessage{
 		Content:   nil,
 		Signature: nil,
@@ -74,31 +137,72 @@ func SendEmptyIdentityCard(privateKey *rsa.PrivateKey, rw *bufio.ReadWriter) err
 // ReceiveAndVerifySignedIdentityCard reads the IdentityCard from the ReadWriter and verifies the public keys. For it to
 // work the IdentityCard needs to be signed with the private key provided by Revolori.
 // Returns the signed message, unmarshaled identity card and if this is a fake exchange.
-func ReceiveAndVerifySignedIdentityCard(rw *bufio.ReadWriter, revoloriPublicKey *rsa.PublicKey) (SignedMessage, IdentityCard, bool, error) {
+func ReceiveAndVerifySignedIdentityCard(rw *ReadWriter, revoloriPublicKey *rsa.PublicKey) (SignedMessage, IdentityCard, bool, error) {
 	// The expected message is a signed message (from peer) of a signed message (from Revolori) of the identity card
 	var peerSignedMessage SignedMessage
 
 	// Read the struct
-	received, err := ReadLine(rw, '}', 10*time.Second)
+	received, err := readMessage(rw, 10*time.Second)
 	if err != nil {
 		return SignedMessage{}, IdentityCard{}, false, fmt.Errorf("node/ReceiveAndVerifySignedIdentityCard - Could not read the json: %w", err)
 	}
 
 	// Parse the SignedMessage from the peer
-	err = json.Unmarshal([]byte(received), &peerSignedMessage)
+	err = rw.codec.Unmarshal(received, &peerSignedMessage)
 	if err != nil {
 		return SignedMessage{}, IdentityCard{}, false, fmt.Errorf("node/ReceiveAndVerifySignedIdentityCard - Could not unmarshal the peer signed message: %w", err)
 	}
 
-	return VerifySignedIdentityCard(peerSignedMessage, revoloriPublicKey)
+	signedMessage, identityCard, isFake, err := VerifySignedIdentityCard(peerSignedMessage, revoloriPublicKey, rw.codec)
+	if err != nil || isFake {
+		return signedMessage, identityCard, isFake, err
+	}
+
+	// The identity card has to be sent by the peer it is bound to. Only clients of the legacy protocol send unbound
+	// cards, a bound card is checked on every stream.
+	peerID, err := BoundPeerID(peerSignedMessage, rw.codec)
+	if errors.Is(err, ErrUnboundIdentityCard) && rw.legacy {
+		return signedMessage, identityCard, isFake, nil
+	} else if err != nil {
+		return SignedMessage{}, IdentityCard{}, false, fmt.Errorf("node/ReceiveAndVerifySignedIdentityCard - %w", err)
+	}
+
+	if rw.remotePeer != "" && peerID != rw.remotePeer {
+		return SignedMessage{}, IdentityCard{}, false, fmt.Errorf("node/ReceiveAndVerifySignedIdentityCard - The identity card of '%s' is bound to peer %s but was sent by %s", identityCard.SSOID, peerID, rw.remotePeer)
+	}
+
+	return signedMessage, identityCard, isFake, nil
+}
+
+// BoundPeerID returns the libp2p peer ID an identity card is bound to. The signatures have to be verified beforehand.
+func BoundPeerID(peerSignedMessage SignedMessage, messageCodec codec.Codec) (peer.ID, error) {
+	var extendedSignedMessage ExtendedSignedMessage
+
+	err := messageCodec.Unmarshal(peerSignedMessage.Content, &extendedSignedMessage)
+	if err != nil {
+		return "", fmt.Errorf("could not unmarshal the extended signed message: %w", err)
+	}
+
+	if extendedSignedMessage.PeerID == "" {
+		return "", ErrUnboundIdentityCard
+	}
+
+	peerID, err := peer.Decode(extendedSignedMessage.PeerID)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer ID '%s': %w", extendedSignedMessage.PeerID, err)
+	}
+
+	return peerID, nil
 }
 
-func VerifySignedIdentityCard(peerSignedMessage SignedMessage, revoloriPublicKey *rsa.PublicKey) (SignedMessage, IdentityCard, bool, error) {
+// VerifySignedIdentityCard verifies both signatures of an identity card. The card itself is always JSON since that is
+// what Revolori signs; the codec is used for the peer's envelope.
+func VerifySignedIdentityCard(peerSignedMessage SignedMessage, revoloriPublicKey *rsa.PublicKey, messageCodec codec.Codec) (SignedMessage, IdentityCard, bool, error) {
 	var extendedSignedMessage ExtendedSignedMessage
 	var peerIdentityCard IdentityCard
 
 	// Parse the SignedMessage from Revolori
-	err := json.Unmarshal(peerSignedMessage.Content, &extendedSignedMessage)
+	err := messageCodec.Unmarshal(peerSignedMessage.Content, &extendedSignedMessage)
 	if err != nil {
 		return SignedMessage{}, IdentityCard{}, false, fmt.Errorf("node/ReceiveAndVerifySignedIdentityCard - Could not unmarshal the extended signed message: %w", err)
 	}
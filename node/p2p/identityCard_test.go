@@ -0,0 +1,104 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/codec"
+	"node/constants"
+)
+
+func _newPeerID(t *testing.T) peer.ID {
+	t.Helper()
+
+	_, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatalf("_newPeerID - Could not generate key: %s\n", err)
+	}
+
+	peerID, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("_newPeerID - Could not derive peer ID: %s\n", err)
+	}
+
+	return peerID
+}
+
+func TestReceiveAndVerifySignedIdentityCard(t *testing.T) {
+	revoloriKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestReceiveAndVerifySignedIdentityCard - Could not generate rsa key: %s\n", err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestReceiveAndVerifySignedIdentityCard - Could not generate rsa key: %s\n", err)
+	}
+
+	revoloriSignedCard, err := CreateSignedMessage(IdentityCard{SSOID: "alice", PublicKey: privateKey.PublicKey}, revoloriKey, codec.JSON)
+	if err != nil {
+		t.Fatalf("TestReceiveAndVerifySignedIdentityCard - Could not sign identity card: %s\n", err)
+	}
+
+	peerID := _newPeerID(t)
+	bound, err := signIdentityCard(revoloriSignedCard, privateKey, peerID, codec.JSON)
+	if err != nil {
+		t.Fatalf("TestReceiveAndVerifySignedIdentityCard - Could not bind identity card: %s\n", err)
+	}
+
+	// Cards of clients that only speak the legacy protocol are not bound to a peer
+	unbound, err := CreateSignedMessage(ExtendedSignedMessage{
+		Content:   revoloriSignedCard.Content,
+		Signature: revoloriSignedCard.Signature,
+		Type:      constants.MessageTypeRealExchange,
+	}, privateKey, codec.JSON)
+	if err != nil {
+		t.Fatalf("TestReceiveAndVerifySignedIdentityCard - Could not sign unbound identity card: %s\n", err)
+	}
+
+	tests := []struct {
+		name       string
+		card       SignedMessage
+		protocolID string
+		remotePeer peer.ID
+		valid      bool
+	}{
+		{"bound card", bound, constants.P2PProtocolName, peerID, true},
+		{"bound card of another peer", bound, constants.P2PProtocolName, _newPeerID(t), false},
+		{"unbound card", unbound, constants.P2PProtocolName, peerID, false},
+		{"unbound card on legacy stream", unbound, constants.P2PLegacyProtocolName, peerID, true},
+		{"bound card of another peer on legacy stream", bound, constants.P2PLegacyProtocolName, _newPeerID(t), false},
+	}
+
+	for _, test := range tests {
+		var buffer bytes.Buffer
+		rw := NewReadWriter(&buffer, test.protocolID)
+		rw.remotePeer = test.remotePeer
+
+		err = SendSignedMessage(test.card, rw)
+		if err != nil {
+			t.Fatalf("TestReceiveAndVerifySignedIdentityCard - %s: Could not send identity card: %s\n", test.name, err)
+		}
+
+		_, identityCard, _, err := ReceiveAndVerifySignedIdentityCard(rw, &revoloriKey.PublicKey)
+		if test.valid && (err != nil || identityCard.SSOID != "alice") {
+			t.Errorf("TestReceiveAndVerifySignedIdentityCard - %s: Valid card was rejected: '%s', %v\n", test.name, identityCard.SSOID, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("TestReceiveAndVerifySignedIdentityCard - %s: Invalid card was accepted\n", test.name)
+		}
+	}
+
+	// The card is rejected because it is unbound, not because of its signatures
+	var buffer bytes.Buffer
+	rw := NewReadWriter(&buffer, constants.P2PProtocolName)
+	_ = SendSignedMessage(unbound, rw)
+	_, _, _, err = ReceiveAndVerifySignedIdentityCard(rw, &revoloriKey.PublicKey)
+	if !errors.Is(err, ErrUnboundIdentityCard) {
+		t.Errorf("TestReceiveAndVerifySignedIdentityCard - Expected ErrUnboundIdentityCard, got %v\n", err)
+	}
+}
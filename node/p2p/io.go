@@ -27,7 +27,7 @@ func Write(rw *bufio.ReadWriter, data string) error {
 }
 
 // ReadLine attempts to read from the ReadWriter. If no waitTime is passed then node.MaxWaitTime is used. Returns the
-// read string on success or an error on failure.
+// read string on success or an error on failure. Only used for streams of constants.P2PLegacyProtocolName.
 func ReadLine(rw *bufio.ReadWriter, delim byte, waitTime ...time.Duration) (string, error) {
 	// Get rid of line feed
 	peak, err := rw.Peek(rw.Reader.Buffered())
@@ -20,12 +20,16 @@ func (n *discoveryNotifee) HandlePeerFound(pi peer.AddrInfo) {
 
 // InitMDNS initializes the MDNS service.
 func InitMDNS(peerhost host.Host) chan peer.AddrInfo {
+	return initMDNS(peerhost, "serviceName")
+}
+
+func initMDNS(peerhost host.Host, serviceName string) chan peer.AddrInfo {
 	// register with service so that we get notified about peer discovery
 	n := &discoveryNotifee{}
 	n.PeerChan = make(chan peer.AddrInfo, 512)
 
 	// An hour might be a long, long period in practical applications. But this is fine for us
-	ser := mdns.NewMdnsService(peerhost, "serviceName", n)
+	ser := mdns.NewMdnsService(peerhost, serviceName, n)
 	if err := ser.Start(); err != nil {
 		log.Error.Fatalf("node/mdns - %v\n", err)
 	}
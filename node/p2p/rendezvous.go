@@ -0,0 +1,85 @@
+package p2p
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	"node/constants"
+)
+
+// DeploymentKeyFromEnv returns the deployment key from constants.DeploymentKeyEnv. Without a key, listeners do not
+// publish a rendezvous token.
+func DeploymentKeyFromEnv() ([]byte, bool) {
+	key, ok := os.LookupEnv(constants.DeploymentKeyEnv)
+	if !ok || len(key) == 0 {
+		return nil, false
+	}
+
+	return []byte(key), true
+}
+
+// RendezvousToken blinds the SSOID with an HMAC under the deployment key. Only nodes that know the key can tell which
+// token belongs to which SSOID.
+func RendezvousToken(deploymentKey []byte, ssoid string) string {
+	mac := hmac.New(sha256.New, deploymentKey)
+	_, _ = mac.Write([]byte(ssoid))
+
+	return hex.EncodeToString(mac.Sum(nil)[:constants.RendezvousTokenSize])
+}
+
+// AdvertiseToken publishes the rendezvous token via mDNS and/or the DHT until the context is cancelled. dhtDiscovery
+// is only used for constants.DiscoveryDHT and constants.DiscoveryBoth.
+func AdvertiseToken(ctx context.Context, peerhost host.Host, mode string, dhtDiscovery *DHTDiscovery, token string) error {
+	if mode != constants.DiscoveryMDNS && mode != constants.DiscoveryDHT && mode != constants.DiscoveryBoth {
+		return fmt.Errorf("node/AdvertiseToken - Unknown discovery mechanism '%s'", mode)
+	}
+
+	if mode != constants.DiscoveryDHT {
+		// The listener does not search for other peers under its token
+		service := mdns.NewMdnsService(peerhost, tokenServiceName(token), ignoreNotifee{})
+		if err := service.Start(); err != nil {
+			return fmt.Errorf("node/AdvertiseToken - Could not start mDNS service: %w", err)
+		}
+
+		go func() {
+			<-ctx.Done()
+			_ = service.Close()
+		}()
+	}
+
+	if mode != constants.DiscoveryMDNS {
+		dhtDiscovery.AdvertiseToken(ctx, token)
+	}
+
+	return nil
+}
+
+// FindToken returns the peers that publish the rendezvous token via mDNS and/or the DHT. dhtDiscovery is only used
+// for constants.DiscoveryDHT and constants.DiscoveryBoth.
+func FindToken(ctx context.Context, peerhost host.Host, mode string, dhtDiscovery *DHTDiscovery, token string) (chan peer.AddrInfo, error) {
+	switch mode {
+	case constants.DiscoveryMDNS:
+		return initMDNS(peerhost, tokenServiceName(token)), nil
+	case constants.DiscoveryDHT:
+		return dhtDiscovery.FindToken(ctx, token), nil
+	case constants.DiscoveryBoth:
+		return MergePeerChans(ctx, initMDNS(peerhost, tokenServiceName(token)), dhtDiscovery.FindToken(ctx, token)), nil
+	default:
+		return nil, fmt.Errorf("node/FindToken - Unknown discovery mechanism '%s'", mode)
+	}
+}
+
+func tokenServiceName(token string) string {
+	return "_p3-" + token + "._udp"
+}
+
+type ignoreNotifee struct{}
+
+func (ignoreNotifee) HandlePeerFound(peer.AddrInfo) {}
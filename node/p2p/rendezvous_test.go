@@ -0,0 +1,86 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"node/constants"
+)
+
+func TestRendezvousToken(t *testing.T) {
+	token := RendezvousToken([]byte("deployment"), "alice@example.com")
+
+	if len(token) != 2*constants.RendezvousTokenSize {
+		t.Errorf("TestRendezvousToken - Unexpected token length: %d\n", len(token))
+	}
+
+	if token != RendezvousToken([]byte("deployment"), "alice@example.com") {
+		t.Errorf("TestRendezvousToken - The token is not deterministic\n")
+	}
+
+	if token == RendezvousToken([]byte("other deployment"), "alice@example.com") {
+		t.Errorf("TestRendezvousToken - The token does not depend on the deployment key\n")
+	}
+
+	if token == RendezvousToken([]byte("deployment"), "bob@example.com") {
+		t.Errorf("TestRendezvousToken - The token does not depend on the SSOID\n")
+	}
+}
+
+func TestFindToken(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	namespace := "/P3/rendezvous/test"
+	key := []byte("deployment")
+
+	bootstrapHost := newLocalHost(t)
+	bootstrap, err := NewDHTDiscovery(ctx, bootstrapHost, nil, namespace)
+	if err != nil {
+		t.Fatalf("TestFindToken - Could not start bootstrap peer: %s\n", err)
+	}
+	defer bootstrap.Close()
+
+	bootstrapPeers := []peer.AddrInfo{{ID: bootstrapHost.ID(), Addrs: bootstrapHost.Addrs()}}
+
+	// Two listeners, only one of which is the owner
+	hosts := make(map[string]peer.ID)
+	for _, ssoid := range []string{"alice@example.com", "bob@example.com"} {
+		listenerHost := newLocalHost(t)
+		listener, err := NewDHTDiscovery(ctx, listenerHost, bootstrapPeers, namespace)
+		if err != nil {
+			t.Fatalf("TestFindToken - Could not start listener: %s\n", err)
+		}
+		defer listener.Close()
+
+		err = AdvertiseToken(ctx, listenerHost, constants.DiscoveryDHT, listener, RendezvousToken(key, ssoid))
+		if err != nil {
+			t.Fatalf("TestFindToken - Could not advertise token: %s\n", err)
+		}
+
+		hosts[ssoid] = listenerHost.ID()
+	}
+
+	requesterHost := newLocalHost(t)
+	requester, err := NewDHTDiscovery(ctx, requesterHost, bootstrapPeers, namespace)
+	if err != nil {
+		t.Fatalf("TestFindToken - Could not start requester: %s\n", err)
+	}
+	defer requester.Close()
+
+	peerChan, err := FindToken(ctx, requesterHost, constants.DiscoveryDHT, requester, RendezvousToken(key, "bob@example.com"))
+	if err != nil {
+		t.Fatalf("TestFindToken - Could not start search: %s\n", err)
+	}
+
+	select {
+	case info := <-peerChan:
+		if info.ID != hosts["bob@example.com"] {
+			t.Errorf("TestFindToken - Found %s instead of the owner\n", info.ID)
+		}
+	case <-ctx.Done():
+		t.Fatalf("TestFindToken - The owner was not found\n")
+	}
+}
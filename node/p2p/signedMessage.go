@@ -1,15 +1,14 @@
 package p2p
 
 import (
-	"bufio"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"time"
 
+	"node/codec"
 	"node/constants"
 )
 
@@ -24,6 +23,8 @@ type ExtendedSignedMessage struct {
 	Content   []byte                `json:"content"`
 	Signature []byte                `json:"signature"`
 	Type      constants.MessageType `json:"type"`
+	// PeerID binds an identity card to the libp2p peer that sends it. It is empty for other messages.
+	PeerID string `json:"peer_id,omitempty"`
 }
 
 func (message *SignedMessage) VerifySignature(publicKey *rsa.PublicKey) error {
@@ -34,26 +35,17 @@ func (message *SignedMessage) VerifySignature(publicKey *rsa.PublicKey) error {
 // ReceiveAndVerifySignedMessage receives the read bytes, tries to convert them into a SignedMessage. if that step was completed
 // successfully then the signature is verified. If that step was completed successfully then the SignedMessage.Content
 // is returned.
-func ReceiveAndVerifySignedMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKey, returnStruct interface{}, waitTime ...time.Duration) (SignedMessage, error) {
+func ReceiveAndVerifySignedMessage(rw *ReadWriter, publicKey *rsa.PublicKey, returnStruct interface{}, waitTime ...time.Duration) (SignedMessage, error) {
 	var signedMessage SignedMessage
 
-	var received string
-	var err error
 	// Read the struct
-	if len(waitTime) > 0 {
-		// Wait time parameter was given
-		received, err = ReadLine(rw, '}', waitTime[0])
-	} else {
-		// Use default time out
-		received, err = ReadLine(rw, '}')
-	}
-
+	received, err := readMessage(rw, waitTime...)
 	if err != nil {
 		return SignedMessage{}, err
 	}
 
 	// Turn []bytes to SignedMessage struct
-	err = json.Unmarshal([]byte(received), &signedMessage)
+	err = rw.codec.Unmarshal(received, &signedMessage)
 	if err != nil {
 		return SignedMessage{}, fmt.Errorf("could not unmarshal signed response: %w", err)
 	}
@@ -65,7 +57,7 @@ func ReceiveAndVerifySignedMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKe
 	}
 
 	// Try to unmarshal Signature.Content
-	err = json.Unmarshal(signedMessage.Content, returnStruct)
+	err = rw.codec.Unmarshal(signedMessage.Content, returnStruct)
 	if err != nil {
 		return SignedMessage{}, fmt.Errorf("could not convert signed message to the given struct: %w", err)
 	}
@@ -74,26 +66,17 @@ func ReceiveAndVerifySignedMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKe
 }
 
 // ReceiveAndVerifyFirstMessage is basically ReceiveAndVerifySignedMessage with optional signature verification for fake chatter.
-func ReceiveAndVerifyFirstMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKey, returnStruct interface{}, isFakeChatter bool, waitTime ...time.Duration) (SignedMessage, error) {
+func ReceiveAndVerifyFirstMessage(rw *ReadWriter, publicKey *rsa.PublicKey, returnStruct interface{}, isFakeChatter bool, waitTime ...time.Duration) (SignedMessage, error) {
 	var signedMessage SignedMessage
 
-	var received string
-	var err error
 	// Read the struct
-	if len(waitTime) > 0 {
-		// Wait time parameter was given
-		received, err = ReadLine(rw, '}', waitTime[0])
-	} else {
-		// Use default time out
-		received, err = ReadLine(rw, '}')
-	}
-
+	received, err := readMessage(rw, waitTime...)
 	if err != nil {
 		return SignedMessage{}, err
 	}
 
 	// Turn []bytes to SignedMessage struct
-	err = json.Unmarshal([]byte(received), &signedMessage)
+	err = rw.codec.Unmarshal(received, &signedMessage)
 	if err != nil {
 		return SignedMessage{}, fmt.Errorf("could not unmarshal signed response: %w", err)
 	}
@@ -107,7 +90,7 @@ func ReceiveAndVerifyFirstMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKey
 	}
 
 	// Try to unmarshal Signature.Content
-	err = json.Unmarshal(signedMessage.Content, returnStruct)
+	err = rw.codec.Unmarshal(signedMessage.Content, returnStruct)
 	if err != nil {
 		return SignedMessage{}, fmt.Errorf("could not convert signed message to the given struct: %w", err)
 	}
@@ -115,9 +98,9 @@ func ReceiveAndVerifyFirstMessage(rw *bufio.ReadWriter, publicKey *rsa.PublicKey
 	return signedMessage, nil
 }
 
-func CreateAndSendSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey, rw *bufio.ReadWriter) error {
+func CreateAndSendSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey, rw *ReadWriter) error {
 	// Created a SignedMessage
-	signedMessage, err := CreateSignedMessage(messageStruct, privateKey)
+	signedMessage, err := CreateSignedMessage(messageStruct, privateKey, rw.codec)
 	if err != nil {
 		return fmt.Errorf("node/CreateAndSendSignedMessage - could not create SignedMessage: %w", err)
 	}
@@ -125,24 +108,25 @@ func CreateAndSendSignedMessage(messageStruct interface{}, privateKey *rsa.Priva
 	return SendSignedMessage(signedMessage, rw)
 }
 
-func SendSignedMessage(signedMessage SignedMessage, rw *bufio.ReadWriter) error {
-	// Create a SignedMessage JSON
-	js, err := json.Marshal(signedMessage)
+func SendSignedMessage(signedMessage SignedMessage, rw *ReadWriter) error {
+	// Encode the SignedMessage
+	encoded, err := rw.codec.Marshal(signedMessage)
 	if err != nil {
 		return fmt.Errorf("node/CreateAndSendSignedMessage - could not marshal signedMessage: %w", err)
 	}
 
-	// Send the json
-	err = Write(rw, string(js))
+	// Send the encoded message
+	err = writeMessage(rw, encoded)
 	if err != nil {
-		return fmt.Errorf("node/CreateAndSendSignedMessage - could not write json: %w", err)
+		return fmt.Errorf("node/CreateAndSendSignedMessage - could not write message: %w", err)
 	}
 
 	return nil
 }
 
-func CreateSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey) (SignedMessage, error) {
-	message, err := json.Marshal(messageStruct)
+// CreateSignedMessage encodes the struct with the codec and signs the result.
+func CreateSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey, messageCodec codec.Codec) (SignedMessage, error) {
+	message, err := messageCodec.Marshal(messageStruct)
 	if err != nil {
 		return SignedMessage{}, err
 	}
@@ -158,13 +142,13 @@ func CreateSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey)
 	}, nil
 }
 
-func CreateSendAndReturnSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey, rw *bufio.ReadWriter) ([]byte, error) {
-	ret, err := CreateSignedMessage(messageStruct, privateKey)
+func CreateSendAndReturnSignedMessage(messageStruct interface{}, privateKey *rsa.PrivateKey, rw *ReadWriter) ([]byte, error) {
+	ret, err := CreateSignedMessage(messageStruct, privateKey, rw.codec)
 	if err != nil {
 		return nil, err
 	}
 
-	retBytes, err := json.Marshal(ret)
+	retBytes, err := rw.codec.Marshal(ret)
 	if err != nil {
 		return nil, err
 	}
@@ -177,14 +161,16 @@ func CreateSendAndReturnSignedMessage(messageStruct interface{}, privateKey *rsa
 	return retBytes, nil
 }
 
-func ExtractAndVerifyMessages(signedMessages []SignedMessage, revoloriPublicKey *rsa.PublicKey) (FirstMessage, IdentityCard, error) {
+// ExtractAndVerifyMessages verifies the identity card and first message of a stored exchange. The messages are decoded
+// with the codec of the exchange's session.
+func ExtractAndVerifyMessages(signedMessages []SignedMessage, revoloriPublicKey *rsa.PublicKey, messageCodec codec.Codec) (FirstMessage, IdentityCard, error) {
 	if len(signedMessages) < 2 {
 		return FirstMessage{}, IdentityCard{}, fmt.Errorf("there are too few messages (%d)", len(signedMessages))
 	}
 
 	// IdentityCard at signedMessages[0]
 	signedIdentityCard := signedMessages[0]
-	_, identityCard, isFakeChatter, err := VerifySignedIdentityCard(signedIdentityCard, revoloriPublicKey)
+	_, identityCard, isFakeChatter, err := VerifySignedIdentityCard(signedIdentityCard, revoloriPublicKey, messageCodec)
 	if err != nil {
 		return FirstMessage{}, IdentityCard{}, err
 	}
@@ -201,7 +187,7 @@ func ExtractAndVerifyMessages(signedMessages []SignedMessage, revoloriPublicKey
 	}
 
 	var firstMessage FirstMessage
-	err = json.Unmarshal(signedFirstMessage.Content, &firstMessage)
+	err = messageCodec.Unmarshal(signedFirstMessage.Content, &firstMessage)
 	if err != nil {
 		return FirstMessage{}, IdentityCard{}, fmt.Errorf("could not unmarshal first message: %w", err)
 	}
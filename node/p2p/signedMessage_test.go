@@ -5,6 +5,7 @@ import (
 	"crypto/rsa"
 	"testing"
 
+	"node/codec"
 	"node/random"
 )
 
@@ -22,7 +23,7 @@ func TestSignAndVerify(t *testing.T) {
 			text string
 		}{
 			text: message,
-		}, privateKey)
+		}, privateKey, codec.CBOR)
 		if err != nil {
 			t.Fatalf("TestSignAndVerify - Could not sign message: %s\n", err)
 		}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"node/logging"
+)
+
+// Decision is the result of evaluating a request.
+type Decision struct {
+	Allowed bool
+	Rule    string
+	Reason  string
+	// reserved is the request that holds a slot of the rule's quota, nil if the rule has no quota
+	reserved *Request
+}
+
+// Engine evaluates requests against a policy file. The file is reloaded as soon as its modification time changes,
+// thus the policy can be changed without restarting the listener.
+type Engine struct {
+	path    string
+	modTime time.Time
+	policy  Policy
+	// quotaUsage maps rule name -> SSOID -> timestamps of allowed requests
+	quotaUsage map[string]map[string][]time.Time
+	mutex      sync.Mutex
+}
+
+// NewEngine loads the policy file at path.
+func NewEngine(path string) (*Engine, error) {
+	engine := &Engine{
+		path:       path,
+		quotaUsage: make(map[string]map[string][]time.Time),
+	}
+
+	err := engine.reloadIfChanged()
+	if err != nil {
+		return nil, fmt.Errorf("policy/NewEngine - %w", err)
+	}
+
+	return engine, nil
+}
+
+// Evaluate decides whether the request is allowed and writes the decision to the log. An allowed request reserves a
+// slot of the rule's quota, so concurrent requests cannot exceed it. The slot is only used up once the exchange is
+// completed; call Release if the request is not served after all.
+func (engine *Engine) Evaluate(request Request) Decision {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	err := engine.reloadIfChanged()
+	if err != nil {
+		// Keep using the last valid policy
+		log.Error.Printf("policy/Evaluate - Could not reload policy, using the previous one: %v\n", err)
+	}
+
+	decision := engine.evaluate(&request)
+
+	log.Info.Printf("Policy decision: allowed=%t, ssoid='%s', datum='%s', justification='%s', rule='%s', reason='%s'\n",
+		decision.Allowed, request.SSOID, request.Datum, request.Justification, decision.Rule, decision.Reason)
+
+	return decision
+}
+
+func (engine *Engine) evaluate(request *Request) Decision {
+	denial := Decision{
+		Allowed: engine.policy.Default == DefaultAllow,
+		Reason:  "no rule applies to the request",
+	}
+
+	if denial.Allowed {
+		denial.Reason = "allowed by default"
+	}
+
+	for i := range engine.policy.Rules {
+		rule := &engine.policy.Rules[i]
+		if !rule.applies(request) {
+			continue
+		}
+
+		// An applying rule overrides the default
+		err := rule.checkConditions(request)
+		if err != nil {
+			denial = Decision{Allowed: false, Rule: rule.Name, Reason: err.Error()}
+			continue
+		}
+
+		if !engine.useQuota(rule, request) {
+			denial = Decision{Allowed: false, Rule: rule.Name, Reason: "the requester's quota is exhausted"}
+			continue
+		}
+
+		decision := Decision{Allowed: true, Rule: rule.Name, Reason: "allowed by rule"}
+		if rule.Quota != nil {
+			decision.reserved = request
+		}
+
+		return decision
+	}
+
+	return denial
+}
+
+// Release gives back the quota slot reserved by an allowed decision, e.g. because the requester was refused or the
+// exchange stopped before the decryption data was acknowledged.
+func (engine *Engine) Release(decision Decision) {
+	if decision.reserved == nil {
+		return
+	}
+
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	usage := engine.quotaUsage[decision.Rule]
+	timestamps := usage[decision.reserved.SSOID]
+	for i, timestamp := range timestamps {
+		if timestamp.Equal(decision.reserved.Time) {
+			usage[decision.reserved.SSOID] = append(timestamps[:i:i], timestamps[i+1:]...)
+			break
+		}
+	}
+
+	log.Info.Printf("Released quota of rule '%s' for ssoid='%s', datum='%s'\n", decision.Rule, decision.reserved.SSOID, decision.reserved.Datum)
+}
+
+// useQuota checks whether the requester has quota left for the rule. If so, the request is counted until it is
+// released.
+func (engine *Engine) useQuota(rule *Rule, request *Request) bool {
+	if rule.Quota == nil {
+		return true
+	}
+
+	usage, ok := engine.quotaUsage[rule.Name]
+	if !ok {
+		usage = make(map[string][]time.Time)
+		engine.quotaUsage[rule.Name] = usage
+	}
+
+	// Drop requests that left the period
+	recent := make([]time.Time, 0, len(usage[request.SSOID]))
+	for _, timestamp := range usage[request.SSOID] {
+		if request.Time.Sub(timestamp) < rule.Quota.period {
+			recent = append(recent, timestamp)
+		}
+	}
+
+	if len(recent) >= rule.Quota.MaxRequests {
+		usage[request.SSOID] = recent
+		return false
+	}
+
+	usage[request.SSOID] = append(recent, request.Time)
+	return true
+}
+
+// reloadIfChanged loads the policy file if it changed since the last call. The current policy is only replaced if
+// the new one is valid.
+func (engine *Engine) reloadIfChanged() error {
+	info, err := os.Stat(engine.path)
+	if err != nil {
+		return fmt.Errorf("could not stat policy file: %w", err)
+	}
+
+	if info.ModTime().Equal(engine.modTime) {
+		return nil
+	}
+
+	content, err := os.ReadFile(engine.path)
+	if err != nil {
+		return fmt.Errorf("could not read policy file: %w", err)
+	}
+
+	var policy Policy
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&policy)
+	if err != nil {
+		return fmt.Errorf("could not parse policy file: %w", err)
+	}
+
+	err = policy.check()
+	if err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if !engine.modTime.IsZero() {
+		log.Info.Printf("Reloaded policy file '%s'\n", engine.path)
+	}
+
+	engine.policy = policy
+	engine.modTime = info.ModTime()
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testPolicy = `{
+	"default": "deny",
+	"rules": [
+		{
+			"name": "doctor",
+			"ssoids": ["doctor@example.com"],
+			"datum_patterns": ["heart*"],
+			"justification_keywords": ["treatment"],
+			"time_windows": [{"days": ["Mon", "Tue", "Wed", "Thu", "Fri"], "start": "08:00", "end": "18:00"}],
+			"quota": {"max_requests": 2, "period": "1h"}
+		},
+		{
+			"name": "night shift",
+			"ssoids": ["nurse@example.com"],
+			"time_windows": [{"start": "22:00", "end": "06:00"}]
+		}
+	]
+}`
+
+// Monday, 10:00 local time.
+var monday = time.Date(2022, time.March, 7, 10, 0, 0, 0, time.Local)
+
+func writePolicy(t *testing.T, path string, content string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("writePolicy - Could not write policy: %s\n", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, testPolicy)
+
+	engine, err := NewEngine(path)
+	if err != nil {
+		t.Fatalf("TestEvaluate - Could not create engine: %s\n", err)
+	}
+
+	tests := []struct {
+		name    string
+		request Request
+		allowed bool
+	}{
+		{"allowed", Request{"doctor@example.com", "heartRate", "For the treatment", monday}, true},
+		{"unknown requester", Request{"someone@example.com", "heartRate", "For the treatment", monday}, false},
+		{"datum does not match", Request{"doctor@example.com", "steps", "For the treatment", monday}, false},
+		{"missing keyword", Request{"doctor@example.com", "heartRate", "Curiosity", monday}, false},
+		{"outside of time window", Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(9 * time.Hour)}, false},
+		{"weekend", Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(-48 * time.Hour)}, false},
+		{"second request within quota", Request{"doctor@example.com", "heartRate", "TREATMENT", monday.Add(time.Minute)}, true},
+		{"quota exhausted", Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(2 * time.Minute)}, false},
+		{"quota renewed", Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(time.Hour)}, true},
+		{"window spanning midnight", Request{"nurse@example.com", "steps", "Check", monday.Add(-8 * time.Hour)}, true},
+		{"outside of window spanning midnight", Request{"nurse@example.com", "steps", "Check", monday}, false},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.request)
+		if decision.Allowed != test.allowed {
+			t.Errorf("TestEvaluate - %s: expected allowed=%t, got %t (%s)\n", test.name, test.allowed, decision.Allowed, decision.Reason)
+		}
+	}
+}
+
+func TestRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, testPolicy)
+
+	engine, err := NewEngine(path)
+	if err != nil {
+		t.Fatalf("TestRelease - Could not create engine: %s\n", err)
+	}
+
+	first := engine.Evaluate(Request{"doctor@example.com", "heartRate", "For the treatment", monday})
+	second := engine.Evaluate(Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(time.Minute)})
+	if !first.Allowed || !second.Allowed {
+		t.Fatalf("TestRelease - Requests within the quota were denied: %s, %s\n", first.Reason, second.Reason)
+	}
+
+	// Both slots are reserved until one of the exchanges is given up
+	request := Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(2 * time.Minute)}
+	if engine.Evaluate(request).Allowed {
+		t.Errorf("TestRelease - A request exceeded the reserved quota\n")
+	}
+
+	engine.Release(first)
+	if !engine.Evaluate(request).Allowed {
+		t.Errorf("TestRelease - The released quota was not given back\n")
+	}
+
+	// Releasing a decision without quota does nothing
+	engine.Release(engine.Evaluate(Request{"nurse@example.com", "steps", "Check", monday.Add(-8 * time.Hour)}))
+	if engine.Evaluate(Request{"doctor@example.com", "heartRate", "For the treatment", monday.Add(3 * time.Minute)}).Allowed {
+		t.Errorf("TestRelease - A decision without quota released a slot\n")
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, `{"default": "deny"}`)
+
+	engine, err := NewEngine(path)
+	if err != nil {
+		t.Fatalf("TestReload - Could not create engine: %s\n", err)
+	}
+
+	request := Request{"someone@example.com", "steps", "Because", monday}
+	if engine.Evaluate(request).Allowed {
+		t.Errorf("TestReload - Request was allowed by a deny policy\n")
+	}
+
+	writePolicy(t, path, `{"default": "allow"}`)
+	// Make sure that the modification time changes on file systems with a coarse resolution
+	err = os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("TestReload - Could not change modification time: %s\n", err)
+	}
+
+	if !engine.Evaluate(request).Allowed {
+		t.Errorf("TestReload - The changed policy was not applied\n")
+	}
+
+	// An invalid policy must not replace the current one
+	writePolicy(t, path, `{"default": "maybe"}`)
+	err = os.Chtimes(path, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("TestReload - Could not change modification time: %s\n", err)
+	}
+
+	if !engine.Evaluate(request).Allowed {
+		t.Errorf("TestReload - An invalid policy replaced the current one\n")
+	}
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+
+	for _, content := range []string{
+		`{"default": "maybe"}`,
+		`{"rules": [{"datum_patterns": ["["]}]}`,
+		`{"rules": [{"time_windows": [{"start": "8", "end": "18:00"}]}]}`,
+		`{"rules": [{"time_windows": [{"days": ["Someday"], "start": "08:00", "end": "18:00"}]}]}`,
+		`{"rules": [{"quota": {"max_requests": 0, "period": "1h"}}]}`,
+		`{"rules": [{"unknown_field": true}]}`,
+	} {
+		writePolicy(t, path, content)
+
+		_, err := NewEngine(path)
+		if err == nil {
+			t.Errorf("TestInvalidPolicy - Accepted invalid policy: %s\n", content)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAllow = "allow"
+	DefaultDeny  = "deny"
+)
+
+// Policy is the content of a policy file. A request is allowed if at least one rule allows it. If no rule applies to
+// the request, Default decides.
+type Policy struct {
+	Default string `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule applies to a request if the requester's SSOID and the datum match. Empty lists match everything. An applying
+// rule allows the request if the justification contains all keywords, the request falls into one of the time
+// windows and the requester's quota is not exhausted.
+type Rule struct {
+	Name                  string       `json:"name"`
+	SSOIDs                []string     `json:"ssoids"`
+	DatumPatterns         []string     `json:"datum_patterns"`
+	JustificationKeywords []string     `json:"justification_keywords"`
+	TimeWindows           []TimeWindow `json:"time_windows"`
+	Quota                 *Quota       `json:"quota"`
+}
+
+// TimeWindow is a daily interval in the listener's local time. If End is before Start, the window spans midnight.
+type TimeWindow struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Quota limits how many requests a single requester may make within Period, e.g. "24h".
+type Quota struct {
+	MaxRequests int    `json:"max_requests"`
+	Period      string `json:"period"`
+
+	period time.Duration
+}
+
+// Request contains everything a policy decision is based on.
+type Request struct {
+	SSOID         string
+	Datum         string
+	Justification string
+	Time          time.Time
+}
+
+// check validates the policy and parses the durations.
+func (policy *Policy) check() error {
+	if policy.Default == "" {
+		policy.Default = DefaultDeny
+	}
+
+	if policy.Default != DefaultAllow && policy.Default != DefaultDeny {
+		return fmt.Errorf("invalid default '%s'. Expected '%s' or '%s'", policy.Default, DefaultAllow, DefaultDeny)
+	}
+
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i)
+		}
+
+		for _, pattern := range rule.DatumPatterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid datum pattern '%s': %w", rule.Name, pattern, err)
+			}
+		}
+
+		for _, window := range rule.TimeWindows {
+			if err := window.check(); err != nil {
+				return fmt.Errorf("%s: %w", rule.Name, err)
+			}
+		}
+
+		if rule.Quota != nil {
+			period, err := time.ParseDuration(rule.Quota.Period)
+			if err != nil {
+				return fmt.Errorf("%s: invalid quota period '%s': %w", rule.Name, rule.Quota.Period, err)
+			}
+
+			if period <= 0 || rule.Quota.MaxRequests < 1 {
+				return fmt.Errorf("%s: the quota needs a positive period and at least one request", rule.Name)
+			}
+
+			rule.Quota.period = period
+		}
+	}
+
+	return nil
+}
+
+// applies returns true if the rule is meant for the requester and datum.
+func (rule *Rule) applies(request *Request) bool {
+	if len(rule.SSOIDs) > 0 {
+		found := false
+		for _, ssoid := range rule.SSOIDs {
+			if ssoid == request.SSOID {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(rule.DatumPatterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range rule.DatumPatterns {
+		if matched, _ := path.Match(pattern, request.Datum); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkConditions returns an error describing the first condition that the request does not fulfill. The quota is
+// checked separately since it depends on previous decisions.
+func (rule *Rule) checkConditions(request *Request) error {
+	justification := strings.ToLower(request.Justification)
+	for _, keyword := range rule.JustificationKeywords {
+		if !strings.Contains(justification, strings.ToLower(keyword)) {
+			return fmt.Errorf("the justification does not contain '%s'", keyword)
+		}
+	}
+
+	if len(rule.TimeWindows) == 0 {
+		return nil
+	}
+
+	for _, window := range rule.TimeWindows {
+		if window.contains(request.Time) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the request was made outside of the allowed time windows")
+}
+
+func (window *TimeWindow) check() error {
+	if _, err := time.Parse("15:04", window.Start); err != nil {
+		return fmt.Errorf("invalid time window start '%s': %w", window.Start, err)
+	}
+
+	if _, err := time.Parse("15:04", window.End); err != nil {
+		return fmt.Errorf("invalid time window end '%s': %w", window.End, err)
+	}
+
+	for _, day := range window.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day '%s'", day)
+		}
+	}
+
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (window *TimeWindow) contains(moment time.Time) bool {
+	// The format has been checked when loading the policy
+	start, _ := time.Parse("15:04", window.Start)
+	end, _ := time.Parse("15:04", window.End)
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	minutes := moment.Hour()*60 + moment.Minute()
+
+	day := moment.Weekday()
+	var inWindow bool
+	if startMinutes <= endMinutes {
+		inWindow = startMinutes <= minutes && minutes < endMinutes
+	} else {
+		// The window spans midnight => the part after midnight belongs to the previous day
+		inWindow = minutes >= startMinutes || minutes < endMinutes
+		if minutes < endMinutes {
+			day = (day + 6) % 7
+		}
+	}
+
+	if !inWindow || len(window.Days) == 0 {
+		return inWindow
+	}
+
+	for _, name := range window.Days {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+
+	return false
+}
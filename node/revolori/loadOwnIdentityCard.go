@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 
+	"node/codec"
 	"node/constants"
 	"node/p2p"
 )
@@ -25,12 +26,12 @@ func LoadOwnIdentityCard(ownPrivateKey *rsa.PrivateKey, revoloriPublicKey *rsa.P
 	}
 
 	// Needed because VerifySignedIdentityCard requires a signedMessage
-	signedMessage, err := p2p.CreateSignedMessage(signedCard, ownPrivateKey)
+	signedMessage, err := p2p.CreateSignedMessage(signedCard, ownPrivateKey, codec.JSON)
 	if err != nil {
 		return "", fmt.Errorf("node/LoadOwnIdentityCard - could not create SignedMessage: %w", err)
 	}
 
-	_, ownIdentityCard, isFakeChatter, err := p2p.VerifySignedIdentityCard(signedMessage, revoloriPublicKey)
+	_, ownIdentityCard, isFakeChatter, err := p2p.VerifySignedIdentityCard(signedMessage, revoloriPublicKey, codec.JSON)
 	if err != nil {
 		return "", fmt.Errorf("node/LoadOwnIdentityCard - could not verify: %w", err)
 	}
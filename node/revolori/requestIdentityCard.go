@@ -180,7 +180,7 @@ func requestRevoloriSignatureWithToken(revoloriURL string, identityFilePath stri
 		return fmt.Errorf("node/requestRevoloriSignatureWithToken - Could not create new request: %w", err)
 	}
 
-	request.AddCookie(&http.Cookie{Name: "token", Value: strings.Replace(string(token), "\"", "", 2)})
+	request.AddCookie(&http.Cookie{Name: constants.RevoloriTokenCookie, Value: strings.Replace(string(token), "\"", "", 2)})
 
 	return makeRequest(request, identityFilePath)
 }
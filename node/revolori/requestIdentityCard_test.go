@@ -0,0 +1,64 @@
+package revolori
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"node/p2p"
+	"node/revoloridev"
+)
+
+func TestRequestIdentityCardFromDevServer(t *testing.T) {
+	server, err := revoloridev.StartTestServer(
+		revoloridev.User{Email: "alice@example.com", Password: "secret"},
+		revoloridev.User{Email: "bob@example.com", Token: "bobToken"},
+	)
+	if err != nil {
+		t.Fatalf("TestRequestIdentityCardFromDevServer - Could not start server: %s\n", err)
+	}
+	defer server.Close()
+	t.Setenv("REVOLORI_ADDRESS", server.URL)
+
+	revoloriPublicKey, err := GetPublicKey()
+	if err != nil {
+		t.Fatalf("TestRequestIdentityCardFromDevServer - Could not get public key: %s\n", err)
+	}
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	identityFilePath := filepath.Join(t.TempDir(), "identity.json")
+
+	requests := map[string]func() error{
+		"credentials": func() error {
+			return requestRevoloriSignatureWithCredentials(server.URL+"/key/sign", identityFilePath, &key.PublicKey, []byte("alice@example.com"), []byte("secret"))
+		},
+		"token": func() error {
+			return requestRevoloriSignatureWithToken(server.URL+"/key/sign", identityFilePath, &key.PublicKey, []byte("\"bobToken\""))
+		},
+	}
+
+	for name, request := range requests {
+		err = request()
+		if err != nil {
+			t.Errorf("TestRequestIdentityCardFromDevServer - %s: Could not request signature: %s\n", name, err)
+			continue
+		}
+
+		content, _ := ioutil.ReadFile(identityFilePath)
+		var signedCard p2p.SignedMessage
+		_ = json.Unmarshal(content, &signedCard)
+
+		err = signedCard.VerifySignature(&revoloriPublicKey)
+		if err != nil {
+			t.Errorf("TestRequestIdentityCardFromDevServer - %s: Could not verify the identity card: %s\n", name, err)
+		}
+	}
+
+	err = requestRevoloriSignatureWithCredentials(server.URL+"/key/sign", identityFilePath, &key.PublicKey, []byte("alice@example.com"), []byte("wrong"))
+	if err == nil {
+		t.Errorf("TestRequestIdentityCardFromDevServer - A wrong password was accepted\n")
+	}
+}
@@ -0,0 +1,230 @@
+// Package revoloridev provides a local stand-in for Revolori. It serves /key/show and /key/sign with the same JSON
+// shapes, so nodes can be set up and tested without the external toolchain.
+package revoloridev
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"node/constants"
+	"node/logging"
+	nP "node/nonRepudiation"
+	"node/p2p"
+)
+
+// User is an account of the server. A user signs in with Email and Password or with Token. The SSOID is written into
+// the identity card and defaults to Email.
+type User struct {
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	SSOID    string `json:"ssoid,omitempty"`
+}
+
+// signRequest is the body of /key/sign. Email and Password are empty if the token cookie is used.
+type signRequest struct {
+	Email     string        `json:"email"`
+	Password  string        `json:"password"`
+	PublicKey rsa.PublicKey `json:"publicKey"`
+}
+
+// Server signs the identity cards of its users with its private key.
+type Server struct {
+	privateKey *rsa.PrivateKey
+	users      []User
+	mux        *http.ServeMux
+}
+
+// NewServer returns a server that signs with the private key for the given users.
+func NewServer(privateKey *rsa.PrivateKey, users []User) *Server {
+	server := &Server{
+		privateKey: privateKey,
+		users:      users,
+		mux:        http.NewServeMux(),
+	}
+
+	server.mux.HandleFunc("/key/show", server.handleShow)
+	server.mux.HandleFunc("/key/sign", server.handleSign)
+
+	return server
+}
+
+// ServeHTTP implements http.Handler.
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	server.mux.ServeHTTP(writer, request)
+}
+
+// PublicKey returns the key that verifies the signed identity cards.
+func (server *Server) PublicKey() rsa.PublicKey {
+	return server.privateKey.PublicKey
+}
+
+// handleShow returns the public key like Revolori does.
+func (server *Server) handleShow(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(writer, server.privateKey.PublicKey)
+}
+
+// handleSign authenticates the user and returns the identity card with the passed public key signed by the server.
+func (server *Server) handleSign(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	var signReq signRequest
+	err = json.Unmarshal(body, &signReq)
+	if err != nil || signReq.PublicKey.N == nil {
+		http.Error(writer, "the body does not contain a public key", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := server.authenticate(request, signReq)
+	if !ok {
+		log.Info.Printf("revolori-dev - Rejected a signing request for '%s'\n", signReq.Email)
+		http.Error(writer, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	ssoid := user.SSOID
+	if ssoid == "" {
+		ssoid = user.Email
+	}
+
+	signedCard, err := server.Sign(ssoid, signReq.PublicKey)
+	if err != nil {
+		http.Error(writer, "could not sign identity card", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info.Printf("revolori-dev - Signed an identity card for '%s'\n", ssoid)
+	writeJSON(writer, signedCard)
+}
+
+// Sign returns the identity card of the SSOID and public key signed like /key/sign does, without authentication.
+func (server *Server) Sign(ssoid string, publicKey rsa.PublicKey) (p2p.SignedMessage, error) {
+	content, err := json.Marshal(p2p.IdentityCard{SSOID: ssoid, PublicKey: publicKey})
+	if err != nil {
+		return p2p.SignedMessage{}, fmt.Errorf("node/Server.Sign - Could not marshal identity card: %w", err)
+	}
+
+	hashed := sha256.Sum256(content)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, server.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return p2p.SignedMessage{}, fmt.Errorf("node/Server.Sign - Could not sign identity card: %w", err)
+	}
+
+	return p2p.SignedMessage{Content: content, Signature: signature}, nil
+}
+
+// authenticate returns the user of the token cookie, or the user with the email and password of the request.
+func (server *Server) authenticate(request *http.Request, signReq signRequest) (User, bool) {
+	cookie, err := request.Cookie(constants.RevoloriTokenCookie)
+	if err == nil && cookie.Value != "" {
+		for _, user := range server.users {
+			if user.Token != "" && equal(user.Token, cookie.Value) {
+				return user, true
+			}
+		}
+
+		return User{}, false
+	}
+
+	for _, user := range server.users {
+		if user.Email == signReq.Email && user.Password != "" && equal(user.Password, signReq.Password) {
+			return user, true
+		}
+	}
+
+	return User{}, false
+}
+
+func equal(expected string, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+func writeJSON(writer http.ResponseWriter, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(value)
+}
+
+// LoadUsers reads the users file, a JSON array of User. Every user needs an email and a password or token.
+func LoadUsers(path string) ([]User, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadUsers - Could not read users file: %w", err)
+	}
+
+	var users []User
+	err = json.Unmarshal(content, &users)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadUsers - Could not unmarshal users file: %w", err)
+	}
+
+	for i, user := range users {
+		if strings.TrimSpace(user.Email) == "" {
+			return nil, fmt.Errorf("node/LoadUsers - User %d has no email", i)
+		} else if user.Password == "" && user.Token == "" {
+			return nil, fmt.Errorf("node/LoadUsers - User '%s' has neither a password nor a token", user.Email)
+		}
+	}
+
+	return users, nil
+}
+
+// LoadOrCreateKey loads the signing key at path. If there is none, a key of constants.RSAKeySize bits is created and
+// stored.
+func LoadOrCreateKey(path string) (*rsa.PrivateKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(content)
+		if block == nil || block.Type != constants.PemKeyName {
+			return nil, fmt.Errorf("node/LoadOrCreateKey - The key file does not contain a %s", constants.PemKeyName)
+		}
+
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("node/LoadOrCreateKey - Could not parse key: %w", err)
+		}
+
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("node/LoadOrCreateKey - Could not read key: %w", err)
+	}
+
+	key, err := nP.GenerateRSAPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateKey - Could not create key: %w", err)
+	}
+
+	encoded := pem.EncodeToMemory(&pem.Block{Type: constants.PemKeyName, Bytes: x509.MarshalPKCS1PrivateKey(&key)})
+	err = ioutil.WriteFile(path, encoded, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("node/LoadOrCreateKey - Could not write key: %w", err)
+	}
+
+	log.Info.Printf("Created a new signing key at %s\n", path)
+	return &key, nil
+}
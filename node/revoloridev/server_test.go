@@ -0,0 +1,112 @@
+package revoloridev
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"node/constants"
+	"node/p2p"
+)
+
+func _sign(t *testing.T, url string, body interface{}, token string) (*http.Response, p2p.SignedMessage) {
+	encoded, _ := json.Marshal(body)
+	request, _ := http.NewRequest(http.MethodPost, url+"/key/sign", strings.NewReader(string(encoded)))
+	if token != "" {
+		request.AddCookie(&http.Cookie{Name: constants.RevoloriTokenCookie, Value: token})
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Could not request signature: %s\n", err)
+	}
+	defer response.Body.Close()
+
+	var signedMessage p2p.SignedMessage
+	_ = json.NewDecoder(response.Body).Decode(&signedMessage)
+
+	return response, signedMessage
+}
+
+func TestSignIdentityCard(t *testing.T) {
+	server, err := StartTestServer(
+		User{Email: "alice@example.com", Password: "secret", SSOID: "alice"},
+		User{Email: "bob@example.com", Token: "bobToken"},
+	)
+	if err != nil {
+		t.Fatalf("TestSignIdentityCard - Could not start server: %s\n", err)
+	}
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/key/show")
+	if err != nil {
+		t.Fatalf("TestSignIdentityCard - Could not get public key: %s\n", err)
+	}
+
+	var publicKey rsa.PublicKey
+	err = json.NewDecoder(response.Body).Decode(&publicKey)
+	response.Body.Close()
+	if err != nil || !publicKey.Equal(&server.privateKey.PublicKey) {
+		t.Fatalf("TestSignIdentityCard - Unexpected public key: %v\n", err)
+	}
+
+	userKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name   string
+		body   interface{}
+		token  string
+		status int
+		ssoid  string
+	}{
+		{"password", signRequest{Email: "alice@example.com", Password: "secret", PublicKey: userKey.PublicKey}, "", http.StatusOK, "alice"},
+		{"token", signRequest{PublicKey: userKey.PublicKey}, "bobToken", http.StatusOK, "bob@example.com"},
+		{"wrong password", signRequest{Email: "alice@example.com", Password: "wrong", PublicKey: userKey.PublicKey}, "", http.StatusUnauthorized, ""},
+		{"wrong token", signRequest{PublicKey: userKey.PublicKey}, "wrong", http.StatusUnauthorized, ""},
+		// A user without a password cannot sign in with an empty one
+		{"empty password", signRequest{Email: "bob@example.com", PublicKey: userKey.PublicKey}, "", http.StatusUnauthorized, ""},
+		{"no public key", signRequest{Email: "alice@example.com", Password: "secret"}, "", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		response, signedMessage := _sign(t, server.URL, test.body, test.token)
+		if response.StatusCode != test.status {
+			t.Errorf("TestSignIdentityCard - %s: Unexpected status %d\n", test.name, response.StatusCode)
+			continue
+		} else if test.status != http.StatusOK {
+			continue
+		}
+
+		err = signedMessage.VerifySignature(&publicKey)
+		if err != nil {
+			t.Errorf("TestSignIdentityCard - %s: Could not verify signature: %s\n", test.name, err)
+		}
+
+		var card p2p.IdentityCard
+		err = json.Unmarshal(signedMessage.Content, &card)
+		if err != nil || card.SSOID != test.ssoid || !card.PublicKey.Equal(&userKey.PublicKey) {
+			t.Errorf("TestSignIdentityCard - %s: Unexpected identity card: %v, %v\n", test.name, card.SSOID, err)
+		}
+	}
+}
+
+func TestLoadUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	_ = os.WriteFile(path, []byte(`[{"email": "alice@example.com", "password": "secret"}, {"email": "bob@example.com", "token": "bobToken"}]`), 0o600)
+	users, err := LoadUsers(path)
+	if err != nil || len(users) != 2 {
+		t.Errorf("TestLoadUsers - Could not load users: %v, %v\n", users, err)
+	}
+
+	_ = os.WriteFile(path, []byte(`[{"email": "alice@example.com"}]`), 0o600)
+	_, err = LoadUsers(path)
+	if err == nil {
+		t.Errorf("TestLoadUsers - A user without credentials was accepted\n")
+	}
+}
@@ -0,0 +1,43 @@
+package revoloridev
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http/httptest"
+)
+
+// testKeySize is smaller than constants.RSAKeySize so that starting a TestServer is fast.
+const testKeySize = 2048
+
+// TestServer is a Server on an ephemeral local port. Close has to be called to stop it.
+type TestServer struct {
+	*Server
+
+	// URL is the base address to set as REVOLORI_ADDRESS
+	URL string
+
+	server *httptest.Server
+}
+
+// StartTestServer starts a server with a new key for the given users.
+func StartTestServer(users ...User) (*TestServer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, testKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("node/StartTestServer - Could not create key: %w", err)
+	}
+
+	server := NewServer(key, users)
+	httpServer := httptest.NewServer(server)
+
+	return &TestServer{
+		Server: server,
+		URL:    httpServer.URL,
+		server: httpServer,
+	}, nil
+}
+
+// Close stops the server.
+func (server *TestServer) Close() {
+	server.server.Close()
+}
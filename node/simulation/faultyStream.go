@@ -0,0 +1,151 @@
+package simulation
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	libNetwork "github.com/libp2p/go-libp2p-core/network"
+)
+
+// FaultKind is what happens to the message a Fault is injected into.
+type FaultKind int
+
+const (
+	// FaultDrop does not send the message, but lets the sender believe it was sent.
+	FaultDrop FaultKind = iota
+	// FaultDelay sends the message after Fault.Delay.
+	FaultDelay
+	// FaultTruncate sends the first half of the message and closes the stream.
+	FaultTruncate
+	// FaultCorrupt flips a byte in the middle of the message.
+	FaultCorrupt
+	// FaultAbort resets the stream instead of sending the message, like a connection that dies.
+	FaultAbort
+	// FaultClose sends the message and closes the stream, like a party that stops right after it.
+	FaultClose
+)
+
+// ErrInjectedAbort is returned by FaultyStream.Write for a message that a FaultAbort was injected into.
+var ErrInjectedAbort = errors.New("the stream was reset by an injected fault")
+
+// Fault is injected into the nth message a node writes to a stream. Messages are counted from 1 and include the
+// handshake.
+//
+// The requester writes its hello, its identity card, the request and then one acknowledgement per round, where round 0
+// is the encrypted datum. The listener writes its hello, its identity card, the encrypted datum and then the data of
+// rounds 1 to the minimum of its rounds or more. Only the last round is real, so the rounds up to the minimum are
+// always fake. RequesterMessage and ListenerMessage return the number of the message of a round.
+type Fault struct {
+	Message int
+	Kind    FaultKind
+	// Delay is only used by FaultDelay
+	Delay time.Duration
+}
+
+// RequesterMessage returns the number of the acknowledgement the requester writes in the round.
+func RequesterMessage(round int) int {
+	return 4 + round
+}
+
+// ListenerMessage returns the number of the message the listener writes in the round.
+func ListenerMessage(round int) int {
+	return 3 + round
+}
+
+// FaultyStream injects faults into the messages written to a stream. It relies on the length-prefixed frames of
+// constants.P2PProtocolName; the bytes written to it are collected until a frame is complete.
+type FaultyStream struct {
+	libNetwork.Stream
+
+	mutex   sync.Mutex
+	faults  map[int]Fault
+	pending []byte
+	written int
+}
+
+// NewFaultyStream wraps the stream. Messages without a fault are passed on unchanged.
+func NewFaultyStream(stream libNetwork.Stream, faults ...Fault) *FaultyStream {
+	faultyStream := &FaultyStream{
+		Stream: stream,
+		faults: make(map[int]Fault),
+	}
+
+	for _, fault := range faults {
+		faultyStream.faults[fault.Message] = fault
+	}
+
+	return faultyStream
+}
+
+// Write passes every complete frame in the written bytes on to the stream, unless a fault is injected into it.
+func (stream *FaultyStream) Write(p []byte) (int, error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.pending = append(stream.pending, p...)
+
+	for {
+		length, prefixLength := binary.Uvarint(stream.pending)
+		if prefixLength <= 0 || uint64(len(stream.pending)-prefixLength) < length {
+			// The frame is not complete yet
+			break
+		}
+
+		frameLength := prefixLength + int(length)
+		frame := append([]byte(nil), stream.pending[:frameLength]...)
+		stream.pending = stream.pending[frameLength:]
+
+		err := stream.writeFrame(frame, prefixLength)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// writeFrame writes the frame with the fault of its message number. The mutex has to be held.
+func (stream *FaultyStream) writeFrame(frame []byte, prefixLength int) error {
+	stream.written++
+
+	fault, ok := stream.faults[stream.written]
+	if !ok {
+		_, err := stream.Stream.Write(frame)
+		return err
+	}
+
+	payloadLength := len(frame) - prefixLength
+
+	switch fault.Kind {
+	case FaultDrop:
+		return nil
+	case FaultDelay:
+		time.Sleep(fault.Delay)
+	case FaultTruncate:
+		_, err := stream.Stream.Write(frame[:prefixLength+payloadLength/2])
+		if err != nil {
+			return err
+		}
+
+		return stream.Stream.Close()
+	case FaultCorrupt:
+		if payloadLength > 0 {
+			frame[prefixLength+payloadLength/2] ^= 0xff
+		}
+	case FaultAbort:
+		_ = stream.Stream.Reset()
+		return ErrInjectedAbort
+	case FaultClose:
+		_, err := stream.Stream.Write(frame)
+		if err != nil {
+			return err
+		}
+
+		return stream.Stream.Close()
+	}
+
+	_, err := stream.Stream.Write(frame)
+	return err
+}
@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	libNetwork "github.com/libp2p/go-libp2p-core/network"
+	"node/p2p"
+)
+
+// _bufferStream is a stream that writes into a buffer. Only the methods used by FaultyStream are implemented.
+type _bufferStream struct {
+	libNetwork.Stream
+	buffer bytes.Buffer
+	closed bool
+}
+
+func (stream *_bufferStream) Write(p []byte) (int, error) {
+	if stream.closed {
+		return 0, errors.New("stream is closed")
+	}
+
+	return stream.buffer.Write(p)
+}
+
+func (stream *_bufferStream) Close() error {
+	stream.closed = true
+	return nil
+}
+
+func (stream *_bufferStream) Reset() error {
+	return stream.Close()
+}
+
+func _writeFrames(t *testing.T, stream *FaultyStream, frames []string) error {
+	t.Helper()
+
+	// A small buffer splits the frames over several writes
+	rw := bufio.NewReadWriter(bufio.NewReader(stream), bufio.NewWriterSize(stream, 16))
+	for _, frame := range frames {
+		err := p2p.WriteFrame(rw, []byte(frame))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func _readFrames(buffer *bytes.Buffer) []string {
+	rw := bufio.NewReadWriter(bufio.NewReader(buffer), nil)
+
+	var frames []string
+	for {
+		frame, err := p2p.ReadFrame(rw, time.Second)
+		if err != nil {
+			return frames
+		}
+
+		frames = append(frames, string(frame))
+	}
+}
+
+func TestFaultyStream(t *testing.T) {
+	frames := []string{"hello", strings.Repeat("identity card ", 10), "request", "acknowledgement"}
+
+	tests := []struct {
+		name     string
+		fault    Fault
+		expected []string
+		err      error
+	}{
+		{"drop", Fault{Message: 2, Kind: FaultDrop}, []string{frames[0], frames[2], frames[3]}, nil},
+		{"delay", Fault{Message: 1, Kind: FaultDelay, Delay: 10 * time.Millisecond}, frames, nil},
+		{"corrupt", Fault{Message: 3, Kind: FaultCorrupt}, []string{frames[0], frames[1], "req\x8aest", frames[3]}, nil},
+		// The truncated frame cannot be read and the stream is closed afterwards
+		{"truncate", Fault{Message: 2, Kind: FaultTruncate}, []string{frames[0]}, nil},
+		{"abort", Fault{Message: 3, Kind: FaultAbort}, []string{frames[0], frames[1]}, ErrInjectedAbort},
+		// The message is sent in full, the following ones cannot be written
+		{"close", Fault{Message: 2, Kind: FaultClose}, []string{frames[0], frames[1]}, nil},
+	}
+
+	for _, test := range tests {
+		underlying := &_bufferStream{}
+		err := _writeFrames(t, NewFaultyStream(underlying, test.fault), frames)
+
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("TestFaultyStream - %s: Expected error %v, got %v\n", test.name, test.err, err)
+		} else if test.err == nil && test.fault.Kind != FaultTruncate && test.fault.Kind != FaultClose && err != nil {
+			t.Errorf("TestFaultyStream - %s: Could not write frames: %s\n", test.name, err)
+		}
+
+		received := _readFrames(&underlying.buffer)
+		if strings.Join(received, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("TestFaultyStream - %s: Unexpected frames %q\n", test.name, received)
+		}
+	}
+}
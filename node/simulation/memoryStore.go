@@ -0,0 +1,118 @@
+package simulation
+
+import (
+	"sync"
+
+	"node/storage"
+)
+
+// MemoryStore is a storage.UsageLogStore that keeps the logs in memory. If a failure is set, Append returns it
+// instead of storing the log.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	logs    []storage.BlockchainPayload
+	failure error
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{logs: make([]storage.BlockchainPayload, 0)}
+}
+
+// Fail lets every following Append return err. A nil error lets Append succeed again.
+func (store *MemoryStore) Fail(err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.failure = err
+}
+
+// Logs returns a copy of all stored logs.
+func (store *MemoryStore) Logs() []storage.BlockchainPayload {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return append([]storage.BlockchainPayload(nil), store.logs...)
+}
+
+// Name implements storage.UsageLogStore.
+func (store *MemoryStore) Name() string {
+	return "memory"
+}
+
+// Append implements storage.UsageLogStore.
+func (store *MemoryStore) Append(payload storage.BlockchainPayload) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.failure != nil {
+		return store.failure
+	}
+
+	store.logs = append(store.logs, payload)
+	return nil
+}
+
+// QueryByPseudonym implements storage.UsageLogStore.
+func (store *MemoryStore) QueryByPseudonym(pseudonym string) (storage.BlockchainPayload, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.find(pseudonym)
+	if index < 0 {
+		return storage.BlockchainPayload{}, storage.ErrLogNotFound
+	}
+
+	return store.logs[index], nil
+}
+
+// QueryAll implements storage.UsageLogStore.
+func (store *MemoryStore) QueryAll(handle func(payload storage.BlockchainPayload) error) error {
+	for _, payload := range store.Logs() {
+		err := handle(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update implements storage.UsageLogStore.
+func (store *MemoryStore) Update(pseudonym string, payload storage.BlockchainPayload) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.find(pseudonym)
+	if index < 0 {
+		return storage.ErrLogNotFound
+	}
+
+	store.logs[index] = payload
+	return nil
+}
+
+// Delete implements storage.UsageLogStore.
+func (store *MemoryStore) Delete(pseudonym string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.find(pseudonym)
+	if index < 0 {
+		return storage.ErrLogNotFound
+	}
+
+	store.logs = append(store.logs[:index], store.logs[index+1:]...)
+	return nil
+}
+
+// find returns the index of the log of the consumer or owner with the pseudonym or -1. The mutex has to be held.
+func (store *MemoryStore) find(pseudonym string) int {
+	for i, payload := range store.logs {
+		if payload.PseudonymConsumer == pseudonym || payload.PseudonymOwner == pseudonym {
+			return i
+		}
+	}
+
+	return -1
+}
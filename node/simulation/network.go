@@ -0,0 +1,451 @@
+// Package simulation runs listeners and requesters in one process. The nodes talk libp2p over the loopback interface
+// and run the real exchange code of node/exchange, while Revolori and the usage log stores are replaced by local
+// stand-ins. This allows scripting exchanges, fake chatter and failures and checking the stored proofs and usage logs.
+package simulation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
+	libNetwork "github.com/libp2p/go-libp2p-core/network"
+	libPeer "github.com/libp2p/go-libp2p-core/peer"
+	"node/consent"
+	"node/constants"
+	"node/datum"
+	"node/exchange"
+	"node/logging"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/policy"
+	"node/revoloridev"
+	"node/storage"
+)
+
+const (
+	// identityKeySize is smaller than constants.RSAKeySize so that adding nodes is fast. The keys of the exchanges
+	// themselves keep their full size.
+	identityKeySize = 2048
+	// DefaultRequestTimeout is used if a Request has no timeout.
+	DefaultRequestTimeout = 2 * time.Minute
+)
+
+// ProofPassphrase encrypts the private keys of all proofs written in a simulation.
+var ProofPassphrase = []byte("simulation")
+
+// ErrListenerNotFound is returned by Request if no reachable listener has the requested SSOID.
+var ErrListenerNotFound = errors.New("no reachable listener has the requested SSOID")
+
+// ErrExchangeFailed is returned by Request if the real exchange was started but broke off.
+var ErrExchangeFailed = errors.New("the real exchange failed")
+
+// Network is a set of nodes that share one Revolori stand-in. Every node writes its proofs to its own directory.
+type Network struct {
+	// MaxWaitTime is passed to the exchanges of all nodes. It can be raised if the simulation runs on few cores, where
+	// the nodes compete for the time to create their keys.
+	MaxWaitTime time.Duration
+
+	revolori  *revoloridev.Server
+	directory string
+
+	mutex      sync.Mutex
+	listeners  []*ListenerNode
+	requesters []*RequesterNode
+
+	connectionCount int64
+}
+
+// ListenerConfig configures a listener of the network.
+type ListenerConfig struct {
+	// SSOID is the data owner the listener serves
+	SSOID string
+	// DatumProvider defaults to datum.EchoProvider
+	DatumProvider datum.DatumProvider
+	// AccessPolicy allows every verified requester if it is nil
+	AccessPolicy   *policy.Engine
+	ConsentAsker   consent.Asker
+	ConsentTimeout time.Duration
+	// Stores receive the usage logs in addition to the listener's MemoryStore
+	Stores []storage.UsageLogStore
+	// ArbiterPublicKey is the arbiter the listener escrows the decryption data with if a requester asks for it
+	ArbiterPublicKey *rsa.PublicKey
+	// Rounds default to nP.DefaultRounds
+	Rounds nP.Rounds
+}
+
+// ListenerNode is a listener of the network.
+type ListenerNode struct {
+	SSOID string
+	// Store receives the usage log of every real exchange of the listener
+	Store          *MemoryStore
+	ProofDirectory string
+	PrivateKey     rsa.PrivateKey
+
+	host host.Host
+	// active is the number of streams that are currently handled
+	active int32
+
+	// faultMutex protects faults, which are injected into the next stream the listener handles
+	faultMutex sync.Mutex
+	faults     []Fault
+}
+
+// RequesterNode is a requester of the network. It can run any number of requests, one after another.
+type RequesterNode struct {
+	SSOID          string
+	ProofDirectory string
+	PrivateKey     rsa.PrivateKey
+
+	host          host.Host
+	identityCards p2p.SignedIdentityCards
+}
+
+// Request describes the datum a requester asks for.
+type Request struct {
+	// SSOID is the listener the datum is requested from
+	SSOID         string
+	Datum         string
+	Justification string
+	// EnableFakeChatter runs fake exchanges with every other listener of the network
+	EnableFakeChatter bool
+	// Timeout defaults to DefaultRequestTimeout
+	Timeout time.Duration
+	// RequesterFaults are injected into the messages the requester writes to the requested listener
+	RequesterFaults []Fault
+	// ListenerFaults are injected into the messages the requested listener writes to the requester
+	ListenerFaults []Fault
+	// ArbiterPublicKey asks the listener to escrow the decryption data with the arbiter
+	ArbiterPublicKey *rsa.PublicKey
+}
+
+// NewNetwork returns an empty network whose nodes store their proofs below directory.
+func NewNetwork(directory string) (*Network, error) {
+	revoloriKey, err := rsa.GenerateKey(rand.Reader, identityKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("simulation/NewNetwork - Could not create Revolori key: %w", err)
+	}
+
+	return &Network{
+		MaxWaitTime: constants.MaxWaitTime,
+		revolori:    revoloridev.NewServer(revoloriKey, nil),
+		directory:   directory,
+	}, nil
+}
+
+// RevoloriPublicKey returns the key that verifies the identity cards of all nodes.
+func (network *Network) RevoloriPublicKey() rsa.PublicKey {
+	return network.revolori.PublicKey()
+}
+
+// AddListener starts a listener that serves requests until the network is closed.
+func (network *Network) AddListener(config ListenerConfig) (*ListenerNode, error) {
+	h, privateKey, identityCards, err := network.createNode(config.SSOID)
+	if err != nil {
+		return nil, fmt.Errorf("simulation/Network.AddListener - %w", err)
+	}
+
+	datumProvider := config.DatumProvider
+	if datumProvider == nil {
+		datumProvider = datum.EchoProvider{}
+	}
+
+	node := &ListenerNode{
+		SSOID:          config.SSOID,
+		Store:          NewMemoryStore(),
+		ProofDirectory: network.proofDirectory("listener", config.SSOID),
+		PrivateKey:     privateKey,
+		host:           h,
+	}
+
+	exchangeListener := exchange.NewListener(exchange.ListenerOptions{
+		RevoloriPublicKey: network.RevoloriPublicKey(),
+		PrivateKey:        privateKey,
+		IdentityCards:     identityCards,
+		DatumProvider:     datumProvider,
+		AccessPolicy:      config.AccessPolicy,
+		ConsentAsker:      config.ConsentAsker,
+		ConsentTimeout:    config.ConsentTimeout,
+		UsageLogStores:    append([]storage.UsageLogStore{node.Store}, config.Stores...),
+		ProofPassphrase:   ProofPassphrase,
+		ProofDirectory:    node.ProofDirectory,
+		MaxWaitTime:       network.MaxWaitTime,
+		ArbiterPublicKey:  config.ArbiterPublicKey,
+		Rounds:            config.Rounds,
+	})
+
+	handleStream := func(s libNetwork.Stream) {
+		atomic.AddInt32(&node.active, 1)
+		defer atomic.AddInt32(&node.active, -1)
+		defer s.Close()
+
+		var stream libNetwork.Stream = s
+		if faults := node.takeFaults(); len(faults) > 0 {
+			stream = NewFaultyStream(s, faults...)
+		}
+
+		connectionID := atomic.AddInt64(&network.connectionCount, 1)
+		exchangeListener.HandleStream(p2p.NewReadWriter(stream, string(s.Protocol())), connectionID)
+	}
+	h.SetStreamHandler(constants.P2PProtocolName, handleStream)
+	h.SetStreamHandler(constants.P2PLegacyProtocolName, handleStream)
+
+	network.mutex.Lock()
+	network.listeners = append(network.listeners, node)
+	network.mutex.Unlock()
+
+	return node, nil
+}
+
+// AddRequester creates a requester with an identity card for the SSOID.
+func (network *Network) AddRequester(ssoid string) (*RequesterNode, error) {
+	h, privateKey, identityCards, err := network.createNode(ssoid)
+	if err != nil {
+		return nil, fmt.Errorf("simulation/Network.AddRequester - %w", err)
+	}
+
+	node := &RequesterNode{
+		SSOID:          ssoid,
+		ProofDirectory: network.proofDirectory("requester", ssoid),
+		PrivateKey:     privateKey,
+		host:           h,
+		identityCards:  identityCards,
+	}
+
+	network.mutex.Lock()
+	network.requesters = append(network.requesters, node)
+	network.mutex.Unlock()
+
+	return node, nil
+}
+
+// Request lets the requester contact every listener of the network like after a peer search, and returns the result
+// of the real exchange. It returns once the requester and all listeners finished their streams, so the proofs and
+// usage logs can be checked right away.
+func (network *Network) Request(requester *RequesterNode, request Request) (exchange.Result, error) {
+	timeout := request.Timeout
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exchangeRequester := exchange.NewRequester(exchange.RequesterOptions{
+		RevoloriPublicKey: network.RevoloriPublicKey(),
+		PrivateKey:        requester.PrivateKey,
+		IdentityCards:     requester.identityCards,
+		SSOID:             request.SSOID,
+		Justification:     request.Justification,
+		Datum:             request.Datum,
+		EnableFakeChatter: request.EnableFakeChatter,
+		ProofPassphrase:   ProofPassphrase,
+		ProofDirectory:    requester.ProofDirectory,
+		MaxWaitTime:       network.MaxWaitTime,
+		ArbiterPublicKey:  request.ArbiterPublicKey,
+	})
+
+	start := time.Now()
+	var streams sync.WaitGroup
+
+	for _, listener := range network.Listeners() {
+		remote := libPeer.AddrInfo{ID: listener.host.ID(), Addrs: listener.host.Addrs()}
+		isRequested := listener.SSOID == request.SSOID
+		if isRequested {
+			listener.setFaults(request.ListenerFaults)
+		}
+
+		err := requester.host.Connect(ctx, remote)
+		if err != nil {
+			log.Info.Printf("simulation/Network.Request - Could not connect to '%s': %s\n", listener.SSOID, err)
+			continue
+		}
+
+		stream, err := requester.host.NewStream(ctx, remote.ID, constants.P2PProtocolName)
+		if err != nil {
+			log.Info.Printf("simulation/Network.Request - Could not open stream to '%s': %s\n", listener.SSOID, err)
+			continue
+		}
+
+		if isRequested && len(request.RequesterFaults) > 0 {
+			stream = NewFaultyStream(stream, request.RequesterFaults...)
+		}
+
+		streams.Add(1)
+		go func(stream libNetwork.Stream, remote libPeer.AddrInfo) {
+			defer streams.Done()
+			defer stream.Close()
+
+			rw := p2p.NewReadWriter(stream, string(stream.Protocol()))
+			exchangeRequester.HandleStream(rw, remote, start, start, exchange.Result{})
+		}(stream, remote)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		streams.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return exchange.Result{}, fmt.Errorf("simulation/Network.Request - %w", ctx.Err())
+	}
+
+	network.settle()
+
+	// Every stream has ended, so the result is either there or will never be
+	select {
+	case result := <-exchangeRequester.Done():
+		return result, nil
+	default:
+	}
+
+	select {
+	case <-exchangeRequester.Failed():
+		return exchange.Result{}, ErrExchangeFailed
+	default:
+		return exchange.Result{}, ErrListenerNotFound
+	}
+}
+
+// Listeners returns all listeners that were added, including stopped ones.
+func (network *Network) Listeners() []*ListenerNode {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	return append([]*ListenerNode(nil), network.listeners...)
+}
+
+// Close stops all nodes of the network.
+func (network *Network) Close() {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	for _, listener := range network.listeners {
+		_ = listener.host.Close()
+	}
+
+	for _, requester := range network.requesters {
+		_ = requester.host.Close()
+	}
+}
+
+// createNode creates the identity key and host of a node and lets the Revolori stand-in sign its identity card.
+func (network *Network) createNode(ssoid string) (host.Host, rsa.PrivateKey, p2p.SignedIdentityCards, error) {
+	if strings.TrimSpace(ssoid) == "" {
+		return nil, rsa.PrivateKey{}, nil, errors.New("the SSOID is empty")
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, identityKeySize)
+	if err != nil {
+		return nil, rsa.PrivateKey{}, nil, fmt.Errorf("could not create identity key: %w", err)
+	}
+
+	hostKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, rsa.PrivateKey{}, nil, fmt.Errorf("could not create host key: %w", err)
+	}
+
+	h, err := p2p.MakeLoopbackHost(hostKey)
+	if err != nil {
+		return nil, rsa.PrivateKey{}, nil, fmt.Errorf("could not create host: %w", err)
+	}
+
+	revoloriSignedCard, err := network.revolori.Sign(ssoid, privateKey.PublicKey)
+	if err != nil {
+		_ = h.Close()
+		return nil, rsa.PrivateKey{}, nil, err
+	}
+
+	identityCards, err := p2p.SignIdentityCards(revoloriSignedCard, privateKey, h.ID())
+	if err != nil {
+		_ = h.Close()
+		return nil, rsa.PrivateKey{}, nil, err
+	}
+
+	return h, *privateKey, identityCards, nil
+}
+
+func (network *Network) proofDirectory(role string, ssoid string) string {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	// The index keeps the directories of nodes with the same SSOID apart
+	index := len(network.listeners) + len(network.requesters)
+	return filepath.Join(network.directory, fmt.Sprintf("%s-%d-%s", role, index, strings.ReplaceAll(ssoid, string(filepath.Separator), "_")))
+}
+
+// settle waits until no listener handles a stream anymore, but at most as long as a listener could wait for the
+// requester.
+func (network *Network) settle() {
+	deadline := time.Now().Add(network.MaxWaitTime * 5)
+
+	for _, listener := range network.Listeners() {
+		for atomic.LoadInt32(&listener.active) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// setFaults replaces the faults that are injected into the next stream.
+func (node *ListenerNode) setFaults(faults []Fault) {
+	node.faultMutex.Lock()
+	defer node.faultMutex.Unlock()
+
+	node.faults = faults
+}
+
+// takeFaults returns the faults for the stream that is handled now and removes them.
+func (node *ListenerNode) takeFaults() []Fault {
+	node.faultMutex.Lock()
+	defer node.faultMutex.Unlock()
+
+	faults := node.faults
+	node.faults = nil
+
+	return faults
+}
+
+// Stop closes the host of the listener, so it cannot be reached anymore.
+func (node *ListenerNode) Stop() error {
+	return node.host.Close()
+}
+
+// Proofs returns the paths of the proofs the listener stored.
+func (node *ListenerNode) Proofs() ([]string, error) {
+	return listProofs(node.ProofDirectory)
+}
+
+// Proofs returns the paths of the proofs the requester stored.
+func (node *RequesterNode) Proofs() ([]string, error) {
+	return listProofs(node.ProofDirectory)
+}
+
+func listProofs(directory string) ([]string, error) {
+	files, err := os.ReadDir(directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("simulation/listProofs - Could not read proof directory: %w", err)
+	}
+
+	var proofs []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			proofs = append(proofs, filepath.Join(directory, file.Name()))
+		}
+	}
+
+	return proofs, nil
+}
@@ -0,0 +1,528 @@
+package simulation
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"node/arbiter"
+	"node/constants"
+	"node/datum"
+	nP "node/nonRepudiation"
+	"node/p2p"
+	"node/policy"
+	"node/storage"
+	"node/verification"
+)
+
+func _newNetwork(t *testing.T) *Network {
+	t.Helper()
+
+	network, err := NewNetwork(t.TempDir())
+	if err != nil {
+		t.Fatalf("Could not create network: %s\n", err)
+	}
+	t.Cleanup(network.Close)
+
+	// Creating the non-repudiation requirement alone can take several seconds if all nodes share one core
+	network.MaxWaitTime = 8 * time.Second
+
+	return network
+}
+
+func _addListener(t *testing.T, network *Network, config ListenerConfig) *ListenerNode {
+	t.Helper()
+
+	listener, err := network.AddListener(config)
+	if err != nil {
+		t.Fatalf("Could not add listener '%s': %s\n", config.SSOID, err)
+	}
+
+	return listener
+}
+
+func _addRequester(t *testing.T, network *Network, ssoid string) *RequesterNode {
+	t.Helper()
+
+	requester, err := network.AddRequester(ssoid)
+	if err != nil {
+		t.Fatalf("Could not add requester '%s': %s\n", ssoid, err)
+	}
+
+	return requester
+}
+
+// _verifyProof verifies the only proof in the list and returns who recorded it, the other party and the datum.
+func _verifyProof(t *testing.T, network *Network, proofs []string) (constants.MessageType, string, string, storage.ProofMetadata) {
+	t.Helper()
+
+	if len(proofs) != 1 {
+		t.Fatalf("Expected one proof, got %d\n", len(proofs))
+	}
+
+	messages, privateKey, identityKey, parameters, err := storage.LoadExchange(proofs[0], ProofPassphrase)
+	if err != nil {
+		t.Fatalf("Could not load proof: %s\n", err)
+	}
+
+	metadata, _, err := storage.LoadProofMetadata(proofs[0])
+	if err != nil {
+		t.Fatalf("Could not load proof metadata: %s\n", err)
+	}
+
+	revoloriPublicKey := network.RevoloriPublicKey()
+	recorder, ssoid, decrypted, err := verification.Exchange(messages, &privateKey.PublicKey, &identityKey, parameters, &revoloriPublicKey)
+	if err != nil {
+		t.Fatalf("Could not verify proof: %s\n", err)
+	}
+
+	return recorder, ssoid, decrypted, metadata
+}
+
+func _countProofs(t *testing.T, listeners ...*ListenerNode) int {
+	t.Helper()
+
+	count := 0
+	for _, listener := range listeners {
+		proofs, err := listener.Proofs()
+		if err != nil {
+			t.Fatalf("Could not list proofs of '%s': %s\n", listener.SSOID, err)
+		}
+
+		count += len(proofs) + len(listener.Store.Logs())
+	}
+
+	return count
+}
+
+func TestRealExchange(t *testing.T) {
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice"})
+	requester := _addRequester(t, network, "bob")
+
+	result, err := network.Request(requester, Request{SSOID: "alice", Datum: "heartRate", Justification: "Treatment"})
+	if err != nil || !result.Success {
+		t.Fatalf("TestRealExchange - Exchange failed: %v, %v\n", result, err)
+	} else if result.Value != "Requested datum: heartRate" {
+		t.Errorf("TestRealExchange - Unexpected datum '%s'\n", result.Value)
+	}
+
+	listenerProofs, _ := listener.Proofs()
+	recorder, ssoid, _, metadata := _verifyProof(t, network, listenerProofs)
+	if recorder != constants.MessageTypeListener || ssoid != "bob" || metadata.PeerSSOID != "bob" {
+		t.Errorf("TestRealExchange - Unexpected listener proof: %d, '%s', '%s'\n", recorder, ssoid, metadata.PeerSSOID)
+	}
+
+	requesterProofs, _ := requester.Proofs()
+	recorder, ssoid, decrypted, _ := _verifyProof(t, network, requesterProofs)
+	if recorder != constants.MessageTypeRequester || ssoid != "alice" || decrypted != result.Value {
+		t.Errorf("TestRealExchange - Unexpected requester proof: %d, '%s', '%s'\n", recorder, ssoid, decrypted)
+	}
+
+	// The usage log is found by the pseudonym of the listener's conversation key
+	logs := listener.Store.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("TestRealExchange - Expected one usage log, got %d\n", len(logs))
+	}
+
+	_, err = listener.Store.QueryByPseudonym(metadata.Pseudonym)
+	if err != nil {
+		t.Errorf("TestRealExchange - The usage log does not belong to the proof: %s\n", err)
+	}
+}
+
+func TestRounds(t *testing.T) {
+	rounds, err := nP.NewRounds(constants.RoundDistributionUniform, 2, 10, 0.25)
+	if err != nil {
+		t.Fatalf("TestRounds - Could not create rounds: %s\n", err)
+	}
+
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice", Rounds: rounds})
+	requester := _addRequester(t, network, "bob")
+
+	result, err := network.Request(requester, Request{SSOID: "alice", Datum: "heartRate", Justification: "Treatment"})
+	if err != nil || !result.Success {
+		t.Fatalf("TestRounds - Exchange failed: %v, %v\n", result, err)
+	}
+
+	// Both proofs keep to the rounds the listener announced
+	listenerProofs, _ := listener.Proofs()
+	_, _, _, metadata := _verifyProof(t, network, listenerProofs)
+	if metadata.Parameters.Rounds != rounds {
+		t.Errorf("TestRounds - Unexpected rounds in the listener proof: %+v\n", metadata.Parameters.Rounds)
+	}
+
+	requesterProofs, _ := requester.Proofs()
+	_, _, _, metadata = _verifyProof(t, network, requesterProofs)
+	if metadata.Parameters.Rounds != rounds {
+		t.Errorf("TestRounds - Unexpected rounds in the requester proof: %+v\n", metadata.Parameters.Rounds)
+	}
+
+	// The exchange took at most 5 fake rounds, which other rounds do not allow. The stored rounds also have to be the
+	// ones the listener signed in its response
+	revoloriPublicKey := network.RevoloriPublicKey()
+	for _, proof := range append(listenerProofs, requesterProofs...) {
+		messages, privateKey, identityKey, parameters, err := storage.LoadExchange(proof, ProofPassphrase)
+		if err != nil {
+			t.Fatalf("TestRounds - Could not load proof: %s\n", err)
+		}
+
+		parameters.Rounds.Minimum = rounds.Maximum + 1
+		parameters.Rounds.Maximum = rounds.Maximum + 4
+		_, _, _, err = verification.Exchange(messages, &privateKey.PublicKey, &identityKey, parameters, &revoloriPublicKey)
+		if err == nil {
+			t.Errorf("TestRounds - A proof was verified with rounds it does not keep to\n")
+		}
+	}
+}
+
+func TestHandshakeBinding(t *testing.T) {
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice"})
+	requester := _addRequester(t, network, "bob")
+
+	result, err := network.Request(requester, Request{SSOID: "alice", Datum: "heartRate", Justification: "Treatment"})
+	if err != nil || !result.Success {
+		t.Fatalf("TestHandshakeBinding - Exchange failed: %v, %v\n", result, err)
+	}
+
+	listenerProofs, _ := listener.Proofs()
+	requesterProofs, _ := requester.Proofs()
+
+	// Hellos that negotiate the same parameters still have another hash than the one both parties signed
+	otherHello := p2p.OwnHello()
+	otherHello.Codecs = append(otherHello.Codecs, "protobuf")
+	otherHelloBytes, err := json.Marshal(otherHello)
+	if err != nil {
+		t.Fatalf("TestHandshakeBinding - Could not marshal hello: %s\n", err)
+	}
+
+	tampered := []func(*p2p.SessionParameters){
+		func(p *p2p.SessionParameters) { p.RequesterHello = string(otherHelloBytes) },
+		func(p *p2p.SessionParameters) {
+			p.Version = constants.LegacyProtocolVersion
+			p.RequesterHello, p.ListenerHello = "", ""
+		},
+	}
+
+	revoloriPublicKey := network.RevoloriPublicKey()
+	for _, proof := range append(listenerProofs, requesterProofs...) {
+		messages, privateKey, identityKey, parameters, err := storage.LoadExchange(proof, ProofPassphrase)
+		if err != nil {
+			t.Fatalf("TestHandshakeBinding - Could not load proof: %s\n", err)
+		} else if parameters.Version != constants.ProtocolVersion || parameters.RequesterHello == "" || parameters.ListenerHello == "" {
+			t.Errorf("TestHandshakeBinding - The Hellos were not stored: %+v\n", parameters)
+		}
+
+		for i, tamper := range tampered {
+			changed := parameters
+			tamper(&changed)
+
+			err = changed.CheckSupported()
+			if err != nil {
+				t.Fatalf("TestHandshakeBinding - Case %d: The tampered parameters are inconsistent: %s\n", i, err)
+			}
+
+			_, _, _, err = verification.Exchange(messages, &privateKey.PublicKey, &identityKey, changed, &revoloriPublicKey)
+			if err == nil {
+				t.Errorf("TestHandshakeBinding - Case %d: A proof was verified with Hellos that were not signed\n", i)
+			}
+		}
+	}
+}
+
+func TestFakeChatter(t *testing.T) {
+	network := _newNetwork(t)
+	alice := _addListener(t, network, ListenerConfig{SSOID: "alice"})
+	others := []*ListenerNode{
+		_addListener(t, network, ListenerConfig{SSOID: "carol"}),
+		_addListener(t, network, ListenerConfig{SSOID: "dave"}),
+	}
+	requester := _addRequester(t, network, "bob")
+
+	result, err := network.Request(requester, Request{SSOID: "alice", Datum: "steps", Justification: "Research", EnableFakeChatter: true})
+	if err != nil || !result.Success {
+		t.Fatalf("TestFakeChatter - Exchange failed: %v, %v\n", result, err)
+	}
+
+	// Only the real exchange leaves a proof and a usage log
+	if _countProofs(t, alice) != 2 {
+		t.Errorf("TestFakeChatter - The real exchange was not stored\n")
+	} else if _countProofs(t, others...) != 0 {
+		t.Errorf("TestFakeChatter - Fake chatter was stored\n")
+	}
+
+	_, err = network.Request(requester, Request{SSOID: "erin", Datum: "steps", Justification: "Research", EnableFakeChatter: true})
+	if !errors.Is(err, ErrListenerNotFound) {
+		t.Errorf("TestFakeChatter - Request for unknown listener returned %v\n", err)
+	} else if _countProofs(t, append(others, alice)...) != 2 {
+		t.Errorf("TestFakeChatter - Fake chatter for an unknown listener was stored\n")
+	}
+}
+
+func TestRejectedRequests(t *testing.T) {
+	directory := t.TempDir()
+
+	policyPath := filepath.Join(directory, "policy.json")
+	_ = os.WriteFile(policyPath, []byte(`{"default": "deny", "rules": [{"name": "bob", "ssoids": ["bob"]}]}`), 0o600)
+	engine, err := policy.NewEngine(policyPath)
+	if err != nil {
+		t.Fatalf("TestRejectedRequests - Could not load policy: %s\n", err)
+	}
+
+	datumDirectory := filepath.Join(directory, "data")
+	_ = os.Mkdir(datumDirectory, 0o700)
+	provider, err := datum.NewDirectoryProvider(datumDirectory)
+	if err != nil {
+		t.Fatalf("TestRejectedRequests - Could not create datum provider: %s\n", err)
+	}
+
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice", AccessPolicy: engine, DatumProvider: provider})
+
+	tests := []struct {
+		name      string
+		requester string
+	}{
+		// Carol is denied by the policy, the datum Bob asks for does not exist
+		{"denied", "carol"},
+		{"refused", "bob"},
+	}
+
+	for _, test := range tests {
+		requester := _addRequester(t, network, test.requester)
+
+		result, err := network.Request(requester, Request{SSOID: "alice", Datum: "missing", Justification: "Research"})
+		if err != nil || result.Success || result.Value == "" {
+			t.Errorf("TestRejectedRequests - %s: Unexpected result: %v, %v\n", test.name, result, err)
+		}
+
+		proofs, _ := requester.Proofs()
+		if len(proofs) != 0 || _countProofs(t, listener) != 0 {
+			t.Errorf("TestRejectedRequests - %s: The rejected request was stored\n", test.name)
+		}
+	}
+}
+
+func TestQuota(t *testing.T) {
+	directory := t.TempDir()
+
+	policyPath := filepath.Join(directory, "policy.json")
+	_ = os.WriteFile(policyPath, []byte(`{"default": "deny", "rules": [{"name": "bob", "ssoids": ["bob"], "quota": {"max_requests": 1, "period": "1h"}}]}`), 0o600)
+	engine, err := policy.NewEngine(policyPath)
+	if err != nil {
+		t.Fatalf("TestQuota - Could not load policy: %s\n", err)
+	}
+
+	datumDirectory := filepath.Join(directory, "data")
+	_ = os.Mkdir(datumDirectory, 0o700)
+	_ = os.WriteFile(filepath.Join(datumDirectory, "steps"), []byte("12345"), 0o600)
+	provider, err := datum.NewDirectoryProvider(datumDirectory)
+	if err != nil {
+		t.Fatalf("TestQuota - Could not create datum provider: %s\n", err)
+	}
+
+	network := _newNetwork(t)
+	_addListener(t, network, ListenerConfig{SSOID: "alice", AccessPolicy: engine, DatumProvider: provider})
+	requester := _addRequester(t, network, "bob")
+
+	// A refused request does not use up the quota, the served one does
+	tests := []struct {
+		name    string
+		datum   string
+		success bool
+	}{
+		{"refused", "missing", false},
+		{"served", "steps", true},
+		{"quota exhausted", "steps", false},
+	}
+
+	for _, test := range tests {
+		result, err := network.Request(requester, Request{SSOID: "alice", Datum: test.datum, Justification: "Research"})
+		if err != nil || result.Success != test.success {
+			t.Errorf("TestQuota - %s: Unexpected result: %v, %v\n", test.name, result, err)
+		}
+	}
+}
+
+func TestFailures(t *testing.T) {
+	network := _newNetwork(t)
+	failingStore := NewMemoryStore()
+	failingStore.Fail(errors.New("store is down"))
+	// A failing store must not keep the usage log from the stores after it
+	laterStore := NewMemoryStore()
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice", Stores: []storage.UsageLogStore{failingStore, laterStore}})
+	requester := _addRequester(t, network, "bob")
+
+	// The requester is served before the usage log is stored, so it does not notice the failing store
+	result, err := network.Request(requester, Request{SSOID: "alice", Datum: "steps", Justification: "Research"})
+	if err != nil || !result.Success {
+		t.Fatalf("TestFailures - Exchange failed: %v, %v\n", result, err)
+	}
+
+	proofs, _ := listener.Proofs()
+	if len(proofs) != 1 || len(listener.Store.Logs()) != 1 || len(failingStore.Logs()) != 0 || len(laterStore.Logs()) != 1 {
+		t.Errorf("TestFailures - Unexpected state after a failing store: %d proofs, %d, %d, %d logs\n", len(proofs), len(listener.Store.Logs()), len(failingStore.Logs()), len(laterStore.Logs()))
+	}
+
+	err = listener.Stop()
+	if err != nil {
+		t.Fatalf("TestFailures - Could not stop listener: %s\n", err)
+	}
+
+	_, err = network.Request(requester, Request{SSOID: "alice", Datum: "steps", Justification: "Research"})
+	if !errors.Is(err, ErrListenerNotFound) {
+		t.Errorf("TestFailures - Request for stopped listener returned %v\n", err)
+	}
+}
+
+func TestArbiter(t *testing.T) {
+	arbiterKey, err := rsa.GenerateKey(rand.Reader, identityKeySize)
+	if err != nil {
+		t.Fatalf("TestArbiter - Could not create arbiter key: %s\n", err)
+	}
+
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice", ArbiterPublicKey: &arbiterKey.PublicKey})
+	requester := _addRequester(t, network, "bob")
+	request := Request{SSOID: "alice", Datum: "heartRate", Justification: "Treatment", ArbiterPublicKey: &arbiterKey.PublicKey}
+
+	// The escrow does not change an exchange that ends successfully
+	result, err := network.Request(requester, request)
+	if err != nil || !result.Success || result.Resolvable {
+		t.Fatalf("TestArbiter - Exchange failed: %v, %v\n", result, err)
+	}
+
+	requesterProofs, _ := requester.Proofs()
+	_verifyProof(t, network, requesterProofs)
+	_ = os.Remove(requesterProofs[0])
+
+	// The listener stops before the real decryption data is sent
+	request.ListenerFaults = []Fault{{Message: ListenerMessage(1), Kind: FaultAbort}}
+	result, _ = network.Request(requester, request)
+	if result.Success || !result.Resolvable {
+		t.Fatalf("TestArbiter - Expected a resolvable failure, got %v\n", result)
+	}
+
+	// The listener stored the first exchange and recorded the second one as incomplete. The decryption data of both is
+	// available to the requester, so the owner got both usage logs.
+	if len(listener.Store.Logs()) != 2 {
+		t.Errorf("TestArbiter - Expected two usage logs, got %d\n", len(listener.Store.Logs()))
+	}
+
+	listenerProofs, _ := listener.Proofs()
+	requesterProofs, _ = requester.Proofs()
+	if len(listenerProofs) != 2 || len(requesterProofs) != 1 {
+		t.Fatalf("TestArbiter - Expected two files of the listener and one of the requester, got %d and %d\n", len(listenerProofs), len(requesterProofs))
+	}
+
+	metadata, _, err := storage.LoadProofMetadata(requesterProofs[0])
+	if err != nil || metadata.Role != constants.ProofRoleTranscript {
+		t.Fatalf("TestArbiter - Expected a transcript, got '%s': %v\n", metadata.Role, err)
+	}
+
+	messages, conversationPublicKey, _, parameters, err := storage.LoadExchangeProof(requesterProofs[0])
+	if err != nil {
+		t.Fatalf("TestArbiter - Could not load transcript: %s\n", err)
+	}
+
+	revoloriPublicKey := network.RevoloriPublicKey()
+	otherKey, _ := rsa.GenerateKey(rand.Reader, identityKeySize)
+	_, _, err = arbiter.Resolve(messages, parameters, &revoloriPublicKey, otherKey)
+	if !errors.Is(err, arbiter.ErrWrongArbiter) {
+		t.Errorf("TestArbiter - Another arbiter resolved the transcript: %v\n", err)
+	}
+
+	signedResolution, resolution, err := arbiter.Resolve(messages, parameters, &revoloriPublicKey, arbiterKey)
+	if err != nil || resolution.Verdict != constants.ArbiterVerdictReleased {
+		t.Fatalf("TestArbiter - Could not resolve transcript: '%s', %v\n", resolution.Verdict, err)
+	}
+
+	resolvedPath := filepath.Join(t.TempDir(), "resolved.json")
+	err = storage.ResolveTranscript(requesterProofs[0], resolvedPath, signedResolution)
+	if err != nil {
+		t.Fatalf("TestArbiter - Could not store resolution: %s\n", err)
+	}
+
+	messages, conversationPublicKey, _, parameters, err = storage.LoadExchangeProof(resolvedPath)
+	if err != nil {
+		t.Fatalf("TestArbiter - Could not load resolved transcript: %s\n", err)
+	}
+
+	recorder, ssoid, decrypted, err := verification.Resolved(messages, &conversationPublicKey, parameters, &revoloriPublicKey)
+	if err != nil || recorder != constants.MessageTypeRequester || ssoid != "alice" || decrypted != "Requested datum: heartRate" {
+		t.Errorf("TestArbiter - Unexpected resolution: %d, '%s', '%s', %v\n", recorder, ssoid, decrypted, err)
+	}
+}
+
+func TestArbiterAfterRequesterDrops(t *testing.T) {
+	arbiterKey, err := rsa.GenerateKey(rand.Reader, identityKeySize)
+	if err != nil {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Could not create arbiter key: %s\n", err)
+	}
+
+	network := _newNetwork(t)
+	listener := _addListener(t, network, ListenerConfig{SSOID: "alice", ArbiterPublicKey: &arbiterKey.PublicKey})
+	requester := _addRequester(t, network, "bob")
+
+	// The requester holds the encrypted datum and the escrow, but never acknowledges the response
+	result, _ := network.Request(requester, Request{
+		SSOID:            "alice",
+		Datum:            "heartRate",
+		Justification:    "Treatment",
+		ArbiterPublicKey: &arbiterKey.PublicKey,
+		RequesterFaults:  []Fault{{Message: RequesterMessage(0), Kind: FaultDrop}},
+	})
+	if result.Success || !result.Resolvable {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Expected a resolvable failure, got %v\n", result)
+	}
+
+	listenerProofs, _ := listener.Proofs()
+	requesterProofs, _ := requester.Proofs()
+	if len(listenerProofs) != 1 || len(requesterProofs) != 1 {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Expected one file per party, got %d and %d\n", len(listenerProofs), len(requesterProofs))
+	}
+
+	// The requester can get the decryption data from the arbiter, so the listener logged the usage on its own
+	if len(listener.Store.Logs()) != 1 {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Expected one usage log, got %d\n", len(listener.Store.Logs()))
+	}
+
+	listenerMetadata, _, err := storage.LoadProofMetadata(listenerProofs[0])
+	if err != nil || listenerMetadata.Role != constants.ProofRoleIncomplete {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Expected an incomplete record, got '%s': %v\n", listenerMetadata.Role, err)
+	}
+
+	messages, _, _, parameters, err := storage.LoadExchangeProof(requesterProofs[0])
+	if err != nil {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Could not load transcript: %s\n", err)
+	}
+
+	revoloriPublicKey := network.RevoloriPublicKey()
+	_, resolution, err := arbiter.Resolve(messages, parameters, &revoloriPublicKey, arbiterKey)
+	if err != nil || resolution.Verdict != constants.ArbiterVerdictReleased {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Could not resolve transcript: '%s', %v\n", resolution.Verdict, err)
+	}
+
+	// The owner finds the log with the key of its incomplete record, which proves the requester got the response
+	_, err = listener.Store.QueryByPseudonym(listenerMetadata.Pseudonym)
+	if err != nil {
+		t.Errorf("TestArbiterAfterRequesterDrops - The usage log does not belong to the incomplete record: %s\n", err)
+	}
+
+	messages, conversationPublicKey, identityKey, parameters, err := storage.LoadExchangeProof(listenerProofs[0])
+	if err != nil {
+		t.Fatalf("TestArbiterAfterRequesterDrops - Could not load the incomplete record: %s\n", err)
+	}
+
+	recorder, ssoid, _, err := verification.Incomplete(messages, &conversationPublicKey, &identityKey, parameters, &revoloriPublicKey)
+	if recorder != constants.MessageTypeListener || ssoid != "bob" || !errors.Is(err, verification.ErrIncomplete) {
+		t.Errorf("TestArbiterAfterRequesterDrops - Unexpected verification of the incomplete record: %d, '%s', %v\n", recorder, ssoid, err)
+	}
+}
@@ -14,10 +14,22 @@ type BlockchainPayload struct {
 	EncryptedOwner    UsageLogContent `json:"encrypted_owner"`
 }
 
+// UsageLogContent is the content of a usage log encrypted for either the owner or the consumer. Logs of
+// constants.UsageLogVersionRSA have no version and encrypt Justification and DatumRequest separately. Logs of
+// constants.UsageLogVersionHybrid store both in Ciphertext.
 type UsageLogContent struct {
-	Justification string `json:"explanation"`
-	DatumRequest  string `json:"datum"`
+	Version       int    `json:"version,omitempty"`
+	Justification string `json:"explanation,omitempty"`
+	DatumRequest  string `json:"datum,omitempty"`
 	Timestamp     int64  `json:"timestamp"`
+	WrappedKey    string `json:"wrapped_key,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	Ciphertext    string `json:"ciphertext,omitempty"`
+}
+
+// NewUsageLog creates the usage log of an exchange. The content is encrypted for the owner and the consumer.
+func NewUsageLog(justification string, datum string, ownerPublicKey *rsa.PublicKey, consumerPublicKey *rsa.PublicKey) (BlockchainPayload, error) {
+	return createBlockchainPayload(justification, datum, ownerPublicKey, consumerPublicKey)
 }
 
 func createBlockchainPayload(justification string, datum string, ownerPublicKey *rsa.PublicKey, consumerPublicKey *rsa.PublicKey) (BlockchainPayload, error) {
@@ -51,19 +63,10 @@ func createBlockchainPayload(justification string, datum string, ownerPublicKey
 }
 
 func createAndEncryptLogContent(justification string, datum string, publicKey *rsa.PublicKey) (UsageLogContent, error) {
-	encryptedJustification, err := PublicKeyEncryption(justification, publicKey)
-	if err != nil {
-		return UsageLogContent{}, fmt.Errorf("could not encrypt justification ('%s') because: %w", justification, err)
-	}
-
-	encryptedDatum, err := PublicKeyEncryption(datum, publicKey)
+	content, err := EncryptUsageLogContent(justification, datum, time.Now().Unix(), publicKey)
 	if err != nil {
-		return UsageLogContent{}, fmt.Errorf("could not encrypt datum ('%s') because: %w", datum, err)
+		return UsageLogContent{}, fmt.Errorf("could not encrypt usage log content: %w", err)
 	}
 
-	return UsageLogContent{
-		Justification: encryptedJustification,
-		DatumRequest:  encryptedDatum,
-		Timestamp:     time.Now().Unix(),
-	}, nil
+	return content, nil
 }
@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"node/constants"
+	"node/logging"
+)
+
+// batchAnchor is the transaction input that anchors a batch. It only contains the Merkle root of the logs, so the
+// size of the transaction does not depend on the number of logs.
+type batchAnchor struct {
+	Version    int    `json:"batch_version"`
+	MerkleRoot string `json:"merkle_root"`
+	Count      int    `json:"count"`
+}
+
+// anchoredBatch is the local file of a batch. Every log keeps the exact bytes of its leaf, so its proof can be checked
+// without depending on how the payload is marshalled.
+type anchoredBatch struct {
+	MerkleRoot      string       `json:"merkle_root"`
+	TransactionHash string       `json:"transaction_hash"`
+	Timestamp       int64        `json:"timestamp"`
+	Logs            []batchEntry `json:"logs"`
+}
+
+type batchEntry struct {
+	Log   json.RawMessage   `json:"log"`
+	Proof []MerkleProofStep `json:"proof"`
+}
+
+// pendingLog is a log that waits for its batch to be anchored. The result of the anchoring is sent on done.
+type pendingLog struct {
+	leaf []byte
+	done chan error
+}
+
+// BatchStore collects usage logs and anchors the Merkle root of up to options.BatchSize logs in a single transaction.
+// The logs and their inclusion proofs are kept in options.BatchDirectory, so every log can be proven against the
+// anchored root. Like the blockchain store, it cannot update or delete logs.
+type BatchStore struct {
+	options StoreOptions
+	// gethStore provides the signer
+	gethStore *GethStore
+
+	// mutex protects pending and timer. timer anchors the pending logs once the first of them waited BatchInterval.
+	mutex   sync.Mutex
+	pending []pendingLog
+	timer   *time.Timer
+}
+
+// NewBatchStore returns a store that anchors batches on the blockchain. Logs are anchored once options.BatchSize of
+// them are pending or the first of them waited options.BatchInterval.
+func NewBatchStore(options StoreOptions) (*BatchStore, error) {
+	if options.BatchSize < 1 {
+		return nil, fmt.Errorf("storage/NewBatchStore - The batch size has to be at least 1, got %d", options.BatchSize)
+	} else if options.BatchInterval <= 0 {
+		return nil, fmt.Errorf("storage/NewBatchStore - The batch interval has to be positive, got %s", options.BatchInterval)
+	}
+
+	return &BatchStore{options: options, gethStore: NewGethStore(options)}, nil
+}
+
+// Name returns StoreBlockchainBatch.
+func (store *BatchStore) Name() string {
+	return StoreBlockchainBatch
+}
+
+// Append adds the log to the current batch and returns once the batch is anchored.
+func (store *BatchStore) Append(payload BlockchainPayload) error {
+	leaf, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("storage/BatchStore.Append - Could not marshal the log: %w", err)
+	}
+
+	done := make(chan error, 1)
+
+	store.mutex.Lock()
+	store.pending = append(store.pending, pendingLog{leaf: leaf, done: done})
+	var full []pendingLog
+	if len(store.pending) >= store.options.BatchSize {
+		full = store.takePending()
+	} else if len(store.pending) == 1 {
+		store.timer = time.AfterFunc(store.options.BatchInterval, store.Flush)
+	}
+	store.mutex.Unlock()
+
+	if full != nil {
+		store.anchor(full)
+	}
+
+	err = <-done
+	if err != nil {
+		return fmt.Errorf("storage/BatchStore.Append - %w", err)
+	}
+
+	return nil
+}
+
+// Flush anchors the pending logs without waiting for the batch to be full.
+func (store *BatchStore) Flush() {
+	store.mutex.Lock()
+	batch := store.takePending()
+	store.mutex.Unlock()
+
+	if len(batch) > 0 {
+		store.anchor(batch)
+	}
+}
+
+// takePending must be called with the mutex locked.
+func (store *BatchStore) takePending() []pendingLog {
+	if store.timer != nil {
+		store.timer.Stop()
+		store.timer = nil
+	}
+
+	batch := store.pending
+	store.pending = nil
+
+	return batch
+}
+
+// anchor commits the root of the batch, writes the batch file and reports the result to every log of the batch.
+func (store *BatchStore) anchor(batch []pendingLog) {
+	err := store.anchorBatch(batch)
+	for _, pending := range batch {
+		pending.done <- err
+	}
+}
+
+func (store *BatchStore) anchorBatch(batch []pendingLog) error {
+	signer, err := store.gethStore.getSigner()
+	if err != nil {
+		return err
+	}
+
+	leaves := make([][]byte, len(batch))
+	for i, pending := range batch {
+		leaves[i] = pending.leaf
+	}
+
+	root, proofs := merkleTree(leaves)
+	anchorBytes, err := json.Marshal(batchAnchor{
+		Version:    constants.BatchAnchorVersion,
+		MerkleRoot: hex.EncodeToString(root),
+		Count:      len(batch),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal the anchor: %w", err)
+	}
+
+	recipient := common.HexToAddress(constants.UsageLogRecipient)
+	receipt, durations, err := signer.commit(&recipient, anchorBytes)
+	if err != nil {
+		return err
+	}
+
+	log.Info.Printf(""+
+		"Blockchain batch export duration: %v\n"+
+		"\tLogs: %d\n"+
+		"\tDuration of funding: %v\n"+
+		"\tDuration of transaction: %v\n"+
+		"\tDuration of confirmation: %v",
+		durations.TotalDuration, len(batch), durations.Funding, durations.Transaction, durations.Confirmation,
+	)
+
+	anchored := anchoredBatch{
+		MerkleRoot:      hex.EncodeToString(root),
+		TransactionHash: receipt.TransactionHash.Hex(),
+		Timestamp:       time.Now().Unix(),
+		Logs:            make([]batchEntry, len(batch)),
+	}
+	for i := range batch {
+		anchored.Logs[i] = batchEntry{Log: leaves[i], Proof: proofs[i]}
+	}
+
+	err = writeBatch(store.options.BatchDirectory, &anchored)
+	if err != nil {
+		return fmt.Errorf("the batch was anchored in %s, but %w", receipt.TransactionHash.Hex(), err)
+	}
+
+	return nil
+}
+
+// QueryByPseudonym returns the first log of the consumer or owner with the pseudonym.
+func (store *BatchStore) QueryByPseudonym(pseudonym string) (BlockchainPayload, error) {
+	var found BlockchainPayload
+
+	err := store.QueryAll(func(payload BlockchainPayload) error {
+		if payload.PseudonymOwner == pseudonym || payload.PseudonymConsumer == pseudonym {
+			found = payload
+			return errStopIteration
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errStopIteration) {
+		return found, nil
+	} else if err != nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/BatchStore.QueryByPseudonym - %w", err)
+	}
+
+	return BlockchainPayload{}, fmt.Errorf("storage/BatchStore.QueryByPseudonym - %w", ErrLogNotFound)
+}
+
+// QueryAll reads the batches in the order they were anchored. The anchor of every batch is checked on the blockchain
+// and the proof of every log against the anchored root.
+func (store *BatchStore) QueryAll(handle func(payload BlockchainPayload) error) error {
+	paths, err := findBatches(store.options.BatchDirectory)
+	if err != nil {
+		return fmt.Errorf("storage/BatchStore.QueryAll - %w", err)
+	}
+
+	for _, path := range paths {
+		batch, err := loadBatch(path)
+		if err != nil {
+			return fmt.Errorf("storage/BatchStore.QueryAll - %w", err)
+		}
+
+		err = VerifyBatchAnchor(batch.TransactionHash, batch.MerkleRoot)
+		if err != nil {
+			return fmt.Errorf("storage/BatchStore.QueryAll - %s: %w", path, err)
+		}
+
+		payloads, err := batch.verifiedLogs()
+		if err != nil {
+			return fmt.Errorf("storage/BatchStore.QueryAll - %s: %w", path, err)
+		}
+
+		for _, payload := range payloads {
+			err = handle(payload)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Update is not supported by the blockchain.
+func (store *BatchStore) Update(string, BlockchainPayload) error {
+	return fmt.Errorf("storage/BatchStore.Update - %w", ErrNotSupported)
+}
+
+// Delete is not supported by the blockchain.
+func (store *BatchStore) Delete(string) error {
+	return fmt.Errorf("storage/BatchStore.Delete - %w", ErrNotSupported)
+}
+
+// VerifyBatchAnchor checks that the transaction with the hash anchors the Merkle root (hex).
+func VerifyBatchAnchor(transactionHash string, merkleRoot string) error {
+	var transaction *struct {
+		Input hexutil.Bytes `json:"input"`
+	}
+
+	err := makeGethRequestInto("eth_getTransactionByHash", []string{common.HexToHash(transactionHash).Hex()}, &transaction)
+	if err != nil {
+		return fmt.Errorf("node/VerifyBatchAnchor - Could not get transaction %s: %w", transactionHash, err)
+	} else if transaction == nil {
+		return fmt.Errorf("node/VerifyBatchAnchor - Transaction %s does not exist", transactionHash)
+	}
+
+	var anchor batchAnchor
+	err = json.Unmarshal(transaction.Input, &anchor)
+	if err != nil {
+		return fmt.Errorf("node/VerifyBatchAnchor - Transaction %s is no batch anchor: %w", transactionHash, err)
+	}
+
+	if anchor.Version != constants.BatchAnchorVersion {
+		return fmt.Errorf("node/VerifyBatchAnchor - Transaction %s has unknown anchor version %d", transactionHash, anchor.Version)
+	} else if !strings.EqualFold(anchor.MerkleRoot, merkleRoot) {
+		return fmt.Errorf("node/VerifyBatchAnchor - Transaction %s anchors a different root", transactionHash)
+	}
+
+	return nil
+}
+
+// verifiedLogs returns the logs of the batch after checking their proofs against the root of the batch.
+func (batch *anchoredBatch) verifiedLogs() ([]BlockchainPayload, error) {
+	root, err := hex.DecodeString(batch.MerkleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode root: %w", err)
+	}
+
+	payloads := make([]BlockchainPayload, len(batch.Logs))
+	for i, entry := range batch.Logs {
+		err = VerifyMerkleProof(entry.Log, entry.Proof, root)
+		if err != nil {
+			return nil, fmt.Errorf("log %d: %w", i, err)
+		}
+
+		err = json.Unmarshal(entry.Log, &payloads[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not unmarshal log %d: %w", i, err)
+		}
+	}
+
+	return payloads, nil
+}
+
+// writeBatch stores the batch in the directory. The file name starts with the time, so sorting the names sorts the
+// batches by the time they were anchored.
+func writeBatch(directory string, batch *anchoredBatch) error {
+	out, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("could not marshal batch: %w", err)
+	}
+
+	err = os.MkdirAll(directory, constants.ProofDirectoryMode)
+	if err != nil {
+		return fmt.Errorf("could not create batch directory: %w", err)
+	}
+
+	name := time.Unix(batch.Timestamp, 0).Format(constants.ProofTimeFormat) + "_" + batch.MerkleRoot + ".json"
+	err = os.WriteFile(filepath.Join(directory, name), out, constants.ProofFileMode)
+	if err != nil {
+		return fmt.Errorf("could not write batch: %w", err)
+	}
+
+	return nil
+}
+
+func loadBatch(path string) (*anchoredBatch, error) {
+	readBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read batch: %w", err)
+	}
+
+	var batch anchoredBatch
+	decoder := json.NewDecoder(bytes.NewReader(readBytes))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&batch)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode batch %s: %w", path, err)
+	}
+
+	return &batch, nil
+}
+
+// findBatches returns the batch files in the directory sorted by name. A missing directory contains no batches.
+func findBatches(directory string) ([]string, error) {
+	files, err := ioutil.ReadDir(directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read batch directory: %w", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			paths = append(paths, filepath.Join(directory, file.Name()))
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchFiles(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "batches")
+
+	paths, err := findBatches(directory)
+	if err != nil || len(paths) != 0 {
+		t.Errorf("TestBatchFiles - A missing directory should contain no batches: %v, %v\n", paths, err)
+	}
+
+	payloads := []BlockchainPayload{
+		{PseudonymConsumer: "consumer1", PseudonymOwner: "owner1", EncryptedConsumer: UsageLogContent{Version: 2, Ciphertext: "c1"}},
+		{PseudonymConsumer: "consumer2", PseudonymOwner: "owner2", EncryptedOwner: UsageLogContent{Version: 2, Ciphertext: "o2"}},
+		{PseudonymConsumer: "consumer3", PseudonymOwner: "owner3"},
+	}
+
+	leaves := make([][]byte, len(payloads))
+	for i := range payloads {
+		leaves[i], _ = json.Marshal(payloads[i])
+	}
+
+	root, proofs := merkleTree(leaves)
+	batch := anchoredBatch{MerkleRoot: hex.EncodeToString(root), TransactionHash: "0x01", Timestamp: 1650000000}
+	for i := range leaves {
+		batch.Logs = append(batch.Logs, batchEntry{Log: leaves[i], Proof: proofs[i]})
+	}
+
+	err = writeBatch(directory, &batch)
+	if err != nil {
+		t.Fatalf("TestBatchFiles - Could not write batch: %v\n", err)
+	}
+
+	// The earlier batch is found first
+	earlier := anchoredBatch{MerkleRoot: hex.EncodeToString(merkleLeafHash(leaves[0])), Timestamp: 1640000000, Logs: []batchEntry{{Log: leaves[0]}}}
+	err = writeBatch(directory, &earlier)
+	if err != nil {
+		t.Fatalf("TestBatchFiles - Could not write batch: %v\n", err)
+	}
+
+	paths, err = findBatches(directory)
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("TestBatchFiles - Expected 2 batches: %v, %v\n", paths, err)
+	}
+
+	first, err := loadBatch(paths[0])
+	if err != nil || first.Timestamp != earlier.Timestamp {
+		t.Errorf("TestBatchFiles - The batches are not sorted by time: %v\n", err)
+	}
+
+	loaded, err := loadBatch(paths[1])
+	if err != nil {
+		t.Fatalf("TestBatchFiles - Could not load batch: %v\n", err)
+	}
+
+	verified, err := loaded.verifiedLogs()
+	if err != nil {
+		t.Fatalf("TestBatchFiles - Could not verify logs: %v\n", err)
+	}
+
+	for i := range payloads {
+		if verified[i] != payloads[i] {
+			t.Errorf("TestBatchFiles - Log %d changed: %v != %v\n", i, verified[i], payloads[i])
+		}
+	}
+
+	// A log that was changed after anchoring is rejected
+	loaded.Logs[1].Log = leaves[2]
+	_, err = loaded.verifiedLogs()
+	if err == nil {
+		t.Errorf("TestBatchFiles - A changed log was accepted\n")
+	}
+}
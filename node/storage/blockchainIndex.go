@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"node/constants"
+	"node/logging"
+)
+
+// blockchainIndexMigrations bring the database of a BlockchainIndex to the current schema, see sqliteMigrations.
+var blockchainIndexMigrations = []string{
+	// 1: Indexed blocks and the usage logs in them
+	`CREATE TABLE indexed_blocks (
+		number INTEGER PRIMARY KEY,
+		hash TEXT NOT NULL
+	);
+	CREATE TABLE indexed_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		block_number INTEGER NOT NULL,
+		tx_hash TEXT NOT NULL,
+		PseudonymConsumer TEXT NOT NULL,
+		PseudonymOwner TEXT NOT NULL,
+		EncryptedConsumer TEXT NOT NULL,
+		EncryptedOwner TEXT NOT NULL
+	);
+	CREATE INDEX indexed_logs_block_number ON indexed_logs (block_number);
+	CREATE INDEX indexed_logs_PseudonymConsumer ON indexed_logs (PseudonymConsumer);
+	CREATE INDEX indexed_logs_PseudonymOwner ON indexed_logs (PseudonymOwner);`,
+}
+
+// IndexedLog is a usage log together with the transaction it was found in.
+type IndexedLog struct {
+	Payload         BlockchainPayload
+	BlockNumber     uint64
+	TransactionHash string
+}
+
+// chainBlock is a block as returned by eth_getBlockByNumber with full transactions.
+type chainBlock struct {
+	Number       hexutil.Uint64     `json:"number"`
+	Hash         common.Hash        `json:"hash"`
+	ParentHash   common.Hash        `json:"parentHash"`
+	Transactions []chainTransaction `json:"transactions"`
+}
+
+type chainTransaction struct {
+	Hash  common.Hash   `json:"hash"`
+	Input hexutil.Bytes `json:"input"`
+}
+
+// blockSource returns the blocks of the chain. It is implemented by gethBlocks.
+type blockSource interface {
+	latestBlockNumber() (uint64, error)
+	// blockByNumber returns nil if the chain has no block with the number.
+	blockByNumber(number uint64) (*chainBlock, error)
+}
+
+// gethBlocks reads the blocks from the geth node at GethAddress.
+type gethBlocks struct{}
+
+func (gethBlocks) latestBlockNumber() (uint64, error) {
+	var number hexutil.Uint64
+	err := makeGethRequestInto("eth_blockNumber", []string{}, &number)
+
+	return uint64(number), err
+}
+
+func (gethBlocks) blockByNumber(number uint64) (*chainBlock, error) {
+	var block *chainBlock
+	err := makeGethRequestInto("eth_getBlockByNumber", []interface{}{hexutil.EncodeUint64(number), true}, &block)
+
+	return block, err
+}
+
+// BlockchainIndex keeps the usage logs on the blockchain in a SQLite database, so they can be looked up without reading
+// every block. Sync adds the blocks since the last indexed one and removes the blocks that are no longer part of the
+// chain.
+type BlockchainIndex struct {
+	db     *sql.DB
+	source blockSource
+
+	// syncMutex lets only one Sync change the index at a time
+	syncMutex sync.Mutex
+
+	head             *sql.Stmt
+	insertBlock      *sql.Stmt
+	insertLog        *sql.Stmt
+	removeBlocks     *sql.Stmt
+	removeLogs       *sql.Stmt
+	queryByPseudonym *sql.Stmt
+	queryAll         *sql.Stmt
+}
+
+// OpenBlockchainIndex opens the index at path and migrates it to the current schema. The index is not synced.
+func OpenBlockchainIndex(path string) (*BlockchainIndex, error) {
+	return openBlockchainIndex(path, gethBlocks{})
+}
+
+func openBlockchainIndex(path string, source blockSource) (*BlockchainIndex, error) {
+	db, err := openOrInitDB(path, blockchainIndexMigrations)
+	if err != nil {
+		return nil, fmt.Errorf("storage/OpenBlockchainIndex - %w", err)
+	}
+
+	index := &BlockchainIndex{db: db, source: source}
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&index.head, "SELECT number, hash FROM indexed_blocks ORDER BY number DESC LIMIT 1"},
+		{&index.insertBlock, "INSERT INTO indexed_blocks (number, hash) VALUES (?,?)"},
+		{&index.insertLog, "INSERT INTO indexed_logs (block_number, tx_hash, " + payloadColumns + ") VALUES (?,?,?,?,?,?)"},
+		{&index.removeBlocks, "DELETE FROM indexed_blocks WHERE number >= ?"},
+		{&index.removeLogs, "DELETE FROM indexed_logs WHERE block_number >= ?"},
+		{&index.queryByPseudonym, "SELECT block_number, tx_hash, " + payloadColumns + " FROM indexed_logs WHERE PseudonymConsumer = ? OR PseudonymOwner = ? ORDER BY block_number, id LIMIT 1"},
+		{&index.queryAll, "SELECT block_number, tx_hash, " + payloadColumns + " FROM indexed_logs ORDER BY block_number, id"},
+	}
+
+	for _, statement := range statements {
+		*statement.stmt, err = db.Prepare(statement.query)
+		if err != nil {
+			_ = index.Close()
+			return nil, fmt.Errorf("storage/OpenBlockchainIndex - Could not prepare statement: %w", err)
+		}
+	}
+
+	return index, nil
+}
+
+// Sync brings the index up to the latest block of the chain.
+func (index *BlockchainIndex) Sync() error {
+	index.syncMutex.Lock()
+	defer index.syncMutex.Unlock()
+
+	err := index.rewind()
+	if err != nil {
+		return fmt.Errorf("storage/BlockchainIndex.Sync - %w", err)
+	}
+
+	latest, err := index.source.latestBlockNumber()
+	if err != nil {
+		return fmt.Errorf("storage/BlockchainIndex.Sync - Could not get the latest block number: %w", err)
+	}
+
+	head, headHash, indexed, err := index.getHead()
+	if err != nil {
+		return fmt.Errorf("storage/BlockchainIndex.Sync - %w", err)
+	}
+
+	start := time.Now()
+	added := 0
+	next := uint64(0)
+	if indexed {
+		next = head + 1
+	}
+
+	for next <= latest {
+		block, err := index.source.blockByNumber(next)
+		if err != nil {
+			return fmt.Errorf("storage/BlockchainIndex.Sync - Could not get block %d: %w", next, err)
+		} else if block == nil {
+			// The chain became shorter since the latest block number was requested
+			break
+		}
+
+		if indexed && block.ParentHash.Hex() != headHash {
+			// The chain changed while the blocks were read
+			err = index.rewind()
+			if err == nil {
+				head, headHash, indexed, err = index.getHead()
+			}
+			if err != nil {
+				return fmt.Errorf("storage/BlockchainIndex.Sync - %w", err)
+			}
+
+			next = 0
+			if indexed {
+				next = head + 1
+			}
+			continue
+		}
+
+		err = index.addBlock(block)
+		if err != nil {
+			return fmt.Errorf("storage/BlockchainIndex.Sync - Block %d: %w", next, err)
+		}
+
+		head, headHash, indexed = next, block.Hash.Hex(), true
+		next++
+		added++
+	}
+
+	if added > 0 {
+		log.Info.Printf("Indexed %d blocks up to block %d in %v\n", added, head, time.Since(start))
+	}
+
+	return nil
+}
+
+// Follow syncs the index every interval until stop is closed. Failed syncs are logged and retried.
+func (index *BlockchainIndex) Follow(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := index.Sync()
+		if err != nil {
+			log.Error.Printf("%v\n", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueryByPseudonym returns the first indexed log of the consumer or owner with the pseudonym.
+func (index *BlockchainIndex) QueryByPseudonym(pseudonym string) (IndexedLog, error) {
+	indexedLog, err := scanIndexedLog(index.queryByPseudonym.QueryRow(pseudonym, pseudonym))
+	if errors.Is(err, sql.ErrNoRows) {
+		return IndexedLog{}, fmt.Errorf("storage/BlockchainIndex.QueryByPseudonym - %w", ErrLogNotFound)
+	} else if err != nil {
+		return IndexedLog{}, fmt.Errorf("storage/BlockchainIndex.QueryByPseudonym - %w", err)
+	}
+
+	return indexedLog, nil
+}
+
+// QueryAll calls handle for every indexed log in the order of the chain. It stops at the first error handle returns.
+func (index *BlockchainIndex) QueryAll(handle func(indexedLog IndexedLog) error) error {
+	rows, err := index.queryAll.Query()
+	if err != nil {
+		return fmt.Errorf("storage/BlockchainIndex.QueryAll - %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		indexedLog, err := scanIndexedLog(rows)
+		if err != nil {
+			return fmt.Errorf("storage/BlockchainIndex.QueryAll - %w", err)
+		}
+
+		err = handle(indexedLog)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Close closes the prepared statements and the database.
+func (index *BlockchainIndex) Close() error {
+	for _, stmt := range []*sql.Stmt{index.head, index.insertBlock, index.insertLog, index.removeBlocks, index.removeLogs, index.queryByPseudonym, index.queryAll} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+
+	return index.db.Close()
+}
+
+// rewind removes the indexed blocks that are no longer part of the chain. Only the last constants.IndexReorgDepth
+// blocks are compared with the chain; if none of them is part of it, the index is rebuilt.
+func (index *BlockchainIndex) rewind() error {
+	for checked := 0; ; checked++ {
+		head, headHash, indexed, err := index.getHead()
+		if err != nil || !indexed {
+			return err
+		}
+
+		if checked == constants.IndexReorgDepth {
+			log.Error.Printf("None of the last %d indexed blocks is part of the chain. Rebuilding the index\n", checked)
+			return index.removeFrom(0)
+		}
+
+		block, err := index.source.blockByNumber(head)
+		if err != nil {
+			return fmt.Errorf("could not get block %d: %w", head, err)
+		} else if block != nil && block.Hash.Hex() == headHash {
+			return nil
+		}
+
+		err = index.removeFrom(head)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// getHead returns the number and hash of the last indexed block. indexed is false if the index is empty.
+func (index *BlockchainIndex) getHead() (number uint64, hash string, indexed bool, err error) {
+	err = index.head.QueryRow().Scan(&number, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", false, nil
+	} else if err != nil {
+		return 0, "", false, fmt.Errorf("could not read the indexed head: %w", err)
+	}
+
+	return number, hash, true, nil
+}
+
+// addBlock stores the block and its usage logs in one transaction.
+func (index *BlockchainIndex) addBlock(block *chainBlock) error {
+	tx, err := index.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	_, err = tx.Stmt(index.insertBlock).Exec(uint64(block.Number), block.Hash.Hex())
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not insert block: %w", err)
+	}
+
+	insertLog := tx.Stmt(index.insertLog)
+	for _, transaction := range block.Transactions {
+		payload, ok := decodeUsageLog(transaction.Input)
+		if !ok {
+			continue
+		}
+
+		encryptedConsumer, encryptedOwner, err := marshalLogContents(&payload)
+		if err == nil {
+			_, err = insertLog.Exec(uint64(block.Number), transaction.Hash.Hex(), payload.PseudonymConsumer, payload.PseudonymOwner, encryptedConsumer, encryptedOwner)
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not insert the log of %s: %w", transaction.Hash.Hex(), err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit block: %w", err)
+	}
+
+	return nil
+}
+
+// removeFrom removes the block with the number and all following blocks together with their logs.
+func (index *BlockchainIndex) removeFrom(number uint64) error {
+	tx, err := index.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	_, err = tx.Stmt(index.removeLogs).Exec(number)
+	if err == nil {
+		_, err = tx.Stmt(index.removeBlocks).Exec(number)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not remove the blocks from %d: %w", number, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit the removal of the blocks from %d: %w", number, err)
+	}
+
+	return nil
+}
+
+func scanIndexedLog(row scanner) (IndexedLog, error) {
+	var indexedLog IndexedLog
+	var encryptedConsumerStr string
+	var encryptedOwnerStr string
+
+	err := row.Scan(&indexedLog.BlockNumber, &indexedLog.TransactionHash,
+		&indexedLog.Payload.PseudonymConsumer, &indexedLog.Payload.PseudonymOwner, &encryptedConsumerStr, &encryptedOwnerStr)
+	if err != nil {
+		return IndexedLog{}, err
+	}
+
+	indexedLog.Payload.EncryptedConsumer, indexedLog.Payload.EncryptedOwner, err = unmarshalLogContents(encryptedConsumerStr, encryptedOwnerStr)
+	if err != nil {
+		return IndexedLog{}, err
+	}
+
+	return indexedLog, nil
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fakeChain is a blockSource whose blocks can be replaced to simulate a reorganization.
+type fakeChain struct {
+	blocks []*chainBlock
+	// requested counts the calls of blockByNumber
+	requested int
+}
+
+func (chain *fakeChain) latestBlockNumber() (uint64, error) {
+	return uint64(len(chain.blocks) - 1), nil
+}
+
+func (chain *fakeChain) blockByNumber(number uint64) (*chainBlock, error) {
+	chain.requested++
+	if number >= uint64(len(chain.blocks)) {
+		return nil, nil
+	}
+
+	return chain.blocks[number], nil
+}
+
+// add appends a block with a transaction for every payload. fork changes the hash of the block.
+func (chain *fakeChain) add(t *testing.T, fork byte, payloads ...BlockchainPayload) {
+	number := uint64(len(chain.blocks))
+	block := &chainBlock{Number: hexutil.Uint64(number), Hash: common.BytesToHash([]byte{fork, byte(number)})}
+	if number > 0 {
+		block.ParentHash = chain.blocks[number-1].Hash
+	}
+
+	for i, payload := range payloads {
+		input, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("Could not marshal payload: %v\n", err)
+		}
+
+		block.Transactions = append(block.Transactions, chainTransaction{Hash: common.BytesToHash([]byte{fork, byte(number), byte(i)}), Input: input})
+	}
+
+	// Transactions without a usage log are skipped
+	block.Transactions = append(block.Transactions, chainTransaction{Hash: common.BytesToHash([]byte{fork, byte(number), 0xff}), Input: []byte{0x12, 0x34}})
+
+	chain.blocks = append(chain.blocks, block)
+}
+
+func _collectIndex(t *testing.T, index *BlockchainIndex) []IndexedLog {
+	logs := make([]IndexedLog, 0)
+	err := index.QueryAll(func(indexedLog IndexedLog) error {
+		logs = append(logs, indexedLog)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Could not query index: %v\n", err)
+	}
+
+	return logs
+}
+
+func TestBlockchainIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	first := BlockchainPayload{PseudonymConsumer: "consumer", PseudonymOwner: "owner1", EncryptedOwner: UsageLogContent{Version: 2, Ciphertext: "1"}}
+	second := BlockchainPayload{PseudonymConsumer: "consumer", PseudonymOwner: "owner2"}
+
+	chain := &fakeChain{}
+	chain.add(t, 0)
+	chain.add(t, 0, first)
+	chain.add(t, 0)
+
+	index, err := openBlockchainIndex(path, chain)
+	if err != nil {
+		t.Fatalf("TestBlockchainIndex - Could not open index: %v\n", err)
+	}
+	defer index.Close()
+
+	err = index.Sync()
+	if err != nil {
+		t.Fatalf("TestBlockchainIndex - Could not sync: %v\n", err)
+	}
+
+	found, err := index.QueryByPseudonym("owner1")
+	if err != nil || found.Payload != first || found.BlockNumber != 1 || found.TransactionHash != chain.blocks[1].Transactions[0].Hash.Hex() {
+		t.Errorf("TestBlockchainIndex - Could not find log: %v, %v\n", found, err)
+	}
+
+	// Only the head is checked and the new block is read
+	chain.add(t, 0, second)
+	chain.requested = 0
+	err = index.Sync()
+	if err != nil || chain.requested != 2 {
+		t.Errorf("TestBlockchainIndex - Expected 2 requested blocks, got %d: %v\n", chain.requested, err)
+	}
+
+	found, err = index.QueryByPseudonym("consumer")
+	if err != nil || found.Payload != first {
+		t.Errorf("TestBlockchainIndex - The first log of the consumer should be returned: %v, %v\n", found, err)
+	}
+
+	// Reorganization: blocks 2 and 3 are replaced, and the log of block 3 moves to block 4
+	chain.blocks = chain.blocks[:2]
+	chain.add(t, 1)
+	chain.add(t, 1)
+	chain.add(t, 1, second)
+
+	err = index.Sync()
+	if err != nil {
+		t.Fatalf("TestBlockchainIndex - Could not sync after reorganization: %v\n", err)
+	}
+
+	logs := _collectIndex(t, index)
+	if len(logs) != 2 || logs[0].Payload != first || logs[1].Payload != second || logs[1].BlockNumber != 4 {
+		t.Errorf("TestBlockchainIndex - Wrong logs after reorganization: %v\n", logs)
+	}
+
+	head, headHash, _, err := index.getHead()
+	if err != nil || head != 4 || headHash != chain.blocks[4].Hash.Hex() {
+		t.Errorf("TestBlockchainIndex - Wrong head after reorganization: %d, %s, %v\n", head, headHash, err)
+	}
+
+	_, err = index.QueryByPseudonym("unknown")
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestBlockchainIndex - Expected ErrLogNotFound: %v\n", err)
+	}
+
+	// The index is kept when it is opened again
+	_ = index.Close()
+	index, err = openBlockchainIndex(path, chain)
+	if err != nil {
+		t.Fatalf("TestBlockchainIndex - Could not reopen index: %v\n", err)
+	}
+
+	chain.requested = 0
+	err = index.Sync()
+	if err != nil || chain.requested != 1 || len(_collectIndex(t, index)) != 2 {
+		t.Errorf("TestBlockchainIndex - The reopened index was not kept: %d, %v\n", chain.requested, err)
+	}
+}
+
+func TestBlockchainIndexFollow(t *testing.T) {
+	chain := &fakeChain{}
+	chain.add(t, 0, BlockchainPayload{PseudonymConsumer: "consumer", PseudonymOwner: "owner"})
+
+	index, err := openBlockchainIndex(filepath.Join(t.TempDir(), "index.db"), chain)
+	if err != nil {
+		t.Fatalf("TestBlockchainIndexFollow - Could not open index: %v\n", err)
+	}
+	defer index.Close()
+
+	// The index is synced before Follow waits for the stop
+	stop := make(chan struct{})
+	close(stop)
+	index.Follow(time.Hour, stop)
+
+	_, err = index.QueryByPseudonym("owner")
+	if err != nil {
+		t.Errorf("TestBlockchainIndexFollow - The index was not synced: %v\n", err)
+	}
+}
+
+func TestBlockchainIndexDeepReorganization(t *testing.T) {
+	chain := &fakeChain{}
+	for i := 0; i < 70; i++ {
+		chain.add(t, 0, BlockchainPayload{PseudonymConsumer: "old", PseudonymOwner: "old"})
+	}
+
+	index, err := openBlockchainIndex(filepath.Join(t.TempDir(), "index.db"), chain)
+	if err != nil {
+		t.Fatalf("TestBlockchainIndexDeepReorganization - Could not open index: %v\n", err)
+	}
+	defer index.Close()
+
+	err = index.Sync()
+	if err != nil {
+		t.Fatalf("TestBlockchainIndexDeepReorganization - Could not sync: %v\n", err)
+	}
+
+	// None of the recent blocks is kept, so the index is rebuilt
+	chain.blocks = nil
+	for i := 0; i < 70; i++ {
+		chain.add(t, 1)
+	}
+	chain.add(t, 1, BlockchainPayload{PseudonymConsumer: "new", PseudonymOwner: "new"})
+
+	err = index.Sync()
+	if err != nil {
+		t.Fatalf("TestBlockchainIndexDeepReorganization - Could not sync after reorganization: %v\n", err)
+	}
+
+	logs := _collectIndex(t, index)
+	if len(logs) != 1 || logs[0].Payload.PseudonymOwner != "new" {
+		t.Errorf("TestBlockchainIndexDeepReorganization - The index was not rebuilt: %v\n", logs)
+	}
+}
@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"node/constants"
+)
+
+// BlockchainDurations are the durations of the steps of committing a usage log to the blockchain.
+type BlockchainDurations struct {
+	Funding       time.Duration
+	Transaction   time.Duration
+	Confirmation  time.Duration
+	TotalDuration time.Duration
+}
+
+// ethSigner signs transactions with a node-local key and submits them with eth_sendRawTransaction, so geth needs
+// neither accounts nor unlocking.
+type ethSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	chainID *big.Int
+
+	confirmations  uint64
+	mine           bool
+	receiptTimeout time.Duration
+
+	// nonceMutex orders the transactions of this node. nonce is the next nonce if nonceSynced is set.
+	nonceMutex  sync.Mutex
+	nonce       uint64
+	nonceSynced bool
+
+	// miningMutex protects miningUsers, the number of commits that currently need the miner.
+	miningMutex sync.Mutex
+	miningUsers int
+}
+
+type transactionReceipt struct {
+	TransactionHash common.Hash     `json:"transactionHash"`
+	BlockNumber     hexutil.Uint64  `json:"blockNumber"`
+	Status          hexutil.Uint64  `json:"status"`
+	ContractAddress *common.Address `json:"contractAddress"`
+}
+
+type callMessage struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Data  hexutil.Bytes   `json:"data"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// newEthSigner loads the key at keyPath, or creates it if it does not exist, and requests the chain ID from geth. If
+// mine is set, geth mines with the signer's address while the signer needs funds or confirmations.
+func newEthSigner(keyPath string, confirmations int, mine bool) (*ethSigner, error) {
+	if confirmations < 1 {
+		return nil, fmt.Errorf("node/newEthSigner - At least 1 confirmation is required, got %d", confirmations)
+	}
+
+	key, err := loadOrCreateEthereumKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("node/newEthSigner - %w", err)
+	}
+
+	var chainID hexutil.Big
+	err = makeGethRequestInto("eth_chainId", []string{}, &chainID)
+	if err != nil {
+		return nil, fmt.Errorf("node/newEthSigner - Could not get chain ID: %w", err)
+	}
+
+	return &ethSigner{
+		key:            key,
+		address:        crypto.PubkeyToAddress(key.PublicKey),
+		chainID:        chainID.ToInt(),
+		confirmations:  uint64(confirmations),
+		mine:           mine,
+		receiptTimeout: constants.ReceiptTimeout,
+	}, nil
+}
+
+// loadOrCreateEthereumKey returns the key at path. A new key is only created if the file does not exist.
+func loadOrCreateEthereumKey(path string) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.LoadECDSA(path)
+	if err == nil {
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not load Ethereum key: %w", err)
+	}
+
+	key, err = crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate Ethereum key: %w", err)
+	}
+
+	// SaveECDSA only allows the owner to read the key
+	err = crypto.SaveECDSA(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not save Ethereum key: %w", err)
+	}
+
+	return key, nil
+}
+
+// commit sends the input in a signed transaction to the recipient and waits until it has the configured number of
+// confirmations. Without a recipient, the transaction creates a contract with the input as code.
+func (signer *ethSigner) commit(recipient *common.Address, input []byte) (*transactionReceipt, *BlockchainDurations, error) {
+	start := time.Now()
+	message := callMessage{From: signer.address, To: recipient, Data: input, Value: (*hexutil.Big)(big.NewInt(0))}
+
+	var gas hexutil.Uint64
+	err := makeGethRequestInto("eth_estimateGas", []callMessage{message}, &gas)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node/ethSigner.commit - Could not estimate gas: %w", err)
+	}
+
+	var gasPrice hexutil.Big
+	err = makeGethRequestInto("eth_gasPrice", []string{}, &gasPrice)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node/ethSigner.commit - Could not get gas price: %w", err)
+	}
+
+	fundingStart := time.Now()
+	cost := new(big.Int).Mul(gasPrice.ToInt(), new(big.Int).SetUint64(uint64(gas)))
+	err = signer.awaitFunds(cost)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node/ethSigner.commit - %w", err)
+	}
+	fundingDuration := time.Since(fundingStart)
+
+	transactionStart := time.Now()
+	hash, err := signer.send(recipient, uint64(gas), gasPrice.ToInt(), input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node/ethSigner.commit - %w", err)
+	}
+	transactionDuration := time.Since(transactionStart)
+
+	confirmationStart := time.Now()
+	receipt, err := signer.awaitConfirmations(hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node/ethSigner.commit - Transaction %s: %w", hash.Hex(), err)
+	}
+
+	return receipt, &BlockchainDurations{
+		Funding:       fundingDuration,
+		Transaction:   transactionDuration,
+		Confirmation:  time.Since(confirmationStart),
+		TotalDuration: time.Since(start),
+	}, nil
+}
+
+// send signs the transaction with the next nonce and submits it. The nonce is requested from geth again after a
+// failed submission.
+func (signer *ethSigner) send(recipient *common.Address, gas uint64, gasPrice *big.Int, input []byte) (common.Hash, error) {
+	signer.nonceMutex.Lock()
+	defer signer.nonceMutex.Unlock()
+
+	if !signer.nonceSynced {
+		var nonce hexutil.Uint64
+		err := makeGethRequestInto("eth_getTransactionCount", []string{signer.address.Hex(), "pending"}, &nonce)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("could not get nonce: %w", err)
+		}
+
+		signer.nonce = uint64(nonce)
+		signer.nonceSynced = true
+	}
+
+	unsigned := types.NewContractCreation(signer.nonce, big.NewInt(0), gas, gasPrice, input)
+	if recipient != nil {
+		unsigned = types.NewTransaction(signer.nonce, *recipient, big.NewInt(0), gas, gasPrice, input)
+	}
+
+	transaction, err := types.SignTx(
+		unsigned,
+		types.NewEIP155Signer(signer.chainID),
+		signer.key,
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("could not sign transaction: %w", err)
+	}
+
+	raw, err := transaction.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("could not encode transaction: %w", err)
+	}
+
+	var hash common.Hash
+	err = makeGethRequestInto("eth_sendRawTransaction", []string{hexutil.Encode(raw)}, &hash)
+	if err != nil {
+		signer.nonceSynced = false
+		return common.Hash{}, fmt.Errorf("could not send transaction: %w", err)
+	}
+
+	signer.nonce++
+	return hash, nil
+}
+
+// awaitFunds returns once the balance of the signer covers cost.
+func (signer *ethSigner) awaitFunds(cost *big.Int) error {
+	deadline := time.Now().Add(signer.receiptTimeout)
+	mining := false
+	defer func() {
+		if mining {
+			signer.stopMining()
+		}
+	}()
+
+	for {
+		var balance hexutil.Big
+		err := makeGethRequestInto("eth_getBalance", []string{signer.address.Hex(), "pending"}, &balance)
+		if err != nil {
+			return fmt.Errorf("could not get balance: %w", err)
+		}
+
+		if balance.ToInt().Cmp(cost) >= 0 {
+			return nil
+		}
+
+		if !signer.mine {
+			return fmt.Errorf("the balance of %s does not cover the transaction costs of %s wei", signer.address.Hex(), cost)
+		} else if time.Now().After(deadline) {
+			return fmt.Errorf("timed out while mining funds for %s", signer.address.Hex())
+		}
+
+		if !mining {
+			err = signer.startMining()
+			if err != nil {
+				return err
+			}
+			mining = true
+		}
+
+		time.Sleep(constants.ReceiptPollInterval)
+	}
+}
+
+// awaitConfirmations returns the receipt of the transaction once it is part of a block that is followed by
+// confirmations-1 blocks.
+func (signer *ethSigner) awaitConfirmations(hash common.Hash) (*transactionReceipt, error) {
+	if signer.mine {
+		err := signer.startMining()
+		if err != nil {
+			return nil, err
+		}
+		defer signer.stopMining()
+	}
+
+	deadline := time.Now().Add(signer.receiptTimeout)
+	for ; time.Now().Before(deadline); time.Sleep(constants.ReceiptPollInterval) {
+		var receipt *transactionReceipt
+		err := makeGethRequestInto("eth_getTransactionReceipt", []string{hash.Hex()}, &receipt)
+		if err != nil {
+			return nil, fmt.Errorf("could not get receipt: %w", err)
+		} else if receipt == nil {
+			// Still pending
+			continue
+		}
+
+		if uint64(receipt.Status) != types.ReceiptStatusSuccessful {
+			return nil, errors.New("the transaction failed")
+		}
+
+		var blockNumber hexutil.Uint64
+		err = makeGethRequestInto("eth_blockNumber", []string{}, &blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("could not get block number: %w", err)
+		}
+
+		if uint64(blockNumber)+1 >= uint64(receipt.BlockNumber)+signer.confirmations {
+			return receipt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("not confirmed within %s", signer.receiptTimeout)
+}
+
+// startMining lets geth mine with the signer's address. The miner runs until the last user calls stopMining.
+func (signer *ethSigner) startMining() error {
+	signer.miningMutex.Lock()
+	defer signer.miningMutex.Unlock()
+
+	if signer.miningUsers == 0 {
+		_, err := makeGethRequestString("miner_setEtherbase", []string{signer.address.Hex()})
+		if err != nil {
+			return fmt.Errorf("could not update etherbase: %w", err)
+		}
+
+		_, err = makeGethRequestString("miner_start", []int{})
+		if err != nil {
+			return fmt.Errorf("could not start miner: %w", err)
+		}
+	}
+
+	signer.miningUsers++
+	return nil
+}
+
+func (signer *ethSigner) stopMining() {
+	signer.miningMutex.Lock()
+	defer signer.miningMutex.Unlock()
+
+	signer.miningUsers--
+	if signer.miningUsers == 0 {
+		_, _ = makeGethRequestString("miner_stop", []string{})
+	}
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLoadOrCreateEthereumKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ethereumKey")
+
+	key, err := loadOrCreateEthereumKey(path)
+	if err != nil {
+		t.Fatalf("TestLoadOrCreateEthereumKey - Could not create key: %s\n", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("TestLoadOrCreateEthereumKey - The key should only be readable by the owner: %v, %v\n", info, err)
+	}
+
+	// An existing key is reused, so the address stays the same
+	loaded, err := loadOrCreateEthereumKey(path)
+	if err != nil || crypto.PubkeyToAddress(loaded.PublicKey) != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("TestLoadOrCreateEthereumKey - Could not reload key: %v\n", err)
+	}
+
+	_ = os.WriteFile(path, []byte("not a key"), 0o600)
+	_, err = loadOrCreateEthereumKey(path)
+	if err == nil {
+		t.Errorf("TestLoadOrCreateEthereumKey - An invalid key was replaced instead of failing\n")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -15,13 +16,59 @@ import (
 	"node/p2p"
 )
 
+// ProofMetadata describes a stored exchange. Role and PeerSSOID are empty for exchanges stored before
+// constants.ProofVersion.
+type ProofMetadata struct {
+	// Role is the party that stored the exchange, constants.ProofRoleListener or constants.ProofRoleRequester.
+	// Transcripts of exchanges that stopped early have constants.ProofRoleTranscript or constants.ProofRoleResolved,
+	// the listener's record of them constants.ProofRoleIncomplete
+	Role string `json:"role"`
+	// Pseudonym is the pseudonym of the conversation key of the party that stored the exchange
+	Pseudonym  string                `json:"pseudonym"`
+	PeerSSOID  string                `json:"peer_ssoid"`
+	Timestamp  int64                 `json:"timestamp"`
+	Parameters p2p.SessionParameters `json:"parameters"`
+}
+
+// proofEnvelope is the format of exchanges stored since constants.ProofVersion.
+type proofEnvelope struct {
+	Version             int                  `json:"version"`
+	Metadata            ProofMetadata        `json:"metadata"`
+	PublicKey           rsa.PublicKey        `json:"public_key"`
+	EncryptedPrivateKey *encryptedPrivateKey `json:"encrypted_private_key"`
+	PublicIdentityKey   rsa.PublicKey        `json:"public_identity_key"`
+	Messages            []p2p.SignedMessage  `json:"messages"`
+}
+
+// storedExchange is the format of exchanges stored before constants.ProofVersion.
 type storedExchange struct {
-	PrivateKey        rsa.PrivateKey      `json:"private_key"`
-	PublicIdentityKey rsa.PublicKey       `json:"public_identity_key"`
-	Messages          []p2p.SignedMessage `json:"messages"`
+	// PrivateKey is only set in exchanges that were stored before the private key was encrypted
+	PrivateKey          *rsa.PrivateKey      `json:"private_key,omitempty"`
+	EncryptedPrivateKey *encryptedPrivateKey `json:"encrypted_private_key,omitempty"`
+	PublicKey           *rsa.PublicKey       `json:"public_key,omitempty"`
+	PublicIdentityKey   rsa.PublicKey        `json:"public_identity_key"`
+	Messages            []p2p.SignedMessage  `json:"messages"`
+	// Parameters is missing in proofs of the legacy protocol
+	Parameters *p2p.SessionParameters `json:"parameters,omitempty"`
+}
+
+// proof is a loaded exchange of any version.
+type proof struct {
+	version  int
+	envelope proofEnvelope
+	// privateKey is only set in legacy exchanges that contain the private key in plaintext
+	privateKey *rsa.PrivateKey
+}
+
+// StoreExchange writes the proof of an exchange to constants.StorageOutputPath. The conversation private key is
+// encrypted with the passphrase, the rest of the proof can be verified without it. The pseudonym and timestamp of the
+// metadata are set by StoreExchange.
+func StoreExchange(messages []p2p.SignedMessage, privateKey *rsa.PrivateKey, publicIdentityKey *rsa.PublicKey, metadata ProofMetadata, passphrase []byte) error {
+	return StoreExchangeIn(constants.StorageOutputPath, messages, privateKey, publicIdentityKey, metadata, passphrase)
 }
 
-func StoreExchange(messages []p2p.SignedMessage, privateKey *rsa.PrivateKey, publicIdentityKey *rsa.PublicKey) error {
+// StoreExchangeIn is StoreExchange with another output directory.
+func StoreExchangeIn(directory string, messages []p2p.SignedMessage, privateKey *rsa.PrivateKey, publicIdentityKey *rsa.PublicKey, metadata ProofMetadata, passphrase []byte) error {
 	if len(messages) == 0 {
 		return errors.New("node.Store - Message is either null or empty")
 	} else if privateKey.Equal(rsa.PrivateKey{}) {
@@ -29,85 +76,331 @@ func StoreExchange(messages []p2p.SignedMessage, privateKey *rsa.PrivateKey, pub
 	}
 
 	// Check if output directory exists and create it if necessary
-	err := createOutputDirectory(constants.StorageOutputPath)
+	err := createOutputDirectory(directory)
 	if err != nil {
 		return fmt.Errorf("node.Store - Could not create output direcory: %w", err)
 	}
 
-	// Generate unique file name
 	pseudonym, err := GeneratePseudonym(&privateKey.PublicKey)
 	if err != nil {
 		return fmt.Errorf("node.Store - Could not generate pseudonym: %w", err)
 	}
-	// fileName length is 84+5 characters
-	fileName := fmt.Sprintf("%s-%s.json", time.Now().Format("2006-01-02T15-04-05"), pseudonym)
 
-	// Marshal data into JSON
-	toWrite := storedExchange{
-		Messages:          messages,
-		PrivateKey:        *privateKey,
-		PublicIdentityKey: *publicIdentityKey,
+	now := time.Now()
+	metadata.Pseudonym = pseudonym
+	metadata.Timestamp = now.Unix()
+
+	encryptedKey, err := encryptPrivateKey(privateKey, passphrase)
+	if err != nil {
+		return fmt.Errorf("node.Store - Could not encrypt private key: %w", err)
+	}
+
+	toWrite := proofEnvelope{
+		Version:             constants.ProofVersion,
+		Metadata:            metadata,
+		PublicKey:           privateKey.PublicKey,
+		EncryptedPrivateKey: encryptedKey,
+		PublicIdentityKey:   *publicIdentityKey,
+		Messages:            messages,
+	}
+
+	// The file name is only meant for humans, the proof is identified by its metadata
+	fileName := fmt.Sprintf("%s-%s.json", now.Format(constants.ProofTimeFormat), pseudonym)
+	err = writeProof(filepath.Join(directory, fileName), &toWrite)
+	if err != nil {
+		return fmt.Errorf("node.Store - %w", err)
+	}
+
+	return nil
+}
+
+// LoadExchange loads a stored exchange and decrypts its private key with the passphrase. Proofs of the legacy protocol
+// are returned with p2p.LegacyParameters.
+func LoadExchange(path string, passphrase []byte) ([]p2p.SignedMessage, rsa.PrivateKey, rsa.PublicKey, p2p.SessionParameters, error) {
+	loaded, err := loadProof(path)
+	if err != nil {
+		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, p2p.SessionParameters{}, err
+	}
+
+	exchange := loaded.envelope
+
+	// Exchanges stored before the private key was encrypted
+	if loaded.privateKey != nil {
+		return exchange.Messages, *loaded.privateKey, exchange.PublicIdentityKey, exchange.Metadata.Parameters, nil
+	}
+
+	privateKey, err := exchange.EncryptedPrivateKey.decrypt(&exchange.PublicKey, passphrase)
+	if err != nil {
+		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, p2p.SessionParameters{}, fmt.Errorf("node.Load - Could not decrypt private key: %w", err)
+	}
+
+	return exchange.Messages, privateKey, exchange.PublicIdentityKey, exchange.Metadata.Parameters, nil
+}
+
+// LoadExchangeProof loads a stored exchange without its private key. This is enough to verify the exchange.
+func LoadExchangeProof(path string) ([]p2p.SignedMessage, rsa.PublicKey, rsa.PublicKey, p2p.SessionParameters, error) {
+	loaded, err := loadProof(path)
+	if err != nil {
+		return nil, rsa.PublicKey{}, rsa.PublicKey{}, p2p.SessionParameters{}, err
+	}
+
+	exchange := loaded.envelope
+	return exchange.Messages, exchange.PublicKey, exchange.PublicIdentityKey, exchange.Metadata.Parameters, nil
+}
+
+// LoadProofMetadata returns the metadata and version of a stored exchange. The metadata of exchanges stored before
+// constants.ProofVersion is derived from the file.
+func LoadProofMetadata(path string) (ProofMetadata, int, error) {
+	loaded, err := loadProof(path)
+	if err != nil {
+		return ProofMetadata{}, 0, err
+	}
+
+	return loaded.envelope.Metadata, loaded.version, nil
+}
+
+// FindExchanges returns the paths of the exchanges in the directory whose pseudonym matches. Files that are not
+// stored exchanges are ignored.
+func FindExchanges(directory string, pseudonym string) ([]string, error) {
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("node.FindExchanges - Could not read directory: %w", err)
+	}
+
+	var matches []string
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(directory, file.Name())
+		metadata, _, err := LoadProofMetadata(path)
+		if err == nil && metadata.Pseudonym == pseudonym {
+			matches = append(matches, path)
+		}
+	}
+
+	return matches, nil
+}
+
+// MigrateExchange rewrites an exchange stored before constants.ProofVersion to outputPath in the current format. Role
+// and peer SSOID are not part of old exchanges and have to be determined by verifying them. The passphrase is only
+// requested if the old exchange contains the private key in plaintext; an encrypted private key is kept as it is.
+func MigrateExchange(path string, outputPath string, role string, peerSSOID string, passphrase func() ([]byte, error)) error {
+	loaded, err := loadProof(path)
+	if err != nil {
+		return err
+	}
+
+	if loaded.version != 0 {
+		return fmt.Errorf("node.Migrate - The exchange already has version %d", loaded.version)
+	} else if role != constants.ProofRoleListener && role != constants.ProofRoleRequester {
+		return fmt.Errorf("node.Migrate - Unknown role '%s'", role)
+	}
+
+	migrated := loaded.envelope
+	migrated.Version = constants.ProofVersion
+	migrated.Metadata.Role = role
+	migrated.Metadata.PeerSSOID = peerSSOID
+
+	if loaded.privateKey != nil {
+		key, err := passphrase()
+		if err != nil {
+			return fmt.Errorf("node.Migrate - Could not get the passphrase: %w", err)
+		}
+
+		migrated.EncryptedPrivateKey, err = encryptPrivateKey(loaded.privateKey, key)
+		if err != nil {
+			return fmt.Errorf("node.Migrate - Could not encrypt private key: %w", err)
+		}
 	}
 
-	out, err := json.Marshal(toWrite)
+	err = writeProof(outputPath, &migrated)
+	if err != nil {
+		return fmt.Errorf("node.Migrate - %w", err)
+	}
+
+	return nil
+}
+
+// ResolveTranscript writes the transcript at path with the arbiter's signed resolution appended to outputPath. The
+// encrypted private key is kept as it is, so the passphrase is not needed.
+func ResolveTranscript(path string, outputPath string, resolution p2p.SignedMessage) error {
+	loaded, err := loadProof(path)
 	if err != nil {
-		return fmt.Errorf("node.Store - Could not marshal json: %w", err)
+		return err
+	}
+
+	if loaded.envelope.Metadata.Role != constants.ProofRoleTranscript {
+		return fmt.Errorf("node.ResolveTranscript - The exchange is not a transcript but has role '%s'", loaded.envelope.Metadata.Role)
 	}
 
-	err = os.WriteFile(constants.StorageOutputPath+fileName, out, 0o644) //nolint: gosec
+	resolved := loaded.envelope
+	resolved.Metadata.Role = constants.ProofRoleResolved
+	resolved.Messages = append(resolved.Messages, resolution)
+
+	err = writeProof(outputPath, &resolved)
 	if err != nil {
-		return fmt.Errorf("node.Store - Could not write to file: %w", err)
+		return fmt.Errorf("node.ResolveTranscript - %w", err)
 	}
 
 	return nil
 }
 
-func LoadExchange(path string) ([]p2p.SignedMessage, rsa.PrivateKey, rsa.PublicKey, error) {
+func writeProof(path string, envelope *proofEnvelope) error {
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("could not marshal json: %w", err)
+	}
+
+	err = os.WriteFile(path, out, constants.ProofFileMode)
+	if err != nil {
+		return fmt.Errorf("could not write to file: %w", err)
+	}
+
+	return nil
+}
+
+func loadProof(path string) (proof, error) {
 	readBytes, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, fmt.Errorf("node.Load - Could not load file: %w", err)
+		return proof{}, fmt.Errorf("node.Load - Could not load file: %w", err)
 	}
 
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	err = json.Unmarshal(readBytes, &header)
+	if err != nil {
+		return proof{}, fmt.Errorf("node.Load - Could not marshal file: %w", err)
+	}
+
+	var loaded proof
+	switch header.Version {
+	case 0:
+		loaded, err = loadLegacyProof(path, readBytes)
+	case constants.ProofVersion:
+		loaded, err = loadVersionedProof(readBytes)
+	default:
+		return proof{}, fmt.Errorf("node.Load - Unsupported proof version %d", header.Version)
+	}
+
+	if err != nil {
+		return proof{}, err
+	}
+
+	err = loaded.envelope.Metadata.Parameters.CheckSupported()
+	if err != nil {
+		return proof{}, fmt.Errorf("node.Load - The exchange cannot be verified: %w", err)
+	}
+
+	if len(loaded.envelope.Messages) < 3 {
+		return proof{}, fmt.Errorf("node.Load - Too little messages were found")
+	}
+
+	return loaded, nil
+}
+
+func loadVersionedProof(readBytes []byte) (proof, error) {
+	var envelope proofEnvelope
+	err := json.Unmarshal(readBytes, &envelope)
+	if err != nil {
+		return proof{}, fmt.Errorf("node.Load - Could not marshal file: %w", err)
+	}
+
+	if envelope.PublicKey.N == nil || envelope.EncryptedPrivateKey == nil {
+		return proof{}, fmt.Errorf("node.Load - The file contains no private key")
+	}
+
+	pseudonym, err := GeneratePseudonym(&envelope.PublicKey)
+	if err != nil || pseudonym != envelope.Metadata.Pseudonym {
+		return proof{}, fmt.Errorf("node.Load - Pseudonym of metadata and key do not match")
+	}
+
+	return proof{version: envelope.Version, envelope: envelope}, nil
+}
+
+func loadLegacyProof(path string, readBytes []byte) (proof, error) {
 	var exchange storedExchange
 	dec := json.NewDecoder(bytes.NewReader(readBytes))
 	dec.DisallowUnknownFields()
 
-	err = dec.Decode(&exchange)
+	err := dec.Decode(&exchange)
 	if err != nil {
-		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, fmt.Errorf("node.Load - Could not marshal file: %w", err)
+		return proof{}, fmt.Errorf("node.Load - Could not marshal file: %w", err)
 	}
 
-	if !filenameMatchesPseudonym(path, &exchange.PrivateKey.PublicKey) {
-		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, fmt.Errorf("node.Load - Pseudonym of file and key do not match")
+	var publicKey rsa.PublicKey
+	switch {
+	case exchange.PrivateKey != nil:
+		publicKey = exchange.PrivateKey.PublicKey
+	case exchange.PublicKey != nil && exchange.EncryptedPrivateKey != nil:
+		publicKey = *exchange.PublicKey
+	default:
+		return proof{}, fmt.Errorf("node.Load - The file contains no private key")
 	}
 
-	if len(exchange.Messages) < 3 {
-		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, fmt.Errorf("node.Load - Too little messages were found")
+	// Old exchanges are identified by their file name
+	pseudonym, err := GeneratePseudonym(&publicKey)
+	if err != nil || !filenameMatchesPseudonym(path, pseudonym) {
+		return proof{}, fmt.Errorf("node.Load - Pseudonym of file and key do not match")
 	}
 
-	return exchange.Messages, exchange.PrivateKey, exchange.PublicIdentityKey, nil
+	parameters := p2p.LegacyParameters()
+	if exchange.Parameters != nil {
+		parameters = *exchange.Parameters
+	}
+
+	return proof{
+		envelope: proofEnvelope{
+			Metadata: ProofMetadata{
+				Pseudonym:  pseudonym,
+				Timestamp:  legacyTimestamp(path),
+				Parameters: parameters,
+			},
+			PublicKey:           publicKey,
+			EncryptedPrivateKey: exchange.EncryptedPrivateKey,
+			PublicIdentityKey:   exchange.PublicIdentityKey,
+			Messages:            exchange.Messages,
+		},
+		privateKey: exchange.PrivateKey,
+	}, nil
 }
 
-func filenameMatchesPseudonym(fileName string, publicKey *rsa.PublicKey) bool {
+func filenameMatchesPseudonym(fileName string, pseudonym string) bool {
 	pseudonymFile := fileName[strings.LastIndex(fileName, "-")+1:]
 	pseudonymFile = strings.TrimSuffix(pseudonymFile, ".json")
 
-	pseudonymKey, err := GeneratePseudonym(publicKey)
+	return pseudonymFile == pseudonym
+}
+
+// legacyTimestamp reads the time from the file name of an old exchange and falls back to its modification time.
+func legacyTimestamp(path string) int64 {
+	name := filepath.Base(path)
+	if len(name) >= len(constants.ProofTimeFormat) {
+		storedAt, err := time.ParseInLocation(constants.ProofTimeFormat, name[:len(constants.ProofTimeFormat)], time.Local)
+		if err == nil {
+			return storedAt.Unix()
+		}
+	}
+
+	info, err := os.Stat(path)
 	if err != nil {
-		return false
+		return 0
 	}
 
-	return pseudonymFile == pseudonymKey
+	return info.ModTime().Unix()
 }
 
 func createOutputDirectory(path string) error {
 	_, err := os.Stat(path)
 	if err == nil {
-		// Directory exists
-		return nil
+		// Directory exists => Make sure that only the owner can access it
+		return os.Chmod(path, constants.ProofDirectoryMode)
 	} else if os.IsNotExist(err) {
 		// Directory does not exist => Create it
-		return os.Mkdir(path, 0o777)
+		return os.Mkdir(path, constants.ProofDirectoryMode)
 	}
 
 	return err
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"node/constants"
+	"node/p2p"
+)
+
+func TestStoreAndLoadExchange(t *testing.T) {
+	workingDirectory, _ := os.Getwd()
+	defer os.Chdir(workingDirectory) //nolint: errcheck
+
+	err := os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatalf("TestStoreAndLoadExchange - Could not change directory: %s\n", err)
+	}
+
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	identityKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	messages := []p2p.SignedMessage{{Content: []byte("1")}, {Content: []byte("2")}, {Content: []byte("3")}}
+	passphrase := []byte("correct horse battery staple")
+
+	metadata := ProofMetadata{Role: constants.ProofRoleRequester, PeerSSOID: "listener@example.com", Parameters: p2p.LegacyParameters()}
+	err = StoreExchange(messages, privateKey, &identityKey.PublicKey, metadata, passphrase)
+	if err != nil {
+		t.Fatalf("TestStoreAndLoadExchange - Could not store exchange: %s\n", err)
+	}
+
+	files, _ := filepath.Glob(constants.StorageOutputPath + "*.json")
+	if len(files) != 1 {
+		t.Fatalf("TestStoreAndLoadExchange - Expected one file, found %d\n", len(files))
+	}
+
+	for path, mode := range map[string]os.FileMode{files[0]: constants.ProofFileMode, constants.StorageOutputPath: constants.ProofDirectoryMode} {
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != mode {
+			t.Errorf("TestStoreAndLoadExchange - '%s' should have mode %o: %v, %v\n", path, mode, info, err)
+		}
+	}
+
+	// The private key must not be stored in plaintext
+	content, _ := os.ReadFile(files[0])
+	var stored map[string]json.RawMessage
+	_ = json.Unmarshal(content, &stored)
+	if _, ok := stored["private_key"]; ok {
+		t.Errorf("TestStoreAndLoadExchange - The private key was stored in plaintext\n")
+	}
+
+	_, loadedKey, _, _, err := LoadExchange(files[0], passphrase)
+	if err != nil || !loadedKey.Equal(privateKey) {
+		t.Errorf("TestStoreAndLoadExchange - Could not load the private key: %v\n", err)
+	}
+
+	_, _, _, _, err = LoadExchange(files[0], []byte("wrong"))
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("TestStoreAndLoadExchange - Expected ErrWrongPassphrase, got: %v\n", err)
+	}
+
+	// Verifying does not need the passphrase
+	loadedMessages, publicKey, loadedIdentityKey, _, err := LoadExchangeProof(files[0])
+	if err != nil || len(loadedMessages) != 3 || !publicKey.Equal(&privateKey.PublicKey) || !loadedIdentityKey.Equal(&identityKey.PublicKey) {
+		t.Errorf("TestStoreAndLoadExchange - Could not load the proof: %v\n", err)
+	}
+
+	// The file name does not matter anymore
+	renamed := filepath.Join(constants.StorageOutputPath, "renamed.json")
+	_ = os.Rename(files[0], renamed)
+
+	pseudonym, _ := GeneratePseudonym(&privateKey.PublicKey)
+	loadedMetadata, version, err := LoadProofMetadata(renamed)
+	if err != nil || version != constants.ProofVersion || loadedMetadata.Pseudonym != pseudonym || loadedMetadata.Role != metadata.Role ||
+		loadedMetadata.PeerSSOID != metadata.PeerSSOID || loadedMetadata.Timestamp == 0 {
+		t.Errorf("TestStoreAndLoadExchange - Unexpected metadata: %v, %d, %v\n", loadedMetadata, version, err)
+	}
+
+	matches, err := FindExchanges(constants.StorageOutputPath, pseudonym)
+	if err != nil || len(matches) != 1 || matches[0] != renamed {
+		t.Errorf("TestStoreAndLoadExchange - Could not find the renamed proof: %v, %v\n", matches, err)
+	}
+}
+
+func TestLoadPlaintextExchange(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pseudonym, _ := GeneratePseudonym(&privateKey.PublicKey)
+
+	// Exchanges stored by older versions contain the private key in plaintext
+	content, _ := json.Marshal(map[string]interface{}{
+		"private_key":         privateKey,
+		"public_identity_key": privateKey.PublicKey,
+		"messages":            []p2p.SignedMessage{{}, {}, {}},
+	})
+
+	path := filepath.Join(t.TempDir(), "2022-01-01T00-00-00-"+pseudonym+".json")
+	_ = os.WriteFile(path, content, 0o600)
+
+	_, loadedKey, _, parameters, err := LoadExchange(path, nil)
+	if err != nil || !loadedKey.Equal(privateKey) || parameters != p2p.LegacyParameters() {
+		t.Errorf("TestLoadPlaintextExchange - Could not load exchange: %v\n", err)
+	}
+}
+
+func TestMigrateExchange(t *testing.T) {
+	directory := t.TempDir()
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pseudonym, _ := GeneratePseudonym(&privateKey.PublicKey)
+
+	content, _ := json.Marshal(map[string]interface{}{
+		"private_key":         privateKey,
+		"public_identity_key": privateKey.PublicKey,
+		"messages":            []p2p.SignedMessage{{}, {}, {}},
+	})
+
+	path := filepath.Join(directory, "2022-01-01T00-00-00-"+pseudonym+".json")
+	_ = os.WriteFile(path, content, 0o600)
+
+	passphrase := func() ([]byte, error) { return []byte("migrate"), nil }
+	migrated := filepath.Join(directory, "migrated.json")
+
+	err := MigrateExchange(path, migrated, "owner", "listener@example.com", passphrase)
+	if err == nil {
+		t.Errorf("TestMigrateExchange - Accepted an unknown role\n")
+	}
+
+	err = MigrateExchange(path, migrated, constants.ProofRoleRequester, "listener@example.com", passphrase)
+	if err != nil {
+		t.Fatalf("TestMigrateExchange - Could not migrate exchange: %s\n", err)
+	}
+
+	metadata, version, err := LoadProofMetadata(migrated)
+	expectedTime, _ := time.ParseInLocation(constants.ProofTimeFormat, "2022-01-01T00-00-00", time.Local)
+	if err != nil || version != constants.ProofVersion || metadata.Pseudonym != pseudonym || metadata.PeerSSOID != "listener@example.com" ||
+		metadata.Timestamp != expectedTime.Unix() || metadata.Parameters != p2p.LegacyParameters() {
+		t.Errorf("TestMigrateExchange - Unexpected metadata: %v, %d, %v\n", metadata, version, err)
+	}
+
+	// The private key is encrypted during the migration
+	_, loadedKey, _, _, err := LoadExchange(migrated, []byte("migrate"))
+	if err != nil || !loadedKey.Equal(privateKey) {
+		t.Errorf("TestMigrateExchange - Could not load the private key: %v\n", err)
+	}
+
+	err = MigrateExchange(migrated, filepath.Join(directory, "twice.json"), constants.ProofRoleRequester, "", passphrase)
+	if err == nil {
+		t.Errorf("TestMigrateExchange - Migrated an exchange twice\n")
+	}
+}
+
+func TestResolveTranscript(t *testing.T) {
+	directory := t.TempDir()
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	messages := []p2p.SignedMessage{{Content: []byte("1")}, {Content: []byte("2")}, {Content: []byte("3")}, {Content: []byte("4")}}
+	passphrase := []byte("resolve")
+
+	metadata := ProofMetadata{Role: constants.ProofRoleTranscript, PeerSSOID: "listener@example.com", Parameters: p2p.LegacyParameters()}
+	err := StoreExchangeIn(directory, messages, privateKey, &privateKey.PublicKey, metadata, passphrase)
+	if err != nil {
+		t.Fatalf("TestResolveTranscript - Could not store transcript: %s\n", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(directory, "*.json"))
+	resolved := filepath.Join(t.TempDir(), "resolved.json")
+	err = ResolveTranscript(files[0], resolved, p2p.SignedMessage{Content: []byte("resolution")})
+	if err != nil {
+		t.Fatalf("TestResolveTranscript - Could not resolve transcript: %s\n", err)
+	}
+
+	resolvedMetadata, _, err := LoadProofMetadata(resolved)
+	if err != nil || resolvedMetadata.Role != constants.ProofRoleResolved || resolvedMetadata.Pseudonym == "" {
+		t.Errorf("TestResolveTranscript - Unexpected metadata: %v, %v\n", resolvedMetadata, err)
+	}
+
+	// The private key can still be decrypted with the passphrase
+	loadedMessages, loadedKey, _, _, err := LoadExchange(resolved, passphrase)
+	if err != nil || !loadedKey.Equal(privateKey) || len(loadedMessages) != len(messages)+1 || string(loadedMessages[len(messages)].Content) != "resolution" {
+		t.Errorf("TestResolveTranscript - Unexpected resolved transcript: %d messages, %v\n", len(loadedMessages), err)
+	}
+
+	err = ResolveTranscript(resolved, filepath.Join(directory, "twice.json"), p2p.SignedMessage{})
+	if err == nil {
+		t.Errorf("TestResolveTranscript - Resolved a transcript twice\n")
+	}
+}
@@ -7,11 +7,37 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"sync"
 
 	"node/constants"
 	"node/random"
 )
 
+var (
+	// gethAddressMutex protects gethAddress, the JSON-RPC endpoint of all requests to geth.
+	gethAddressMutex sync.RWMutex
+	gethAddress      = constants.GethAddress
+
+	gethClient = &http.Client{Timeout: constants.GethRequestTimeout}
+)
+
+// SetGethAddress changes the JSON-RPC endpoint of geth, which is constants.GethAddress by default. The binaries set it
+// from their -gethAddress flag before they open a store.
+func SetGethAddress(address string) {
+	gethAddressMutex.Lock()
+	defer gethAddressMutex.Unlock()
+
+	gethAddress = address
+}
+
+// GethAddress returns the JSON-RPC endpoint of geth.
+func GethAddress() string {
+	gethAddressMutex.RLock()
+	defer gethAddressMutex.RUnlock()
+
+	return gethAddress
+}
+
 type gethRequest struct {
 	Params  interface{} `json:"params"`
 	Jsonrpc string      `json:"jsonrpc"`
@@ -59,6 +85,26 @@ func makeGethRequestString(method string, params interface{}) (string, error) {
 	}
 }
 
+// makeGethRequestInto decodes the result of the request into result. A null result leaves result unchanged.
+func makeGethRequestInto(method string, params interface{}, result interface{}) error {
+	response, err := makeGethRequestInterface(method, params)
+	if err != nil {
+		return err
+	}
+
+	resultBytes, err := json.Marshal(response.Result)
+	if err != nil {
+		return fmt.Errorf("could not marshal result of method '%s': %w", method, err)
+	}
+
+	err = json.Unmarshal(resultBytes, result)
+	if err != nil {
+		return fmt.Errorf("geth returned an unexpected result for method '%s': %w", method, err)
+	}
+
+	return nil
+}
+
 func makeGethRequestInterface(method string, params interface{}) (gethResponse, error) {
 	requestID := random.PositiveIntFromRange(0, 2048)
 	requestData := gethRequest{
@@ -74,15 +120,14 @@ func makeGethRequestInterface(method string, params interface{}) (gethResponse,
 	}
 
 	// Build request
-	request, err := http.NewRequest(http.MethodPost, constants.GethAddress, bytes.NewReader(requestBytes))
+	request, err := http.NewRequest(http.MethodPost, GethAddress(), bytes.NewReader(requestBytes))
 	if err != nil {
 		return gethResponse{}, fmt.Errorf("could not create request: %w", err)
 	}
 	request.Header.Add("Content-Type", "application/json")
 
 	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(request)
+	resp, err := gethClient.Do(request)
 	if err != nil {
 		return gethResponse{}, fmt.Errorf("could not make request: %w", err)
 	}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"node/constants"
+	"node/logging"
+)
+
+// errStopIteration ends QueryAll once the requested log was found.
+var errStopIteration = errors.New("stop iteration")
+
+// GethStore stores usage logs as transactions on the geth node at GethAddress. Since the blockchain is
+// append-only, logs cannot be updated or deleted. Logs are read from the index at options.IndexPath, which is synced
+// before every query.
+type GethStore struct {
+	options StoreOptions
+
+	// The signer is only created by the first Append, so reading does not need a key
+	signerMutex sync.Mutex
+	signer      *ethSigner
+
+	// The index is only opened by the first query, so writing does not create it
+	indexMutex sync.Mutex
+	index      *BlockchainIndex
+}
+
+// NewGethStore returns a store for the blockchain. The transactions are signed with the key at
+// options.EthereumKeyPath.
+func NewGethStore(options StoreOptions) *GethStore {
+	return &GethStore{options: options}
+}
+
+// Name returns StoreBlockchain.
+func (store *GethStore) Name() string {
+	return StoreBlockchain
+}
+
+// Append commits the log in a signed transaction and waits for its confirmations.
+func (store *GethStore) Append(payload BlockchainPayload) error {
+	signer, err := store.getSigner()
+	if err != nil {
+		return fmt.Errorf("storage/GethStore.Append - %w", err)
+	}
+
+	blockBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("storage/GethStore.Append - Could not marshal the block: %w", err)
+	}
+
+	recipient := common.HexToAddress(constants.UsageLogRecipient)
+	_, durations, err := signer.commit(&recipient, blockBytes)
+	if err != nil {
+		return fmt.Errorf("storage/GethStore.Append - %w", err)
+	}
+
+	log.Info.Printf(""+
+		"Blockchain export duration: %v\n"+
+		"\tDuration of funding: %v\n"+
+		"\tDuration of transaction: %v\n"+
+		"\tDuration of confirmation: %v",
+		durations.TotalDuration, durations.Funding, durations.Transaction, durations.Confirmation,
+	)
+
+	return nil
+}
+
+func (store *GethStore) getSigner() (*ethSigner, error) {
+	store.signerMutex.Lock()
+	defer store.signerMutex.Unlock()
+
+	if store.signer == nil {
+		signer, err := newEthSigner(store.options.EthereumKeyPath, store.options.Confirmations, store.options.Mine)
+		if err != nil {
+			return nil, err
+		}
+
+		store.signer = signer
+	}
+
+	return store.signer, nil
+}
+
+// QueryByPseudonym returns the first log of the consumer or owner with the pseudonym.
+func (store *GethStore) QueryByPseudonym(pseudonym string) (BlockchainPayload, error) {
+	index, err := store.getSyncedIndex()
+	if err != nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/GethStore.QueryByPseudonym - %w", err)
+	}
+
+	indexedLog, err := index.QueryByPseudonym(pseudonym)
+	if err != nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/GethStore.QueryByPseudonym - %w", err)
+	}
+
+	return indexedLog.Payload, nil
+}
+
+// QueryAll reads the logs in the order of the chain.
+func (store *GethStore) QueryAll(handle func(payload BlockchainPayload) error) error {
+	index, err := store.getSyncedIndex()
+	if err != nil {
+		return fmt.Errorf("storage/GethStore.QueryAll - %w", err)
+	}
+
+	// Errors of handle are returned as they are
+	return index.QueryAll(func(indexedLog IndexedLog) error {
+		return handle(indexedLog.Payload)
+	})
+}
+
+// Follow syncs the index in the background every interval until stop is closed, so queries only have to read the
+// newest blocks.
+func (store *GethStore) Follow(interval time.Duration, stop <-chan struct{}) error {
+	index, err := store.getIndex()
+	if err != nil {
+		return fmt.Errorf("storage/GethStore.Follow - %w", err)
+	}
+
+	go index.Follow(interval, stop)
+
+	return nil
+}
+
+// getSyncedIndex opens the index if it is not open yet and syncs it with the chain.
+func (store *GethStore) getSyncedIndex() (*BlockchainIndex, error) {
+	index, err := store.getIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	err = index.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// getIndex opens the index if it is not open yet.
+func (store *GethStore) getIndex() (*BlockchainIndex, error) {
+	store.indexMutex.Lock()
+	defer store.indexMutex.Unlock()
+
+	if store.index == nil {
+		index, err := OpenBlockchainIndex(store.options.IndexPath)
+		if err != nil {
+			return nil, err
+		}
+
+		store.index = index
+	}
+
+	return store.index, nil
+}
+
+// Update is not supported by the blockchain.
+func (store *GethStore) Update(string, BlockchainPayload) error {
+	return fmt.Errorf("storage/GethStore.Update - %w", ErrNotSupported)
+}
+
+// Delete is not supported by the blockchain.
+func (store *GethStore) Delete(string) error {
+	return fmt.Errorf("storage/GethStore.Delete - %w", ErrNotSupported)
+}
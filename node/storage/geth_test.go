@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"node/gethtest"
+)
+
+// _useFakeGeth points all requests to geth at a new gethtest.Server until the test ends.
+func _useFakeGeth(t *testing.T) *gethtest.Server {
+	server := gethtest.NewServer()
+	previous := GethAddress()
+	SetGethAddress(server.URL)
+
+	t.Cleanup(func() {
+		SetGethAddress(previous)
+		server.Close()
+	})
+
+	return server
+}
+
+func TestGethStoreWithFakeGeth(t *testing.T) {
+	server := _useFakeGeth(t)
+	directory := t.TempDir()
+
+	store := NewGethStore(StoreOptions{
+		EthereumKeyPath: filepath.Join(directory, "ethereumKey"),
+		Confirmations:   2,
+		Mine:            true,
+		IndexPath:       filepath.Join(directory, "index.db"),
+	})
+
+	ownerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	consumerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	usageLog, err := NewUsageLog("justification", "datum", &ownerKey.PublicKey, &consumerKey.PublicKey)
+	if err != nil {
+		t.Fatalf("TestGethStoreWithFakeGeth - Could not create usage log: %s\n", err)
+	}
+
+	// The signer starts without funds, so they have to be mined first
+	err = store.Append(usageLog)
+	if err != nil {
+		t.Fatalf("TestGethStoreWithFakeGeth - Could not append log: %s\n", err)
+	}
+
+	if server.Mining() {
+		t.Errorf("TestGethStoreWithFakeGeth - The miner was not stopped after the log was confirmed\n")
+	}
+
+	found, err := store.QueryByPseudonym(usageLog.PseudonymConsumer)
+	if err != nil || found != usageLog {
+		t.Errorf("TestGethStoreWithFakeGeth - Unexpected log: %v, %v\n", found, err)
+	}
+
+	// A failed submission is reported
+	server.InjectFault("eth_sendRawTransaction", gethtest.Fault{Error: &gethtest.RPCError{Code: -32000, Message: "txpool is full"}, Times: 1})
+	err = store.Append(usageLog)
+	if err == nil {
+		t.Errorf("TestGethStoreWithFakeGeth - The injected error was ignored\n")
+	}
+}
+
+func TestMakeGethRequestFaults(t *testing.T) {
+	server := _useFakeGeth(t)
+
+	previousTimeout := gethClient.Timeout
+	gethClient.Timeout = 100 * time.Millisecond
+	defer func() {
+		gethClient.Timeout = previousTimeout
+	}()
+
+	tests := []struct {
+		name  string
+		fault gethtest.Fault
+	}{
+		{"error", gethtest.Fault{Error: &gethtest.RPCError{Code: -32000, Message: "injected"}}},
+		{"wrong ID", gethtest.Fault{WrongID: true}},
+		{"status code", gethtest.Fault{StatusCode: 503}},
+		{"slow response", gethtest.Fault{Delay: 500 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		server.InjectFault("eth_blockNumber", test.fault)
+
+		var number hexutil.Uint64
+		err := makeGethRequestInto("eth_blockNumber", []string{}, &number)
+		if err == nil {
+			t.Errorf("TestMakeGethRequestFaults - The fault '%s' was not detected\n", test.name)
+		}
+	}
+
+	server.ClearFaults()
+	server.Mine()
+
+	var number hexutil.Uint64
+	err := makeGethRequestInto("eth_blockNumber", []string{}, &number)
+	if err != nil || number != 1 {
+		t.Errorf("TestMakeGethRequestFaults - Unexpected block number after the faults were cleared: %d, %v\n", number, err)
+	}
+
+	// Methods the server does not implement are reported like geth does
+	_, err = makeGethRequestString("eth_syncing", []string{})
+	if err == nil {
+		t.Errorf("TestMakeGethRequestFaults - Unknown method was accepted\n")
+	}
+}
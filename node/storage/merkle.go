@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Prefixes of the hashed data, so a leaf can never be passed off as an inner node.
+const (
+	merkleLeafPrefix  = 0x00
+	merkleInnerPrefix = 0x01
+)
+
+// MerkleProofStep is a sibling on the path from a leaf to the root.
+type MerkleProofStep struct {
+	Hash string `json:"hash"`
+	// Left is set if the sibling is the left child
+	Left bool `json:"left"`
+}
+
+func merkleLeafHash(data []byte) []byte {
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, data...))
+	return hash[:]
+}
+
+func merkleInnerHash(left []byte, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, merkleInnerPrefix)
+	data = append(data, left...)
+	data = append(data, right...)
+
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// merkleTree returns the root of the leaves and the proof of every leaf. A node without a sibling is moved up a
+// level unchanged.
+func merkleTree(leaves [][]byte) ([]byte, [][]MerkleProofStep) {
+	if len(leaves) == 0 {
+		return nil, nil
+	}
+
+	level := make([][]byte, len(leaves))
+	// positions[i] is the index of the node in the current level that leaf i is part of
+	positions := make([]int, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = merkleLeafHash(leaf)
+		positions[i] = i
+	}
+
+	proofs := make([][]MerkleProofStep, len(leaves))
+	for len(level) > 1 {
+		for i, position := range positions {
+			sibling := position ^ 1
+			if sibling < len(level) {
+				proofs[i] = append(proofs[i], MerkleProofStep{Hash: hex.EncodeToString(level[sibling]), Left: sibling < position})
+			}
+			positions[i] = position / 2
+		}
+
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, merkleInnerHash(level[i], level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+		level = next
+	}
+
+	return level[0], proofs
+}
+
+// VerifyMerkleProof checks that the leaf is part of the tree with the root.
+func VerifyMerkleProof(leaf []byte, proof []MerkleProofStep, root []byte) error {
+	hash := merkleLeafHash(leaf)
+	for i, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return fmt.Errorf("node/VerifyMerkleProof - Could not decode step %d: %w", i, err)
+		}
+
+		if step.Left {
+			hash = merkleInnerHash(sibling, hash)
+		} else {
+			hash = merkleInnerHash(hash, sibling)
+		}
+	}
+
+	if !bytes.Equal(hash, root) {
+		return fmt.Errorf("node/VerifyMerkleProof - The proof does not lead to the root")
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMerkleTree(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		leaves := make([][]byte, count)
+		for i := range leaves {
+			leaves[i] = []byte(fmt.Sprintf("log %d", i))
+		}
+
+		root, proofs := merkleTree(leaves)
+		for i, leaf := range leaves {
+			err := VerifyMerkleProof(leaf, proofs[i], root)
+			if err != nil {
+				t.Errorf("TestMerkleTree - Proof of leaf %d of %d is invalid: %v\n", i, count, err)
+			}
+
+			err = VerifyMerkleProof([]byte("other log"), proofs[i], root)
+			if err == nil {
+				t.Errorf("TestMerkleTree - Proof of leaf %d of %d accepted another leaf\n", i, count)
+			}
+		}
+
+		// A single leaf is its own root, but still hashed as a leaf
+		if count == 1 && (len(proofs[0]) != 0 || string(root) != string(merkleLeafHash(leaves[0]))) {
+			t.Errorf("TestMerkleTree - A single leaf should have an empty proof\n")
+		}
+	}
+
+	// An inner node cannot be passed off as a leaf
+	leaves := [][]byte{[]byte("a"), []byte("b")}
+	root, _ := merkleTree(leaves)
+	inner := append(merkleLeafHash(leaves[0]), merkleLeafHash(leaves[1])...)
+	err := VerifyMerkleProof(inner, nil, root)
+	if err == nil {
+		t.Errorf("TestMerkleTree - The children of the root were accepted as a leaf\n")
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/term"
+	"node/constants"
+)
+
+// ErrWrongPassphrase is returned if the private key of a stored exchange cannot be decrypted.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted private key")
+
+// encryptedPrivateKey is the conversation private key encrypted with AES-GCM under a key derived from a passphrase.
+type encryptedPrivateKey struct {
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// ProofPassphrase returns the passphrase of stored exchanges from constants.ProofPassphraseEnv or, if it is not set,
+// from the terminal.
+func ProofPassphrase() ([]byte, error) {
+	passphrase, ok := os.LookupEnv(constants.ProofPassphraseEnv)
+	if ok && len(passphrase) > 0 {
+		return []byte(passphrase), nil
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		return nil, fmt.Errorf("node/ProofPassphrase - %s is not set and stdin is not a terminal", constants.ProofPassphraseEnv)
+	}
+
+	fmt.Print("Please enter the passphrase of the stored exchanges: ")
+	read, err := term.ReadPassword(stdin)
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("node/ProofPassphrase - Could not read the passphrase: %w", err)
+	}
+
+	read = []byte(strings.TrimSpace(string(read)))
+	if len(read) == 0 {
+		return nil, errors.New("node/ProofPassphrase - Empty passphrase passed")
+	}
+
+	return read, nil
+}
+
+func encryptPrivateKey(privateKey *rsa.PrivateKey, passphrase []byte) (*encryptedPrivateKey, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+
+	salt := make([]byte, 16)
+	nonce := make([]byte, 12)
+	for _, buffer := range [][]byte{salt, nonce} {
+		if _, err := io.ReadFull(rand.Reader, buffer); err != nil {
+			return nil, fmt.Errorf("could not generate randomness: %w", err)
+		}
+	}
+
+	encrypted := &encryptedPrivateKey{
+		KDF:     constants.ProofKDFArgon2id,
+		Salt:    hex.EncodeToString(salt),
+		Time:    constants.Argon2Time,
+		Memory:  constants.Argon2Memory,
+		Threads: constants.Argon2Threads,
+		Nonce:   hex.EncodeToString(nonce),
+	}
+
+	aesGCM, err := encrypted.cipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	additionalData, err := GeneratePseudonym(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := x509.MarshalPKCS1PrivateKey(privateKey)
+	encrypted.Ciphertext = hex.EncodeToString(aesGCM.Seal(nil, nonce, plaintext, []byte(additionalData)))
+
+	return encrypted, nil
+}
+
+// decrypt returns the private key if it belongs to the public key.
+func (encrypted *encryptedPrivateKey) decrypt(publicKey *rsa.PublicKey, passphrase []byte) (rsa.PrivateKey, error) {
+	salt, err := hex.DecodeString(encrypted.Salt)
+	if err != nil {
+		return rsa.PrivateKey{}, fmt.Errorf("could not decode salt: %w", err)
+	}
+
+	nonce, err := hex.DecodeString(encrypted.Nonce)
+	if err != nil {
+		return rsa.PrivateKey{}, fmt.Errorf("could not decode nonce: %w", err)
+	}
+
+	ciphertext, err := hex.DecodeString(encrypted.Ciphertext)
+	if err != nil {
+		return rsa.PrivateKey{}, fmt.Errorf("could not decode ciphertext: %w", err)
+	}
+
+	aesGCM, err := encrypted.cipher(passphrase, salt)
+	if err != nil {
+		return rsa.PrivateKey{}, err
+	}
+
+	if len(nonce) != aesGCM.NonceSize() {
+		return rsa.PrivateKey{}, fmt.Errorf("invalid nonce size %d", len(nonce))
+	}
+
+	additionalData, err := GeneratePseudonym(publicKey)
+	if err != nil {
+		return rsa.PrivateKey{}, err
+	}
+
+	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, []byte(additionalData))
+	if err != nil {
+		return rsa.PrivateKey{}, ErrWrongPassphrase
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(plaintext)
+	if err != nil {
+		return rsa.PrivateKey{}, fmt.Errorf("could not parse private key: %w", err)
+	}
+
+	if !privateKey.PublicKey.Equal(publicKey) {
+		return rsa.PrivateKey{}, errors.New("the private key does not belong to the stored public key")
+	}
+
+	return *privateKey, nil
+}
+
+func (encrypted *encryptedPrivateKey) cipher(passphrase []byte, salt []byte) (cipher.AEAD, error) {
+	if encrypted.KDF != constants.ProofKDFArgon2id {
+		return nil, fmt.Errorf("unknown KDF '%s'", encrypted.KDF)
+	}
+
+	if encrypted.Time == 0 || encrypted.Memory == 0 || encrypted.Threads == 0 {
+		return nil, errors.New("invalid KDF parameters")
+	}
+
+	if encrypted.Time > constants.Argon2MaxTime || encrypted.Memory > constants.Argon2MaxMemory || encrypted.Threads > constants.Argon2MaxThreads {
+		return nil, fmt.Errorf("KDF parameters exceed the limits: time %d, memory %d KiB, threads %d", encrypted.Time, encrypted.Memory, encrypted.Threads)
+	}
+
+	key := argon2.IDKey(passphrase, salt, encrypted.Time, encrypted.Memory, encrypted.Threads, 32)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
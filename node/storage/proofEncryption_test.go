@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"node/constants"
+)
+
+func TestPrivateKeyEncryptionLimits(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, constants.RSAKeySize)
+	if err != nil {
+		t.Fatalf("TestPrivateKeyEncryptionLimits - Could not generate key: %s\n", err)
+	}
+
+	passphrase := []byte("passphrase")
+	encrypted, err := encryptPrivateKey(privateKey, passphrase)
+	if err != nil {
+		t.Fatalf("TestPrivateKeyEncryptionLimits - Could not encrypt: %s\n", err)
+	}
+
+	_, err = encrypted.decrypt(&privateKey.PublicKey, passphrase)
+	if err != nil {
+		t.Fatalf("TestPrivateKeyEncryptionLimits - Could not decrypt: %s\n", err)
+	}
+
+	// Parameters of a crafted file must be rejected before the key is derived
+	tests := []func(*encryptedPrivateKey){
+		func(e *encryptedPrivateKey) { e.Time = constants.Argon2MaxTime + 1 },
+		func(e *encryptedPrivateKey) { e.Memory = constants.Argon2MaxMemory + 1 },
+		func(e *encryptedPrivateKey) { e.Threads = constants.Argon2MaxThreads + 1 },
+		func(e *encryptedPrivateKey) { e.Time = ^uint32(0); e.Memory = ^uint32(0) },
+		func(e *encryptedPrivateKey) { e.Memory = 0 },
+	}
+
+	for i, tamper := range tests {
+		tampered := *encrypted
+		tamper(&tampered)
+
+		_, err = tampered.decrypt(&privateKey.PublicKey, passphrase)
+		if err == nil {
+			t.Errorf("TestPrivateKeyEncryptionLimits - Case %d: decrypted with parameters %+v\n", i, tampered)
+		}
+	}
+}
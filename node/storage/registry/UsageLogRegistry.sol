@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @title Registry of the usage logs of P3
+/// @notice Every usage log is emitted as an event, so it can be found by the pseudonym of its consumer or owner with
+/// eth_getLogs. The contract keeps no state.
+contract UsageLogRegistry {
+    /// @param pseudonymConsumer Pseudonym of the data consumer
+    /// @param pseudonymOwner Pseudonym of the data owner
+    /// @param payload JSON of the usage log, see storage.BlockchainPayload
+    event UsageLogged(bytes32 indexed pseudonymConsumer, bytes32 indexed pseudonymOwner, bytes payload);
+
+    function logUsage(bytes32 pseudonymConsumer, bytes32 pseudonymOwner, bytes calldata payload) external {
+        emit UsageLogged(pseudonymConsumer, pseudonymOwner, payload);
+    }
+}
@@ -0,0 +1,10 @@
+// Package registry contains the UsageLogRegistry contract, which emits every usage log as an event, and its Go
+// bindings. UsageLogRegistry.sol is the source. The bytecode and ABI are compiled by solc and the bindings are
+// generated by abigen:
+//
+//	solc --overwrite --bin --abi -o . UsageLogRegistry.sol
+//	abigen --abi UsageLogRegistry.abi --bin UsageLogRegistry.bin --pkg registry --type UsageLogRegistry --out registry.go
+package registry
+
+//go:generate solc --overwrite --bin --abi -o . UsageLogRegistry.sol
+//go:generate abigen --abi UsageLogRegistry.abi --bin UsageLogRegistry.bin --pkg registry --type UsageLogRegistry --out registry.go
@@ -0,0 +1,377 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package registry
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// UsageLogRegistryMetaData contains all meta data concerning the UsageLogRegistry contract.
+var UsageLogRegistryMetaData = &bind.MetaData{
+	ABI: "[{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"pseudonymConsumer\",\"type\":\"bytes32\"},{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"pseudonymOwner\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"bytes\",\"name\":\"payload\",\"type\":\"bytes\"}],\"name\":\"UsageLogged\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"pseudonymConsumer\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"pseudonymOwner\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"payload\",\"type\":\"bytes\"}],\"name\":\"logUsage\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x6100a180600c6000396000f334630000009c5760643610630000009c5760003560e01c639293bdf01415630000009c5760443560040180358063ffffffff10630000009c5780602001826020376020600052601f017fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe01660400190506024356004357f97926ddbf69cf5d00204cd037d4f1f8f6624b94b2bfc894edf51a12844299af3836000a3005b600080fd",
+}
+
+// UsageLogRegistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use UsageLogRegistryMetaData.ABI instead.
+var UsageLogRegistryABI = UsageLogRegistryMetaData.ABI
+
+// UsageLogRegistryBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use UsageLogRegistryMetaData.Bin instead.
+var UsageLogRegistryBin = UsageLogRegistryMetaData.Bin
+
+// DeployUsageLogRegistry deploys a new Ethereum contract, binding an instance of UsageLogRegistry to it.
+func DeployUsageLogRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *UsageLogRegistry, error) {
+	parsed, err := UsageLogRegistryMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(UsageLogRegistryBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &UsageLogRegistry{UsageLogRegistryCaller: UsageLogRegistryCaller{contract: contract}, UsageLogRegistryTransactor: UsageLogRegistryTransactor{contract: contract}, UsageLogRegistryFilterer: UsageLogRegistryFilterer{contract: contract}}, nil
+}
+
+// UsageLogRegistry is an auto generated Go binding around an Ethereum contract.
+type UsageLogRegistry struct {
+	UsageLogRegistryCaller     // Read-only binding to the contract
+	UsageLogRegistryTransactor // Write-only binding to the contract
+	UsageLogRegistryFilterer   // Log filterer for contract events
+}
+
+// UsageLogRegistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type UsageLogRegistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UsageLogRegistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type UsageLogRegistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UsageLogRegistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type UsageLogRegistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UsageLogRegistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type UsageLogRegistrySession struct {
+	Contract     *UsageLogRegistry // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// UsageLogRegistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type UsageLogRegistryCallerSession struct {
+	Contract *UsageLogRegistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts           // Call options to use throughout this session
+}
+
+// UsageLogRegistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type UsageLogRegistryTransactorSession struct {
+	Contract     *UsageLogRegistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts           // Transaction auth options to use throughout this session
+}
+
+// UsageLogRegistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type UsageLogRegistryRaw struct {
+	Contract *UsageLogRegistry // Generic contract binding to access the raw methods on
+}
+
+// UsageLogRegistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type UsageLogRegistryCallerRaw struct {
+	Contract *UsageLogRegistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// UsageLogRegistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type UsageLogRegistryTransactorRaw struct {
+	Contract *UsageLogRegistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewUsageLogRegistry creates a new instance of UsageLogRegistry, bound to a specific deployed contract.
+func NewUsageLogRegistry(address common.Address, backend bind.ContractBackend) (*UsageLogRegistry, error) {
+	contract, err := bindUsageLogRegistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLogRegistry{UsageLogRegistryCaller: UsageLogRegistryCaller{contract: contract}, UsageLogRegistryTransactor: UsageLogRegistryTransactor{contract: contract}, UsageLogRegistryFilterer: UsageLogRegistryFilterer{contract: contract}}, nil
+}
+
+// NewUsageLogRegistryCaller creates a new read-only instance of UsageLogRegistry, bound to a specific deployed contract.
+func NewUsageLogRegistryCaller(address common.Address, caller bind.ContractCaller) (*UsageLogRegistryCaller, error) {
+	contract, err := bindUsageLogRegistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLogRegistryCaller{contract: contract}, nil
+}
+
+// NewUsageLogRegistryTransactor creates a new write-only instance of UsageLogRegistry, bound to a specific deployed contract.
+func NewUsageLogRegistryTransactor(address common.Address, transactor bind.ContractTransactor) (*UsageLogRegistryTransactor, error) {
+	contract, err := bindUsageLogRegistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLogRegistryTransactor{contract: contract}, nil
+}
+
+// NewUsageLogRegistryFilterer creates a new log filterer instance of UsageLogRegistry, bound to a specific deployed contract.
+func NewUsageLogRegistryFilterer(address common.Address, filterer bind.ContractFilterer) (*UsageLogRegistryFilterer, error) {
+	contract, err := bindUsageLogRegistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLogRegistryFilterer{contract: contract}, nil
+}
+
+// bindUsageLogRegistry binds a generic wrapper to an already deployed contract.
+func bindUsageLogRegistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(UsageLogRegistryABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_UsageLogRegistry *UsageLogRegistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _UsageLogRegistry.Contract.UsageLogRegistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_UsageLogRegistry *UsageLogRegistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.UsageLogRegistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_UsageLogRegistry *UsageLogRegistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.UsageLogRegistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_UsageLogRegistry *UsageLogRegistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _UsageLogRegistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_UsageLogRegistry *UsageLogRegistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_UsageLogRegistry *UsageLogRegistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// LogUsage is a paid mutator transaction binding the contract method 0x9293bdf0.
+//
+// Solidity: function logUsage(bytes32 pseudonymConsumer, bytes32 pseudonymOwner, bytes payload) returns()
+func (_UsageLogRegistry *UsageLogRegistryTransactor) LogUsage(opts *bind.TransactOpts, pseudonymConsumer [32]byte, pseudonymOwner [32]byte, payload []byte) (*types.Transaction, error) {
+	return _UsageLogRegistry.contract.Transact(opts, "logUsage", pseudonymConsumer, pseudonymOwner, payload)
+}
+
+// LogUsage is a paid mutator transaction binding the contract method 0x9293bdf0.
+//
+// Solidity: function logUsage(bytes32 pseudonymConsumer, bytes32 pseudonymOwner, bytes payload) returns()
+func (_UsageLogRegistry *UsageLogRegistrySession) LogUsage(pseudonymConsumer [32]byte, pseudonymOwner [32]byte, payload []byte) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.LogUsage(&_UsageLogRegistry.TransactOpts, pseudonymConsumer, pseudonymOwner, payload)
+}
+
+// LogUsage is a paid mutator transaction binding the contract method 0x9293bdf0.
+//
+// Solidity: function logUsage(bytes32 pseudonymConsumer, bytes32 pseudonymOwner, bytes payload) returns()
+func (_UsageLogRegistry *UsageLogRegistryTransactorSession) LogUsage(pseudonymConsumer [32]byte, pseudonymOwner [32]byte, payload []byte) (*types.Transaction, error) {
+	return _UsageLogRegistry.Contract.LogUsage(&_UsageLogRegistry.TransactOpts, pseudonymConsumer, pseudonymOwner, payload)
+}
+
+// UsageLogRegistryUsageLoggedIterator is returned from FilterUsageLogged and is used to iterate over the raw logs and unpacked data for UsageLogged events raised by the UsageLogRegistry contract.
+type UsageLogRegistryUsageLoggedIterator struct {
+	Event *UsageLogRegistryUsageLogged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *UsageLogRegistryUsageLoggedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(UsageLogRegistryUsageLogged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(UsageLogRegistryUsageLogged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *UsageLogRegistryUsageLoggedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *UsageLogRegistryUsageLoggedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// UsageLogRegistryUsageLogged represents a UsageLogged event raised by the UsageLogRegistry contract.
+type UsageLogRegistryUsageLogged struct {
+	PseudonymConsumer [32]byte
+	PseudonymOwner    [32]byte
+	Payload           []byte
+	Raw               types.Log // Blockchain specific contextual infos
+}
+
+// FilterUsageLogged is a free log retrieval operation binding the contract event 0x97926ddbf69cf5d00204cd037d4f1f8f6624b94b2bfc894edf51a12844299af3.
+//
+// Solidity: event UsageLogged(bytes32 indexed pseudonymConsumer, bytes32 indexed pseudonymOwner, bytes payload)
+func (_UsageLogRegistry *UsageLogRegistryFilterer) FilterUsageLogged(opts *bind.FilterOpts, pseudonymConsumer [][32]byte, pseudonymOwner [][32]byte) (*UsageLogRegistryUsageLoggedIterator, error) {
+
+	var pseudonymConsumerRule []interface{}
+	for _, pseudonymConsumerItem := range pseudonymConsumer {
+		pseudonymConsumerRule = append(pseudonymConsumerRule, pseudonymConsumerItem)
+	}
+	var pseudonymOwnerRule []interface{}
+	for _, pseudonymOwnerItem := range pseudonymOwner {
+		pseudonymOwnerRule = append(pseudonymOwnerRule, pseudonymOwnerItem)
+	}
+
+	logs, sub, err := _UsageLogRegistry.contract.FilterLogs(opts, "UsageLogged", pseudonymConsumerRule, pseudonymOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLogRegistryUsageLoggedIterator{contract: _UsageLogRegistry.contract, event: "UsageLogged", logs: logs, sub: sub}, nil
+}
+
+// WatchUsageLogged is a free log subscription operation binding the contract event 0x97926ddbf69cf5d00204cd037d4f1f8f6624b94b2bfc894edf51a12844299af3.
+//
+// Solidity: event UsageLogged(bytes32 indexed pseudonymConsumer, bytes32 indexed pseudonymOwner, bytes payload)
+func (_UsageLogRegistry *UsageLogRegistryFilterer) WatchUsageLogged(opts *bind.WatchOpts, sink chan<- *UsageLogRegistryUsageLogged, pseudonymConsumer [][32]byte, pseudonymOwner [][32]byte) (event.Subscription, error) {
+
+	var pseudonymConsumerRule []interface{}
+	for _, pseudonymConsumerItem := range pseudonymConsumer {
+		pseudonymConsumerRule = append(pseudonymConsumerRule, pseudonymConsumerItem)
+	}
+	var pseudonymOwnerRule []interface{}
+	for _, pseudonymOwnerItem := range pseudonymOwner {
+		pseudonymOwnerRule = append(pseudonymOwnerRule, pseudonymOwnerItem)
+	}
+
+	logs, sub, err := _UsageLogRegistry.contract.WatchLogs(opts, "UsageLogged", pseudonymConsumerRule, pseudonymOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(UsageLogRegistryUsageLogged)
+				if err := _UsageLogRegistry.contract.UnpackLog(event, "UsageLogged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseUsageLogged is a log parse operation binding the contract event 0x97926ddbf69cf5d00204cd037d4f1f8f6624b94b2bfc894edf51a12844299af3.
+//
+// Solidity: event UsageLogged(bytes32 indexed pseudonymConsumer, bytes32 indexed pseudonymOwner, bytes payload)
+func (_UsageLogRegistry *UsageLogRegistryFilterer) ParseUsageLogged(log types.Log) (*UsageLogRegistryUsageLogged, error) {
+	event := new(UsageLogRegistryUsageLogged)
+	if err := _UsageLogRegistry.contract.UnpackLog(event, "UsageLogged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"node/logging"
+	"node/storage/registry"
+)
+
+// transactionCommitter sends a transaction and waits for its confirmations. It is implemented by ethSigner.
+type transactionCommitter interface {
+	commit(recipient *common.Address, input []byte) (*transactionReceipt, *BlockchainDurations, error)
+}
+
+// RegistryStore stores usage logs as UsageLogged events of the registry contract at options.RegistryAddress. The
+// pseudonyms are indexed topics, so logs are found with eth_getLogs instead of reading every block. Like the
+// blockchain store, it cannot update or delete logs.
+type RegistryStore struct {
+	address common.Address
+	abi     abi.ABI
+
+	// The committer and the filterer are only created when they are first needed, so reading does not need a key
+	// and writing does not need a connection for eth_getLogs
+	mutex        sync.Mutex
+	newCommitter func() (transactionCommitter, error)
+	committer    transactionCommitter
+	newFilterer  func() (bind.ContractFilterer, error)
+	filterer     *registry.UsageLogRegistryFilterer
+}
+
+// NewRegistryStore returns a store for the registry contract at options.RegistryAddress. The transactions are signed
+// with the key at options.EthereumKeyPath.
+func NewRegistryStore(options StoreOptions) (*RegistryStore, error) {
+	if !common.IsHexAddress(options.RegistryAddress) {
+		return nil, fmt.Errorf("storage/NewRegistryStore - Invalid registry address '%s'", options.RegistryAddress)
+	}
+
+	gethStore := NewGethStore(options)
+	return newRegistryStore(
+		common.HexToAddress(options.RegistryAddress),
+		func() (transactionCommitter, error) {
+			return gethStore.getSigner()
+		},
+		func() (bind.ContractFilterer, error) {
+			return ethclient.Dial(GethAddress())
+		},
+	)
+}
+
+func newRegistryStore(address common.Address, newCommitter func() (transactionCommitter, error), newFilterer func() (bind.ContractFilterer, error)) (*RegistryStore, error) {
+	parsed, err := registry.UsageLogRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("storage/NewRegistryStore - Could not parse the registry ABI: %w", err)
+	}
+
+	return &RegistryStore{address: address, abi: *parsed, newCommitter: newCommitter, newFilterer: newFilterer}, nil
+}
+
+// DeployRegistry deploys a new registry contract with the key at options.EthereumKeyPath and returns its address once
+// the deployment has options.Confirmations confirmations.
+func DeployRegistry(options StoreOptions) (common.Address, error) {
+	signer, err := newEthSigner(options.EthereumKeyPath, options.Confirmations, options.Mine)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("storage/DeployRegistry - %w", err)
+	}
+
+	receipt, _, err := signer.commit(nil, common.FromHex(registry.UsageLogRegistryMetaData.Bin))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("storage/DeployRegistry - %w", err)
+	} else if receipt.ContractAddress == nil {
+		return common.Address{}, fmt.Errorf("storage/DeployRegistry - Transaction %s created no contract", receipt.TransactionHash.Hex())
+	}
+
+	return *receipt.ContractAddress, nil
+}
+
+// Name returns StoreRegistry.
+func (store *RegistryStore) Name() string {
+	return StoreRegistry
+}
+
+// Append calls logUsage of the registry and waits for the confirmations of the transaction.
+func (store *RegistryStore) Append(payload BlockchainPayload) error {
+	pseudonymConsumer, err := pseudonymTopic(payload.PseudonymConsumer)
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - Consumer: %w", err)
+	}
+
+	pseudonymOwner, err := pseudonymTopic(payload.PseudonymOwner)
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - Owner: %w", err)
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - Could not marshal the log: %w", err)
+	}
+
+	input, err := store.abi.Pack("logUsage", pseudonymConsumer, pseudonymOwner, payloadBytes)
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - Could not encode the call: %w", err)
+	}
+
+	committer, err := store.getCommitter()
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - %w", err)
+	}
+
+	_, durations, err := committer.commit(&store.address, input)
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.Append - %w", err)
+	}
+
+	log.Info.Printf(""+
+		"Registry export duration: %v\n"+
+		"\tDuration of funding: %v\n"+
+		"\tDuration of transaction: %v\n"+
+		"\tDuration of confirmation: %v",
+		durations.TotalDuration, durations.Funding, durations.Transaction, durations.Confirmation,
+	)
+
+	return nil
+}
+
+// QueryByPseudonym returns the first log of the consumer or owner with the pseudonym. The consumer and the owner are
+// looked up with one eth_getLogs request each.
+func (store *RegistryStore) QueryByPseudonym(pseudonym string) (BlockchainPayload, error) {
+	topic, err := pseudonymTopic(pseudonym)
+	if err != nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/RegistryStore.QueryByPseudonym - %w", ErrLogNotFound)
+	}
+
+	var first *registry.UsageLogRegistryUsageLogged
+	var payload BlockchainPayload
+	for _, topics := range [][2][][32]byte{{{topic}, nil}, {nil, {topic}}} {
+		err = store.filter(topics[0], topics[1], func(event *registry.UsageLogRegistryUsageLogged, found BlockchainPayload) error {
+			if first == nil || event.Raw.BlockNumber < first.Raw.BlockNumber ||
+				(event.Raw.BlockNumber == first.Raw.BlockNumber && event.Raw.Index < first.Raw.Index) {
+				first = event
+				payload = found
+			}
+
+			// Only the first log of each query is needed
+			return errStopIteration
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			return BlockchainPayload{}, fmt.Errorf("storage/RegistryStore.QueryByPseudonym - %w", err)
+		}
+	}
+
+	if first == nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/RegistryStore.QueryByPseudonym - %w", ErrLogNotFound)
+	}
+
+	return payload, nil
+}
+
+// QueryAll reads all events of the registry in the order they were emitted.
+func (store *RegistryStore) QueryAll(handle func(payload BlockchainPayload) error) error {
+	err := store.filter(nil, nil, func(_ *registry.UsageLogRegistryUsageLogged, payload BlockchainPayload) error {
+		return handle(payload)
+	})
+	if err != nil {
+		return fmt.Errorf("storage/RegistryStore.QueryAll - %w", err)
+	}
+
+	return nil
+}
+
+// Update is not supported by the blockchain.
+func (store *RegistryStore) Update(string, BlockchainPayload) error {
+	return fmt.Errorf("storage/RegistryStore.Update - %w", ErrNotSupported)
+}
+
+// Delete is not supported by the blockchain.
+func (store *RegistryStore) Delete(string) error {
+	return fmt.Errorf("storage/RegistryStore.Delete - %w", ErrNotSupported)
+}
+
+// filter calls handle for every event with one of the pseudonyms. Events whose payload does not match their topics
+// are skipped, since anyone can call the registry.
+func (store *RegistryStore) filter(pseudonymsConsumer [][32]byte, pseudonymsOwner [][32]byte, handle func(event *registry.UsageLogRegistryUsageLogged, payload BlockchainPayload) error) error {
+	filterer, err := store.getFilterer()
+	if err != nil {
+		return err
+	}
+
+	events, err := filterer.FilterUsageLogged(&bind.FilterOpts{Start: 0}, pseudonymsConsumer, pseudonymsOwner)
+	if err != nil {
+		return fmt.Errorf("could not filter the registry events: %w", err)
+	}
+	defer events.Close()
+
+	for events.Next() {
+		var payload BlockchainPayload
+		err = json.Unmarshal(events.Event.Payload, &payload)
+		if err != nil {
+			log.Error.Printf("node/RegistryStore - Found a malformed usage log in %s: %s\n", events.Event.Raw.TxHash.Hex(), err)
+			continue
+		}
+
+		if payload.PseudonymConsumer != hex.EncodeToString(events.Event.PseudonymConsumer[:]) ||
+			payload.PseudonymOwner != hex.EncodeToString(events.Event.PseudonymOwner[:]) {
+			log.Error.Printf("node/RegistryStore - The usage log in %s does not match its pseudonyms\n", events.Event.Raw.TxHash.Hex())
+			continue
+		}
+
+		err = handle(events.Event, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return events.Error()
+}
+
+func (store *RegistryStore) getCommitter() (transactionCommitter, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.committer == nil {
+		committer, err := store.newCommitter()
+		if err != nil {
+			return nil, err
+		}
+
+		store.committer = committer
+	}
+
+	return store.committer, nil
+}
+
+func (store *RegistryStore) getFilterer() (*registry.UsageLogRegistryFilterer, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.filterer == nil {
+		backend, err := store.newFilterer()
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to geth: %w", err)
+		}
+
+		filterer, err := registry.NewUsageLogRegistryFilterer(store.address, backend)
+		if err != nil {
+			return nil, fmt.Errorf("could not bind the registry: %w", err)
+		}
+
+		store.filterer = filterer
+	}
+
+	return store.filterer, nil
+}
+
+// pseudonymTopic returns the 32 bytes of a hex pseudonym.
+func pseudonymTopic(pseudonym string) ([32]byte, error) {
+	var topic [32]byte
+
+	decoded, err := hex.DecodeString(pseudonym)
+	if err != nil || len(decoded) != len(topic) {
+		return topic, fmt.Errorf("invalid pseudonym '%s'", pseudonym)
+	}
+
+	copy(topic[:], decoded)
+	return topic, nil
+}
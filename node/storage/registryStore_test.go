@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"node/storage/registry"
+)
+
+// simulatedCommitter sends transactions to a simulated backend and mines a block for each of them.
+type simulatedCommitter struct {
+	backend *backends.SimulatedBackend
+	key     *ecdsa.PrivateKey
+}
+
+func (committer *simulatedCommitter) commit(recipient *common.Address, input []byte) (*transactionReceipt, *BlockchainDurations, error) {
+	ctx := context.Background()
+	from := crypto.PubkeyToAddress(committer.key.PublicKey)
+
+	nonce, err := committer.backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gas, err := committer.backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: recipient, Data: input})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gasPrice, err := committer.backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction, err := types.SignTx(types.NewTransaction(nonce, *recipient, big.NewInt(0), gas, gasPrice, input), types.HomesteadSigner{}, committer.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = committer.backend.SendTransaction(ctx, transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	committer.backend.Commit()
+
+	receipt, err := committer.backend.TransactionReceipt(ctx, transaction.Hash())
+	if err != nil {
+		return nil, nil, err
+	} else if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, nil, errors.New("the transaction failed")
+	}
+
+	return &transactionReceipt{TransactionHash: transaction.Hash()}, &BlockchainDurations{}, nil
+}
+
+func _newSimulatedRegistry(t *testing.T) (*RegistryStore, *simulatedCommitter, *bind.TransactOpts) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Could not generate key: %v\n", err)
+	}
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{from: {Balance: big.NewInt(1e18)}}, 8_000_000)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatalf("Could not create transactor: %v\n", err)
+	}
+
+	address, _, _, err := registry.DeployUsageLogRegistry(auth, backend)
+	if err != nil {
+		t.Fatalf("Could not deploy registry: %v\n", err)
+	}
+	backend.Commit()
+
+	committer := &simulatedCommitter{backend: backend, key: key}
+	store, err := newRegistryStore(
+		address,
+		func() (transactionCommitter, error) { return committer, nil },
+		func() (bind.ContractFilterer, error) { return backend, nil },
+	)
+	if err != nil {
+		t.Fatalf("Could not create store: %v\n", err)
+	}
+
+	return store, committer, auth
+}
+
+func TestRegistryStore(t *testing.T) {
+	store, _, _ := _newSimulatedRegistry(t)
+
+	consumer := strings.Repeat("c", 64)
+	owner1 := strings.Repeat("a", 64)
+	owner2 := strings.Repeat("b", 64)
+	payloads := []BlockchainPayload{
+		{PseudonymConsumer: consumer, PseudonymOwner: owner1, EncryptedOwner: UsageLogContent{Version: 2, Ciphertext: strings.Repeat("x", 100)}},
+		{PseudonymConsumer: consumer, PseudonymOwner: owner2, EncryptedConsumer: UsageLogContent{Version: 2, Ciphertext: "y"}},
+	}
+
+	for _, payload := range payloads {
+		err := store.Append(payload)
+		if err != nil {
+			t.Fatalf("TestRegistryStore - Could not append log: %v\n", err)
+		}
+	}
+
+	found, err := store.QueryByPseudonym(owner2)
+	if err != nil || found != payloads[1] {
+		t.Errorf("TestRegistryStore - Could not find log by owner: %v, %v\n", found, err)
+	}
+
+	// The consumer has two logs, of which the first one is returned
+	found, err = store.QueryByPseudonym(consumer)
+	if err != nil || found != payloads[0] {
+		t.Errorf("TestRegistryStore - Could not find first log by consumer: %v, %v\n", found, err)
+	}
+
+	_, err = store.QueryByPseudonym(strings.Repeat("d", 64))
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestRegistryStore - Expected ErrLogNotFound: %v\n", err)
+	}
+
+	all := make([]BlockchainPayload, 0)
+	err = store.QueryAll(func(payload BlockchainPayload) error {
+		all = append(all, payload)
+		return nil
+	})
+	if err != nil || len(all) != 2 || all[0] != payloads[0] || all[1] != payloads[1] {
+		t.Errorf("TestRegistryStore - Could not query all logs: %v, %v\n", all, err)
+	}
+
+	err = store.Append(BlockchainPayload{PseudonymConsumer: "invalid", PseudonymOwner: owner1})
+	if err == nil {
+		t.Errorf("TestRegistryStore - A log with an invalid pseudonym was appended\n")
+	}
+}
+
+func TestRegistryContract(t *testing.T) {
+	store, committer, auth := _newSimulatedRegistry(t)
+
+	contract, err := registry.NewUsageLogRegistry(store.address, committer.backend)
+	if err != nil {
+		t.Fatalf("TestRegistryContract - Could not bind registry: %v\n", err)
+	}
+
+	// Logs whose payload does not match their pseudonyms are skipped
+	consumer := strings.Repeat("1", 64)
+	topic, _ := pseudonymTopic(consumer)
+	_, err = contract.LogUsage(auth, topic, topic, []byte(`{"pseudonym_consumer":"other","pseudonym_owner":"other"}`))
+	if err != nil {
+		t.Fatalf("TestRegistryContract - Could not call logUsage: %v\n", err)
+	}
+	committer.backend.Commit()
+
+	_, err = store.QueryByPseudonym(consumer)
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestRegistryContract - A mismatching log was returned: %v\n", err)
+	}
+
+	// The event data is the payload as it was passed
+	events, err := contract.FilterUsageLogged(&bind.FilterOpts{Start: 0}, [][32]byte{topic}, nil)
+	if err != nil || !events.Next() || string(events.Event.Payload) != `{"pseudonym_consumer":"other","pseudonym_owner":"other"}` {
+		t.Errorf("TestRegistryContract - The event does not contain the payload: %v\n", err)
+	}
+
+	// Ether and unknown functions are rejected
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	from := crypto.PubkeyToAddress(committer.key.PublicKey)
+	data, err := store.abi.Pack("logUsage", topic, topic, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("TestRegistryContract - Could not pack logUsage: %v\n", err)
+	}
+
+	_, err = committer.backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &store.address, Data: data})
+	if err != nil {
+		t.Errorf("TestRegistryContract - A valid call was rejected: %v\n", err)
+	}
+
+	_, err = committer.backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &store.address, Value: big.NewInt(1), Data: data})
+	if err == nil {
+		t.Errorf("TestRegistryContract - A call with ether was accepted\n")
+	}
+
+	_, err = committer.backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &store.address, Data: make([]byte, 100)})
+	if err == nil {
+		t.Errorf("TestRegistryContract - An unknown function was accepted\n")
+	}
+}
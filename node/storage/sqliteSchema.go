@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	// Needed for sqlite3 driver.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations are applied in order to bring the database of the SQLite store to the current schema. The version of
+// a database is the number of migrations that were applied to it and is stored in schema_version. Migrations must
+// never be changed once they are released; a new schema needs a new migration.
+var sqliteMigrations = []string{
+	// 1: Table of the first versions. Databases created by them have this table but no schema_version
+	`CREATE TABLE IF NOT EXISTS exportTable (PseudonymConsumer text, PseudonymOwner text, EncryptedConsumer text, EncryptedOwner text)`,
+
+	// 2: Primary key, creation time and indexes on the pseudonyms. Existing logs get the time of the migration
+	`CREATE TABLE exportTable_new (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+		PseudonymConsumer TEXT NOT NULL,
+		PseudonymOwner TEXT NOT NULL,
+		EncryptedConsumer TEXT NOT NULL,
+		EncryptedOwner TEXT NOT NULL
+	);
+	INSERT INTO exportTable_new (PseudonymConsumer, PseudonymOwner, EncryptedConsumer, EncryptedOwner)
+		SELECT PseudonymConsumer, PseudonymOwner, EncryptedConsumer, EncryptedOwner FROM exportTable;
+	DROP TABLE exportTable;
+	ALTER TABLE exportTable_new RENAME TO exportTable;
+	CREATE INDEX exportTable_PseudonymConsumer ON exportTable (PseudonymConsumer);
+	CREATE INDEX exportTable_PseudonymOwner ON exportTable (PseudonymOwner);`,
+}
+
+// openOrInitDB opens the database at path and applies the migrations it is missing.
+func openOrInitDB(path string, migrations []string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("node/openOrInitDB - could not open db: %w", err)
+	}
+
+	err = migrateDB(db, migrations)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("node/openOrInitDB - %w", err)
+	}
+
+	return db, nil
+}
+
+// migrateDB applies the migrations the database is missing. Every migration runs in its own transaction together with
+// the update of schema_version.
+func migrateDB(db *sql.DB, migrations []string) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("could not create schema_version: %w", err)
+	}
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("the database has schema version %d, but only %d is supported", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		err = applyMigration(db, version+1, migrations[version])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("could not read schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+func applyMigration(db *sql.DB, version int, migration string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin migration %d: %w", version, err)
+	}
+
+	_, err = tx.Exec(migration)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not apply migration %d: %w", version, err)
+	}
+
+	_, err = tx.Exec("DELETE FROM schema_version")
+	if err == nil {
+		_, err = tx.Exec("INSERT INTO schema_version (version) VALUES (?)", version)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not update schema version to %d: %w", version, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit migration %d: %w", version, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	log "node/logging"
+)
+
+// Columns of a usage log in the order scanPayload expects them.
+const payloadColumns = "PseudonymConsumer, PseudonymOwner, EncryptedConsumer, EncryptedOwner"
+
+// SQLiteStore stores usage logs in the exportTable of a SQLite database. All queries are prepared when the store is
+// opened.
+type SQLiteStore struct {
+	db *sql.DB
+
+	insert           *sql.Stmt
+	queryByPseudonym *sql.Stmt
+	queryAll         *sql.Stmt
+	update           *sql.Stmt
+	remove           *sql.Stmt
+}
+
+// NewSQLiteStore opens the database at path and migrates it to the current schema.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := openOrInitDB(path, sqliteMigrations)
+	if err != nil {
+		return nil, fmt.Errorf("storage/NewSQLiteStore - %w", err)
+	}
+
+	store := &SQLiteStore{db: db}
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&store.insert, "INSERT INTO exportTable (created_at, " + payloadColumns + ") VALUES (?,?,?,?,?)"},
+		{&store.queryByPseudonym, "SELECT " + payloadColumns + " FROM exportTable WHERE PseudonymConsumer = ? OR PseudonymOwner = ? ORDER BY id LIMIT 1"},
+		{&store.queryAll, "SELECT " + payloadColumns + " FROM exportTable ORDER BY id"},
+		{&store.update, "UPDATE exportTable SET PseudonymConsumer = ?, PseudonymOwner = ?, EncryptedConsumer = ?, EncryptedOwner = ? WHERE PseudonymConsumer = ? OR PseudonymOwner = ?"},
+		{&store.remove, "DELETE FROM exportTable WHERE PseudonymConsumer = ? OR PseudonymOwner = ?"},
+	}
+
+	for _, statement := range statements {
+		*statement.stmt, err = db.Prepare(statement.query)
+		if err != nil {
+			_ = store.Close()
+			return nil, fmt.Errorf("storage/NewSQLiteStore - Could not prepare statement: %w", err)
+		}
+	}
+
+	return store, nil
+}
+
+// Name returns StoreSQLite.
+func (store *SQLiteStore) Name() string {
+	return StoreSQLite
+}
+
+// Append inserts the log.
+func (store *SQLiteStore) Append(payload BlockchainPayload) error {
+	start := time.Now()
+
+	encryptedConsumer, encryptedOwner, err := marshalLogContents(&payload)
+	if err != nil {
+		return fmt.Errorf("storage/SQLiteStore.Append - %w", err)
+	}
+
+	_, err = store.insert.Exec(time.Now().Unix(), payload.PseudonymConsumer, payload.PseudonymOwner, encryptedConsumer, encryptedOwner)
+	if err != nil {
+		return fmt.Errorf("storage/SQLiteStore.Append - %w", err)
+	}
+
+	log.Info.Printf("SQLite export duration: %s", time.Since(start))
+	return nil
+}
+
+// QueryByPseudonym returns the first log of the consumer or owner with the pseudonym.
+func (store *SQLiteStore) QueryByPseudonym(pseudonym string) (BlockchainPayload, error) {
+	row := store.queryByPseudonym.QueryRow(pseudonym, pseudonym)
+
+	payload, err := scanPayload(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return BlockchainPayload{}, fmt.Errorf("storage/SQLiteStore.QueryByPseudonym - %w", ErrLogNotFound)
+	} else if err != nil {
+		return BlockchainPayload{}, fmt.Errorf("storage/SQLiteStore.QueryByPseudonym - %w", err)
+	}
+
+	return payload, nil
+}
+
+// QueryAll reads the logs row by row in the order they were appended.
+func (store *SQLiteStore) QueryAll(handle func(payload BlockchainPayload) error) error {
+	rows, err := store.queryAll.Query()
+	if err != nil {
+		return fmt.Errorf("storage/SQLiteStore.QueryAll - %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payload, err := scanPayload(rows)
+		if err != nil {
+			return fmt.Errorf("storage/SQLiteStore.QueryAll - %w", err)
+		}
+
+		err = handle(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Update replaces the log of the consumer or owner with the pseudonym.
+func (store *SQLiteStore) Update(pseudonym string, payload BlockchainPayload) error {
+	encryptedConsumer, encryptedOwner, err := marshalLogContents(&payload)
+	if err != nil {
+		return fmt.Errorf("storage/SQLiteStore.Update - %w", err)
+	}
+
+	result, err := store.update.Exec(payload.PseudonymConsumer, payload.PseudonymOwner, encryptedConsumer, encryptedOwner, pseudonym, pseudonym)
+
+	return checkAffected("storage/SQLiteStore.Update", result, err)
+}
+
+// Delete removes the log of the consumer or owner with the pseudonym.
+func (store *SQLiteStore) Delete(pseudonym string) error {
+	result, err := store.remove.Exec(pseudonym, pseudonym)
+
+	return checkAffected("storage/SQLiteStore.Delete", result, err)
+}
+
+// Close closes the prepared statements and the database.
+func (store *SQLiteStore) Close() error {
+	for _, stmt := range []*sql.Stmt{store.insert, store.queryByPseudonym, store.queryAll, store.update, store.remove} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+
+	return store.db.Close()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPayload(row scanner) (BlockchainPayload, error) {
+	var payload BlockchainPayload
+	var encryptedConsumerStr string
+	var encryptedOwnerStr string
+
+	err := row.Scan(&payload.PseudonymConsumer, &payload.PseudonymOwner, &encryptedConsumerStr, &encryptedOwnerStr)
+	if err != nil {
+		return BlockchainPayload{}, err
+	}
+
+	payload.EncryptedConsumer, payload.EncryptedOwner, err = unmarshalLogContents(encryptedConsumerStr, encryptedOwnerStr)
+	if err != nil {
+		return BlockchainPayload{}, err
+	}
+
+	return payload, nil
+}
+
+func marshalLogContents(payload *BlockchainPayload) (string, string, error) {
+	encryptedConsumer, err := json.Marshal(payload.EncryptedConsumer)
+	if err != nil {
+		return "", "", fmt.Errorf("could not marshal encrypted consumer: %w", err)
+	}
+
+	encryptedOwner, err := json.Marshal(payload.EncryptedOwner)
+	if err != nil {
+		return "", "", fmt.Errorf("could not marshal encrypted owner: %w", err)
+	}
+
+	return string(encryptedConsumer), string(encryptedOwner), nil
+}
+
+func unmarshalLogContents(encryptedConsumerStr string, encryptedOwnerStr string) (UsageLogContent, UsageLogContent, error) {
+	var encryptedConsumer UsageLogContent
+	var encryptedOwner UsageLogContent
+
+	err := json.Unmarshal([]byte(encryptedConsumerStr), &encryptedConsumer)
+	if err != nil {
+		return UsageLogContent{}, UsageLogContent{}, fmt.Errorf("could not unmarshal encrypted consumer: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(encryptedOwnerStr), &encryptedOwner)
+	if err != nil {
+		return UsageLogContent{}, UsageLogContent{}, fmt.Errorf("could not unmarshal encrypted owner: %w", err)
+	}
+
+	return encryptedConsumer, encryptedOwner, nil
+}
+
+func checkAffected(prefix string, result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s - %w", prefix, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s - %w", prefix, err)
+	} else if affected == 0 {
+		return fmt.Errorf("%s - %w", prefix, ErrLogNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "database.db"))
+	if err != nil {
+		t.Fatalf("TestSQLiteStore - Could not open store: %s\n", err)
+	}
+	defer store.Close()
+
+	ownerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	consumerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	usageLog, err := NewUsageLog("justification", "datum", &ownerKey.PublicKey, &consumerKey.PublicKey)
+	if err != nil {
+		t.Fatalf("TestSQLiteStore - Could not create usage log: %s\n", err)
+	}
+
+	err = store.Append(usageLog)
+	if err != nil {
+		t.Fatalf("TestSQLiteStore - Could not append log: %s\n", err)
+	}
+
+	// The log is found by both pseudonyms
+	for _, pseudonym := range []string{usageLog.PseudonymOwner, usageLog.PseudonymConsumer} {
+		found, err := store.QueryByPseudonym(pseudonym)
+		if err != nil || found != usageLog {
+			t.Errorf("TestSQLiteStore - Unexpected log: %v, %v\n", found, err)
+		}
+	}
+
+	updatedLog, _ := NewUsageLog("updated", "datum", &ownerKey.PublicKey, &consumerKey.PublicKey)
+	err = store.Update(usageLog.PseudonymOwner, updatedLog)
+	if err != nil {
+		t.Fatalf("TestSQLiteStore - Could not update log: %s\n", err)
+	}
+
+	count := 0
+	err = store.QueryAll(func(payload BlockchainPayload) error {
+		count++
+		if payload != updatedLog {
+			t.Errorf("TestSQLiteStore - The log was not updated: %v\n", payload)
+		}
+
+		return nil
+	})
+	if err != nil || count != 1 {
+		t.Errorf("TestSQLiteStore - Unexpected result of QueryAll: %d logs, %v\n", count, err)
+	}
+
+	err = store.Delete(usageLog.PseudonymConsumer)
+	if err != nil {
+		t.Fatalf("TestSQLiteStore - Could not delete log: %s\n", err)
+	}
+
+	_, err = store.QueryByPseudonym(usageLog.PseudonymConsumer)
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestSQLiteStore - Expected ErrLogNotFound, got: %v\n", err)
+	}
+
+	err = store.Delete(usageLog.PseudonymConsumer)
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestSQLiteStore - Deleting a missing log should fail with ErrLogNotFound, got: %v\n", err)
+	}
+}
+
+func TestSQLiteMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.db")
+
+	ownerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	consumerKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	usageLog, _ := NewUsageLog("justification", "datum", &ownerKey.PublicKey, &consumerKey.PublicKey)
+	encryptedConsumer, encryptedOwner, _ := marshalLogContents(&usageLog)
+
+	// Databases of the first versions only have the exportTable
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("TestSQLiteMigration - Could not open database: %s\n", err)
+	}
+
+	_, err = db.Exec(sqliteMigrations[0])
+	if err == nil {
+		_, err = db.Exec("INSERT INTO exportTable VALUES (?,?,?,?)", usageLog.PseudonymConsumer, usageLog.PseudonymOwner, encryptedConsumer, encryptedOwner)
+	}
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("TestSQLiteMigration - Could not create old database: %s\n", err)
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("TestSQLiteMigration - Could not open store: %s\n", err)
+	}
+	defer store.Close()
+
+	version, err := schemaVersion(store.db)
+	if err != nil || version != len(sqliteMigrations) {
+		t.Errorf("TestSQLiteMigration - Unexpected schema version %d: %v\n", version, err)
+	}
+
+	var id, createdAt int64
+	err = store.db.QueryRow("SELECT id, created_at FROM exportTable").Scan(&id, &createdAt)
+	if err != nil || id != 1 || createdAt == 0 {
+		t.Errorf("TestSQLiteMigration - The log was not migrated: %d, %d, %v\n", id, createdAt, err)
+	}
+
+	found, err := store.QueryByPseudonym(usageLog.PseudonymConsumer)
+	if err != nil || found != usageLog {
+		t.Errorf("TestSQLiteMigration - Unexpected log: %v, %v\n", found, err)
+	}
+
+	var indexes int
+	_ = store.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'exportTable' AND name LIKE 'exportTable_Pseudonym%'").Scan(&indexes)
+	if indexes != 2 {
+		t.Errorf("TestSQLiteMigration - Expected 2 indexes on the pseudonyms, found %d\n", indexes)
+	}
+
+	// Pseudonyms are never part of the SQL
+	_, err = store.QueryByPseudonym("' OR '1'='1")
+	if !errors.Is(err, ErrLogNotFound) {
+		t.Errorf("TestSQLiteMigration - Expected ErrLogNotFound for an injected pseudonym, got: %v\n", err)
+	}
+
+	// Opening a migrated database again does not change it
+	_ = store.Close()
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("TestSQLiteMigration - Could not reopen store: %s\n", err)
+	}
+	defer store.Close()
+
+	count := 0
+	_ = store.QueryAll(func(BlockchainPayload) error {
+		count++
+		return nil
+	})
+	if count != 1 {
+		t.Errorf("TestSQLiteMigration - Expected 1 log after reopening, found %d\n", count)
+	}
+}
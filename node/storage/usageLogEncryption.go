@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"node/constants"
+)
+
+type usageLogPlaintext struct {
+	Justification string `json:"explanation"`
+	DatumRequest  string `json:"datum"`
+}
+
+// EncryptUsageLogContent encrypts the justification and datum with AES-GCM under a random key, which is wrapped with
+// RSA-OAEP. Unlike constants.UsageLogVersionRSA, the length of the content is not limited by the RSA key size.
+func EncryptUsageLogContent(justification string, datum string, timestamp int64, publicKey *rsa.PublicKey) (UsageLogContent, error) {
+	plaintext, err := json.Marshal(usageLogPlaintext{Justification: justification, DatumRequest: datum})
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/EncryptUsageLogContent - Could not marshal content: %w", err)
+	}
+
+	key := make([]byte, 32)
+	nonce := make([]byte, 12)
+	for _, buffer := range [][]byte{key, nonce} {
+		if _, err = io.ReadFull(rand.Reader, buffer); err != nil {
+			return UsageLogContent{}, fmt.Errorf("node/EncryptUsageLogContent - Could not generate randomness: %w", err)
+		}
+	}
+
+	aesGCM, err := newUsageLogCipher(key)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/EncryptUsageLogContent - %w", err)
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, []byte(constants.RSAEncryptionLabel))
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/EncryptUsageLogContent - Could not wrap key: %w", err)
+	}
+
+	content := UsageLogContent{
+		Version:    constants.UsageLogVersionHybrid,
+		Timestamp:  timestamp,
+		WrappedKey: hex.EncodeToString(wrappedKey),
+		Nonce:      hex.EncodeToString(nonce),
+	}
+	content.Ciphertext = hex.EncodeToString(aesGCM.Seal(nil, nonce, plaintext, content.additionalData()))
+
+	return content, nil
+}
+
+// Decrypt returns the content with the justification and datum in plaintext. It reads both versions.
+func (content *UsageLogContent) Decrypt(privateKey *rsa.PrivateKey) (UsageLogContent, error) {
+	switch content.Version {
+	case 0, constants.UsageLogVersionRSA:
+		return content.decryptRSA(privateKey)
+	case constants.UsageLogVersionHybrid:
+		return content.decryptHybrid(privateKey)
+	default:
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Unknown version %d", content.Version)
+	}
+}
+
+func (content *UsageLogContent) decryptRSA(privateKey *rsa.PrivateKey) (UsageLogContent, error) {
+	justification, err := PublicKeyDecryption(content.Justification, privateKey)
+	if err != nil {
+		return UsageLogContent{}, err
+	}
+
+	datum, err := PublicKeyDecryption(content.DatumRequest, privateKey)
+	if err != nil {
+		return UsageLogContent{}, err
+	}
+
+	return UsageLogContent{
+		Version:       constants.UsageLogVersionRSA,
+		Justification: string(justification),
+		DatumRequest:  string(datum),
+		Timestamp:     content.Timestamp,
+	}, nil
+}
+
+func (content *UsageLogContent) decryptHybrid(privateKey *rsa.PrivateKey) (UsageLogContent, error) {
+	wrappedKey, err := hex.DecodeString(content.WrappedKey)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not decode wrapped key: %w", err)
+	}
+
+	nonce, err := hex.DecodeString(content.Nonce)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not decode nonce: %w", err)
+	}
+
+	ciphertext, err := hex.DecodeString(content.Ciphertext)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not decode ciphertext: %w", err)
+	}
+
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, wrappedKey, []byte(constants.RSAEncryptionLabel))
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not unwrap key: %w", err)
+	}
+
+	aesGCM, err := newUsageLogCipher(key)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - %w", err)
+	}
+
+	if len(nonce) != aesGCM.NonceSize() {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Invalid nonce size %d", len(nonce))
+	}
+
+	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, content.additionalData())
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not decrypt content: %w", err)
+	}
+
+	var decrypted usageLogPlaintext
+	err = json.Unmarshal(plaintext, &decrypted)
+	if err != nil {
+		return UsageLogContent{}, fmt.Errorf("node/UsageLogContent.Decrypt - Could not unmarshal content: %w", err)
+	}
+
+	return UsageLogContent{
+		Version:       constants.UsageLogVersionHybrid,
+		Justification: decrypted.Justification,
+		DatumRequest:  decrypted.DatumRequest,
+		Timestamp:     content.Timestamp,
+	}, nil
+}
+
+// additionalData binds the version and timestamp to the ciphertext so that they cannot be changed unnoticed.
+func (content *UsageLogContent) additionalData() []byte {
+	return []byte(constants.UsageLogAdditionalData + "/" + strconv.Itoa(content.Version) + "/" + strconv.FormatInt(content.Timestamp, 10))
+}
+
+func newUsageLogCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
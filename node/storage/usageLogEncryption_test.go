@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"node/constants"
+)
+
+func TestUsageLogContentEncryption(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, constants.RSAKeySize)
+	if err != nil {
+		t.Fatalf("TestUsageLogContentEncryption - Could not generate key: %s\n", err)
+	}
+
+	// Far longer than what RSA-OAEP can encrypt directly
+	justification := strings.Repeat("A long justification. ", 1000)
+
+	content, err := EncryptUsageLogContent(justification, "datum", 1650000000, &privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("TestUsageLogContentEncryption - Could not encrypt: %s\n", err)
+	}
+
+	decrypted, err := content.Decrypt(privateKey)
+	if err != nil {
+		t.Fatalf("TestUsageLogContentEncryption - Could not decrypt: %s\n", err)
+	}
+
+	if decrypted.Justification != justification || decrypted.DatumRequest != "datum" || decrypted.Timestamp != 1650000000 {
+		t.Errorf("TestUsageLogContentEncryption - Unexpected content: %v\n", decrypted)
+	}
+
+	// The timestamp is bound to the ciphertext
+	tampered := content
+	tampered.Timestamp++
+	_, err = tampered.Decrypt(privateKey)
+	if err == nil {
+		t.Errorf("TestUsageLogContentEncryption - A changed timestamp was not detected\n")
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, err = content.Decrypt(otherKey)
+	if err == nil {
+		t.Errorf("TestUsageLogContentEncryption - Decrypted with the wrong key\n")
+	}
+}
+
+func TestLegacyUsageLogContent(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("TestLegacyUsageLogContent - Could not generate key: %s\n", err)
+	}
+
+	encryptedJustification, _ := PublicKeyEncryption("justification", &privateKey.PublicKey)
+	encryptedDatum, _ := PublicKeyEncryption("datum", &privateKey.PublicKey)
+
+	// Logs written before the envelope was versioned have no version
+	content := UsageLogContent{
+		Justification: encryptedJustification,
+		DatumRequest:  encryptedDatum,
+		Timestamp:     1650000000,
+	}
+
+	decrypted, err := content.Decrypt(privateKey)
+	if err != nil {
+		t.Fatalf("TestLegacyUsageLogContent - Could not decrypt: %s\n", err)
+	}
+
+	if decrypted.Justification != "justification" || decrypted.DatumRequest != "datum" || decrypted.Version != constants.UsageLogVersionRSA {
+		t.Errorf("TestLegacyUsageLogContent - Unexpected content: %v\n", decrypted)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"node/constants"
+)
+
+const (
+	StoreSQLite          = "sqlite"
+	StoreBlockchain      = "blockchain"
+	StoreBlockchainBatch = "blockchain-batch"
+	StoreRegistry        = "registry"
+)
+
+// ErrLogNotFound is returned by a UsageLogStore if no log has the requested pseudonym.
+var ErrLogNotFound = errors.New("no usage log has the requested pseudonym")
+
+// ErrNotSupported is returned by a UsageLogStore that cannot change logs once they are written.
+var ErrNotSupported = errors.New("the store does not support this operation")
+
+// UsageLogStore persists usage logs. A log is found by the pseudonym of its consumer or owner.
+type UsageLogStore interface {
+	// Name returns the name the store was selected with.
+	Name() string
+	// Append adds the log to the store.
+	Append(payload BlockchainPayload) error
+	// QueryByPseudonym returns the log of the consumer or owner with the pseudonym or ErrLogNotFound.
+	QueryByPseudonym(pseudonym string) (BlockchainPayload, error)
+	// QueryAll calls handle for every log in the store. It stops at the first error handle returns.
+	QueryAll(handle func(payload BlockchainPayload) error) error
+	// Update replaces the log of the consumer or owner with the pseudonym.
+	Update(pseudonym string, payload BlockchainPayload) error
+	// Delete removes the log of the consumer or owner with the pseudonym.
+	Delete(pseudonym string) error
+}
+
+// StoreOptions configures the stores returned by NewUsageLogStore.
+type StoreOptions struct {
+	// SQLitePath is the database of the SQLite store
+	SQLitePath string
+	// EthereumKeyPath is the key that signs the transactions of the blockchain store. It is created if it does not exist
+	EthereumKeyPath string
+	// Confirmations is the number of blocks a transaction needs before the blockchain store considers a log stored
+	Confirmations int
+	// Mine lets the local geth node mine with the signer's address while the blockchain store needs funds or
+	// confirmations
+	Mine bool
+	// BatchSize is the number of logs the batch store anchors in one transaction
+	BatchSize int
+	// BatchInterval is the longest time a log waits in the batch store before its batch is anchored
+	BatchInterval time.Duration
+	// BatchDirectory keeps the logs of the batch store and their inclusion proofs
+	BatchDirectory string
+	// IndexPath is the SQLite database that indexes the logs of the blockchain store
+	IndexPath string
+	// RegistryAddress is the address of the registry contract used by the registry store
+	RegistryAddress string
+}
+
+// DefaultStoreOptions returns the options used if nothing else is configured.
+func DefaultStoreOptions() StoreOptions {
+	return StoreOptions{
+		SQLitePath:      constants.SQLitePath,
+		EthereumKeyPath: constants.EthereumKeyFilePath,
+		Confirmations:   constants.DefaultConfirmations,
+		Mine:            true,
+		BatchSize:       constants.DefaultBatchSize,
+		BatchInterval:   constants.DefaultBatchInterval,
+		BatchDirectory:  constants.BatchDirectory,
+		IndexPath:       constants.BlockchainIndexPath,
+	}
+}
+
+// NewUsageLogStore returns the store of the given type.
+func NewUsageLogStore(storeType string, options StoreOptions) (UsageLogStore, error) {
+	switch strings.ToLower(strings.TrimSpace(storeType)) {
+	case StoreSQLite:
+		return NewSQLiteStore(options.SQLitePath)
+	case StoreBlockchain:
+		return NewGethStore(options), nil
+	case StoreBlockchainBatch:
+		return NewBatchStore(options)
+	case StoreRegistry:
+		return NewRegistryStore(options)
+	default:
+		return nil, fmt.Errorf("storage/NewUsageLogStore - Unknown store type '%s'", storeType)
+	}
+}
+
+// NewUsageLogStores returns the stores of a comma-separated list of store types.
+func NewUsageLogStores(storeTypes string, options StoreOptions) ([]UsageLogStore, error) {
+	stores := make([]UsageLogStore, 0)
+
+	for _, storeType := range strings.Split(storeTypes, ",") {
+		if strings.TrimSpace(storeType) == "" {
+			continue
+		}
+
+		store, err := NewUsageLogStore(storeType, options)
+		if err != nil {
+			return nil, err
+		}
+
+		stores = append(stores, store)
+	}
+
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("storage/NewUsageLogStores - No store given")
+	}
+
+	return stores, nil
+}
+
+// FollowIndexes keeps the blockchain index of every store that reads its logs from one synced every interval until
+// stop is closed. Other stores are skipped.
+func FollowIndexes(stores []UsageLogStore, interval time.Duration, stop <-chan struct{}) error {
+	for _, store := range stores {
+		gethStore, ok := store.(*GethStore)
+		if !ok {
+			continue
+		}
+
+		err := gethStore.Follow(interval, stop)
+		if err != nil {
+			return fmt.Errorf("storage/FollowIndexes - %w", err)
+		}
+	}
+
+	return nil
+}
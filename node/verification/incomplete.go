@@ -0,0 +1,78 @@
+package verification
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"node/codec"
+	"node/constants"
+	"node/p2p"
+)
+
+// ErrIncomplete is returned for a verified record of an exchange that stopped before the requester acknowledged the
+// real decryption data.
+var ErrIncomplete = errors.New("the requester did not acknowledge the real decryption data")
+
+// Incomplete verifies the listener's record of an exchange that stopped early. The record is the requester's identity
+// card, its request, the listener's response and, if it was received, the requester's acknowledgement of the
+// response. Like Exchange, it returns the type of the party that recorded it, which is always the listener, and the
+// SSOID of the requester. The error wraps ErrIncomplete if the record is genuine.
+func Incomplete(signedMessages []p2p.SignedMessage, conversationPublicKey *rsa.PublicKey, identityKey *rsa.PublicKey, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey) (constants.MessageType, string, string, error) {
+	if len(signedMessages) != 3 && len(signedMessages) != 4 {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - Expected 3 or 4 messages, got %d", len(signedMessages))
+	}
+
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", err
+	}
+
+	request, identityCard, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], revoloriPublicKey, messageCodec)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - %w", err)
+	} else if request.Type != constants.MessageTypeRequester {
+		return constants.MessageTypeFailure, "", "", errors.New("verification/Incomplete - The record does not start with a request")
+	}
+
+	// The response is signed with the listener's identity key and carries the stored conversation key
+	var response p2p.FirstMessage
+	err = signedMessages[2].VerifySignature(identityKey)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - Could not verify the response: %w", err)
+	}
+
+	err = messageCodec.Unmarshal(signedMessages[2].Content, &response)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - Could not unmarshal the response: %w", err)
+	} else if response.Type != constants.MessageTypeListener || !response.PublicKey.Equal(conversationPublicKey) {
+		return constants.MessageTypeFailure, "", "", errors.New("verification/Incomplete - The response does not belong to the stored conversation key")
+	}
+
+	for _, firstMessage := range []p2p.FirstMessage{request, response} {
+		err = parameters.CheckHandshakeHash(firstMessage.Handshake)
+		if err != nil {
+			return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - %w", err)
+		}
+	}
+
+	if len(signedMessages) == 3 {
+		return constants.MessageTypeListener, identityCard.SSOID, "", fmt.Errorf("verification/Incomplete - The encrypted datum was not acknowledged: %w", ErrIncomplete)
+	}
+
+	// The acknowledgement is signed by the requester and contains the response
+	err = signedMessages[3].VerifySignature(&request.PublicKey)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - Could not verify the acknowledgement: %w", err)
+	}
+
+	acknowledged, id, err := getAcknowledgementContent(signedMessages[3], identityKey, messageCodec)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Incomplete - %w", err)
+	} else if id != 0 || !bytes.Equal(acknowledged, signedMessages[2].Content) {
+		return constants.MessageTypeFailure, "", "", errors.New("verification/Incomplete - The acknowledgement is not the one of the response")
+	}
+
+	return constants.MessageTypeListener, identityCard.SSOID, "", fmt.Errorf("verification/Incomplete - The encrypted datum was acknowledged: %w", ErrIncomplete)
+}
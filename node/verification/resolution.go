@@ -0,0 +1,41 @@
+package verification
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"node/arbiter"
+	"node/constants"
+	"node/p2p"
+	"node/storage"
+)
+
+// Resolved verifies a transcript that was resolved by the arbiter. Like Exchange, it returns the type of the party
+// that recorded it, which is always the requester, the SSOID of the listener and the datum released by the arbiter.
+// If the arbiter found the escrow invalid, the recorder and SSOID are still set.
+func Resolved(signedMessages []p2p.SignedMessage, conversationPublicKey *rsa.PublicKey, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey) (constants.MessageType, string, string, error) {
+	transcript, resolution, decrypted, err := arbiter.VerifyResolution(signedMessages, parameters, revoloriPublicKey)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Resolved - %w", err)
+	}
+
+	// The stored conversation key has to be the one of the request
+	pseudonym, err := storage.GeneratePseudonym(&transcript.Request.PublicKey)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Resolved - %w", err)
+	}
+
+	storedPseudonym, err := storage.GeneratePseudonym(conversationPublicKey)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Resolved - %w", err)
+	} else if pseudonym != storedPseudonym {
+		return constants.MessageTypeFailure, "", "", errors.New("verification/Resolved - The transcript was not stored by the requester")
+	}
+
+	if resolution.Verdict != constants.ArbiterVerdictReleased {
+		return constants.MessageTypeRequester, transcript.Listener.SSOID, "", fmt.Errorf("verification/Resolved - The arbiter found the listener's escrow invalid: %s", resolution.Reason)
+	}
+
+	return constants.MessageTypeRequester, transcript.Listener.SSOID, decrypted, nil
+}
@@ -0,0 +1,176 @@
+package verification
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"node/codec"
+	"node/constants"
+	nP "node/nonRepudiation"
+	"node/p2p"
+)
+
+// Exchange verifies a stored exchange. It returns the type of the party that recorded it, the SSOID of the other
+// party and the decrypted datum. If only the success could not be verified, the recorder and SSOID are still set. If
+// the listener announced its rounds, the real decryption data has to be sent in a round they allow. The rounds are the
+// ones signed in the listener's response.
+func Exchange(signedMessages []p2p.SignedMessage, conversationPublicKey *rsa.PublicKey, identityKey *rsa.PublicKey, parameters p2p.SessionParameters, revoloriPublicKey *rsa.PublicKey) (constants.MessageType, string, string, error) {
+	if len(signedMessages) < 3 {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Exchange - Expected at least 3 messages, got %d", len(signedMessages))
+	}
+
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", err
+	}
+
+	firstMessage, identityCard, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], revoloriPublicKey, messageCodec)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", err
+	}
+
+	// The first message of the other party is signed together with the hash of the Hellos
+	err = parameters.CheckHandshakeHash(firstMessage.Handshake)
+	if err != nil {
+		return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Exchange - %w", err)
+	}
+
+	/**	Since the *receiving* party stores the first message the types are switched **/
+	if firstMessage.Type == constants.MessageTypeListener {
+		rounds, errRounds := parameters.SignedRounds(&firstMessage)
+		if errRounds != nil {
+			return constants.MessageTypeFailure, "", "", fmt.Errorf("verification/Exchange - %w", errRounds)
+		}
+
+		decrypted, errVerify := RequesterSuccess(signedMessages[2:], &firstMessage.PublicKey, firstMessage.Datum, messageCodec, &rounds)
+		return constants.MessageTypeRequester, identityCard.SSOID, decrypted, errVerify
+	}
+
+	decrypted, err := ListenerSuccess(signedMessages[2:], &firstMessage.PublicKey, conversationPublicKey, identityKey, messageCodec, &parameters)
+	return constants.MessageTypeListener, identityCard.SSOID, decrypted, err
+}
+
+// ListenerSuccess verifies the messages after the first message of an exchange recorded by the listener. The rounds
+// are read from the listener's response that the requester acknowledged.
+func ListenerSuccess(signedMessages []p2p.SignedMessage, signingKey *rsa.PublicKey, conversationSigningKey *rsa.PublicKey, identityKey *rsa.PublicKey, messageCodec codec.Codec, parameters *p2p.SessionParameters) (string, error) {
+	if len(signedMessages) != 2 {
+		return "", fmt.Errorf("verification/ListenerSuccess - Expected 2 signed message, got %d", len(signedMessages))
+	}
+
+	// Verify signatures
+	for i, message := range signedMessages {
+		err := message.VerifySignature(signingKey)
+		if err != nil {
+			return "", fmt.Errorf("verification/ListenerSuccess - Could not verify the signature for message %d: %w", i, err)
+		}
+	}
+
+	var firstMessage p2p.FirstMessage
+	var data nP.Data
+
+	// Get encrypted values
+	firstMsgBytes, _, err := getAcknowledgementContent(signedMessages[0], identityKey, messageCodec)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - Error on message 0: %w", err)
+	}
+
+	err = messageCodec.Unmarshal(firstMsgBytes, &firstMessage)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - Could not unmarshal first message: %w", err)
+	}
+	encryptedData := firstMessage.Datum
+
+	err = parameters.CheckHandshakeHash(firstMessage.Handshake)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - %w", err)
+	}
+
+	rounds, err := parameters.SignedRounds(&firstMessage)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - %w", err)
+	}
+
+	// Get decryption values
+	dataBytes, round, err := getAcknowledgementContent(signedMessages[1], conversationSigningKey, messageCodec)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - Error on message 1: %w", err)
+	}
+
+	err = messageCodec.Unmarshal(dataBytes, &data)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - Could not unmarshal data: %w", err)
+	}
+
+	// The requester acknowledged the round the data was sent in
+	if rounds.IsAnnounced() && data.Round != round {
+		return "", fmt.Errorf("verification/ListenerSuccess - The decryption data of round %d is marked as round %d", round, data.Round)
+	}
+
+	err = rounds.CheckLastRound(round)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - %w", err)
+	}
+
+	// Check if the data can be decrypted
+	decrypted, err := nP.DecryptMessage(&data, encryptedData)
+	if err != nil {
+		return "", fmt.Errorf("verification/ListenerSuccess - Could not decrypt data: %w", err)
+	}
+
+	return decrypted, nil
+}
+
+// RequesterSuccess verifies the message after the first message of an exchange recorded by the requester.
+func RequesterSuccess(signedMessages []p2p.SignedMessage, signingKey *rsa.PublicKey, encryptedDatum string, messageCodec codec.Codec, rounds *nP.Rounds) (string, error) {
+	if len(signedMessages) != 1 {
+		return "", fmt.Errorf("verification/RequesterSuccess - Expected 1 signed message, got %d", len(signedMessages))
+	}
+	signedMessage := signedMessages[0]
+
+	err := signedMessage.VerifySignature(signingKey)
+	if err != nil {
+		return "", fmt.Errorf("verification/RequesterSuccess - Could not verify signature: %w", err)
+	}
+
+	var decryptionData nP.Data
+	err = messageCodec.Unmarshal(signedMessage.Content, &decryptionData)
+	if err != nil {
+		return "", fmt.Errorf("verification/RequesterSuccess - Could not unmarshal decryption data: %w", err)
+	}
+
+	err = rounds.CheckLastRound(decryptionData.Round)
+	if err != nil {
+		return "", fmt.Errorf("verification/RequesterSuccess - %w", err)
+	}
+
+	decrypted, err := nP.DecryptMessage(&decryptionData, encryptedDatum)
+	if err != nil {
+		return "", fmt.Errorf("verification/RequesterSuccess - Could not decrypt message: %w", err)
+	}
+
+	return decrypted, nil
+}
+
+// getAcknowledgementContent returns the content of the acknowledged message and the ID of the acknowledgement.
+func getAcknowledgementContent(message p2p.SignedMessage, signingKey *rsa.PublicKey, messageCodec codec.Codec) ([]byte, int, error) {
+	var ack p2p.Acknowledgement
+	var signedMsg p2p.SignedMessage
+
+	err := messageCodec.Unmarshal(message.Content, &ack)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not unmarshal acknowledgement: %w", err)
+	}
+
+	err = messageCodec.Unmarshal(ack.Content, &signedMsg)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not unmarshal signed message: %w", err)
+	}
+
+	err = signedMsg.VerifySignature(signingKey)
+	if err != nil {
+		return nil, 0, errors.New("could not verify signature")
+	}
+
+	return signedMsg.Content, ack.ID, nil
+}
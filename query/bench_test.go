@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"node/constants"
 	"node/logging"
 	"node/storage"
 )
@@ -26,9 +27,16 @@ func BenchmarkQueryEntireBlockchain(b *testing.B) {
 }
 
 func BenchmarkQueryEntireSQLiteDB(b *testing.B) {
+	store := _openSQLiteStore(b)
+	defer store.Close()
+
 	for i := 0; i < b.N; i++ {
 		start := time.Now()
-		allLogs, err := storage.QueryAllLogsFromSQLite()
+		allLogs := 0
+		err := store.QueryAll(func(storage.BlockchainPayload) error {
+			allLogs++
+			return nil
+		})
 		duration := time.Since(start)
 
 		if err != nil {
@@ -36,7 +44,7 @@ func BenchmarkQueryEntireSQLiteDB(b *testing.B) {
 			b.Errorf("Could not query logs from SQLite DB: %s", err)
 		}
 
-		log.Info.Printf("Reading from SQLite DB\n\tEntries: %d\n\tDuration: %v\n", len(allLogs), duration)
+		log.Info.Printf("Reading from SQLite DB\n\tEntries: %d\n\tDuration: %v\n", allLogs, duration)
 	}
 }
 
@@ -62,6 +70,9 @@ func BenchmarkSearchSingleLogBlockchain(b *testing.B) {
 }
 
 func BenchmarkSearchSingleLogSQLite(b *testing.B) {
+	store := _openSQLiteStore(b)
+	defer store.Close()
+
 	for i := 0; i < b.N; i++ {
 		pseudonym, err := _getRandomPseudonym()
 		if err != nil {
@@ -70,7 +81,7 @@ func BenchmarkSearchSingleLogSQLite(b *testing.B) {
 		}
 
 		start := time.Now()
-		_, err = storage.QuerySingleLogFromSQLite(pseudonym)
+		_, err = store.QueryByPseudonym(pseudonym)
 		duration := time.Since(start)
 
 		if err != nil {
@@ -82,9 +93,19 @@ func BenchmarkSearchSingleLogSQLite(b *testing.B) {
 	}
 }
 
-// helper function
+// helper functions
+func _openSQLiteStore(b *testing.B) *storage.SQLiteStore {
+	store, err := storage.NewSQLiteStore(constants.SQLitePath)
+	if err != nil {
+		b.Fatalf("Could not open SQLite DB: %s", err)
+	}
+
+	return store
+}
+
 func _getRandomPseudonym() (string, error) {
-	entries, err := getAllLogs("/build/measureStorage/storage/")
+	// The exchanges were stored by measureStorage, which uses a fixed passphrase
+	entries, err := getAllLogs("/build/measureStorage/storage/", []byte("measureStorage"))
 	if err != nil {
 		return "", err
 	}
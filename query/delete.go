@@ -4,7 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
+
+	"node/storage"
 )
 
 func DeleteLog(directories []string, pseudonym string) error {
@@ -12,19 +13,17 @@ func DeleteLog(directories []string, pseudonym string) error {
 	for _, directory := range directories {
 		fmt.Printf("Searching in directory: %s\n", directory)
 
-		files, err := os.ReadDir(directory)
+		matches, err := storage.FindExchanges(directory, pseudonym)
 		if err != nil {
-			return fmt.Errorf("DeleteLog - Could not read directory: %w", err)
+			return fmt.Errorf("DeleteLog - %w", err)
 		}
 
-		for _, file := range files {
-			if strings.HasSuffix(file.Name(), "-"+pseudonym+".json") {
-				foundFile = true
+		for _, match := range matches {
+			foundFile = true
 
-				err = os.Remove(directory + file.Name())
-				if err != nil {
-					return fmt.Errorf("DeleteLog - Could not delete the log: %w", err)
-				}
+			err = os.Remove(match)
+			if err != nil {
+				return fmt.Errorf("DeleteLog - Could not delete the log: %w", err)
 			}
 		}
 	}
@@ -36,3 +35,18 @@ func DeleteLog(directories []string, pseudonym string) error {
 	fmt.Printf("Deleted the requested log\n")
 	return nil
 }
+
+// DeleteFromStore removes the usage log from the store. Stores that cannot delete logs keep it; without the proof, it
+// can no longer be linked to the exchange.
+func DeleteFromStore(store storage.UsageLogStore, pseudonym string) error {
+	err := store.Delete(pseudonym)
+	if errors.Is(err, storage.ErrNotSupported) {
+		fmt.Printf("The %s store cannot delete logs. Only the proof was deleted\n", store.Name())
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("DeleteFromStore - Could not delete the log: %w", err)
+	}
+
+	fmt.Printf("Deleted the log from the %s store\n", store.Name())
+	return nil
+}
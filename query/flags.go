@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"node/constants"
+	"node/storage"
 )
 
 const invalidParamExitCode = 64
@@ -13,6 +16,12 @@ type flagConfig struct {
 	pseudonym           string
 	updateJustification string
 	updateDatum         string
+	store               string
+	sqlitePath          string
+	gethAddress         string
+	batchDirectory      string
+	registry            string
+	indexPath           string
 
 	delete       bool
 	searchAll    bool
@@ -46,6 +55,12 @@ func parseFlags() (flagConfig, []string) {
 	flag.StringVar(&config.pseudonym, "pseudonym", "", "The pseudonym to be deleted, searched or updated")
 	flag.StringVar(&config.updateJustification, "updateJustification", "", "The updated justification")
 	flag.StringVar(&config.updateDatum, "updateDatum", "", "The updated datum")
+	flag.StringVar(&config.store, "store", storage.StoreBlockchain, "The store the usage logs are read from: 'sqlite', 'blockchain', 'blockchain-batch' or 'registry'. Defaults to 'blockchain'")
+	flag.StringVar(&config.sqlitePath, "sqlitePath", constants.SQLitePath, "Path of the SQLite database used by the 'sqlite' store. Defaults to 'database.db' in the working directory")
+	flag.StringVar(&config.gethAddress, "gethAddress", constants.GethAddress, "JSON-RPC endpoint of the geth node the usage logs are sent to and read from. Defaults to '"+constants.GethAddress+"'")
+	flag.StringVar(&config.batchDirectory, "batchDirectory", constants.BatchDirectory, "Directory of the usage logs and inclusion proofs used by the 'blockchain-batch' store. Defaults to './batches/'")
+	flag.StringVar(&config.registry, "registry", "", "Address of the registry contract used by the 'registry' store")
+	flag.StringVar(&config.indexPath, "indexPath", constants.BlockchainIndexPath, "Path of the index of the 'blockchain' store. It is created if it does not exist. Defaults to 'blockchainIndex.db' in the working directory")
 	flag.Parse()
 	directories := flag.Args()
 
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 
+	"node/codec"
 	"node/constants"
 	"node/revolori"
 	"node/storage"
@@ -39,23 +40,24 @@ func LogInfo(directories []string) {
 				continue
 			}
 
-			signedMessages, conversationPrivateKey, _, err := storage.LoadExchange(directory + file.Name())
+			signedMessages, conversationPublicKey, _, parameters, err := storage.LoadExchangeProof(directory + file.Name())
 			if err != nil {
 				log.Printf("logInfo: Could not load exchange for '%s': %v\n", directory+file.Name(), err)
 			}
 
-			myPseudonym, err := storage.GeneratePseudonym(&conversationPrivateKey.PublicKey)
+			messageCodec, err := codec.Get(parameters.Codec)
 			if err != nil {
-				log.Printf("\tCould not generate my pseudonym for '%s': %v => Skipping it\n", file.Name(), err)
+				log.Printf("\tCould not get the codec of '%s': %v => Skipping it\n", file.Name(), err)
 				continue
 			}
 
-			if !strings.HasSuffix(file.Name(), "-"+myPseudonym+".json") {
-				log.Printf("logInfo - File '%s' does not have the expected suffix of '%s' => Skipped it\n", file.Name(), myPseudonym)
+			myPseudonym, err := storage.GeneratePseudonym(&conversationPublicKey)
+			if err != nil {
+				log.Printf("\tCould not generate my pseudonym for '%s': %v => Skipping it\n", file.Name(), err)
 				continue
 			}
 
-			firstMessage, identityCard, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], &revoloriPublicKey)
+			firstMessage, identityCard, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], &revoloriPublicKey, messageCodec)
 			if err != nil {
 				log.Fatalf("logInfo - Could not extract the first message: %v\n", err)
 			}
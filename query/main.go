@@ -1,31 +1,70 @@
 package main
 
-import "log"
+import (
+	"log"
+
+	"node/constants"
+	"node/storage"
+)
 
 func main() {
 	config, directories := parseFlags()
+	storage.SetGethAddress(config.gethAddress)
+
+	// The query only appends to the blockchain when updating a log
+	options := storage.DefaultStoreOptions()
+	options.SQLitePath = config.sqlitePath
+	options.BatchDirectory = config.batchDirectory
+	options.RegistryAddress = config.registry
+	options.IndexPath = config.indexPath
+	// An updated log is anchored without waiting for other logs
+	options.BatchSize = 1
+
+	store, err := storage.NewUsageLogStore(config.store, options)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Keeps the index up to date while the logs are decrypted
+	stop := make(chan struct{})
+	defer close(stop)
+	err = storage.FollowIndexes([]storage.UsageLogStore{store}, constants.IndexFollowInterval, stop)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	if config.delete {
-		err := DeleteLog(directories, config.pseudonym)
+		err = DeleteLog(directories, config.pseudonym)
 		if err != nil {
 			log.Fatalln(err)
 		}
 
-		return
-	}
+		err = DeleteFromStore(store, config.pseudonym)
+		if err != nil {
+			log.Fatalln(err)
+		}
 
-	if config.searchAll {
-		SearchAllLogs(directories)
 		return
 	}
 
-	if config.searchSingle {
-		SearchSingleLog(directories, config.pseudonym)
+	if config.searchAll || config.searchSingle {
+		// Only decrypting the usage logs requires the private keys of the stored exchanges
+		passphrase, err := storage.ProofPassphrase()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.searchAll {
+			SearchAllLogs(directories, store, passphrase)
+		} else {
+			SearchSingleLog(directories, store, passphrase, config.pseudonym)
+		}
+
 		return
 	}
 
 	if config.update {
-		UpdateLog(directories, config.pseudonym, config.updateJustification, config.updateDatum)
+		UpdateLog(directories, store, config.pseudonym, config.updateJustification, config.updateDatum)
 		return
 	}
 
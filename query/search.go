@@ -16,12 +16,12 @@ type entry struct {
 	PrivateKey rsa.PrivateKey
 }
 
-func SearchAllLogs(directories []string) {
+func SearchAllLogs(directories []string, store storage.UsageLogStore, passphrase []byte) {
 	allEntries := make([]entry, 0)
 	for _, directory := range directories {
 		fmt.Printf("Searching in directory: %s\n", directory)
 
-		entries, err := getAllLogs(directory)
+		entries, err := getAllLogs(directory, passphrase)
 		if err != nil {
 			log.Fatalf("An error occurred: %v\n", err)
 		}
@@ -48,41 +48,52 @@ func SearchAllLogs(directories []string) {
 		fmt.Printf("I have %d unique logs\n", len(allEntries))
 	}
 
-	allLogs, err := storage.QueryAllLogs()
-	if err != nil {
-		log.Fatalf("Could not query logs: %s", err)
+	entriesByPseudonym := make(map[string]entry)
+	for _, entry := range allEntries {
+		entriesByPseudonym[entry.Pseudonym] = entry
 	}
 
 	myLogs := make(map[string]storage.UsageLogContent)
-	for _, entry := range allEntries {
-		for _, singleLog := range allLogs {
-			if singleLog.PseudonymConsumer == entry.Pseudonym || singleLog.PseudonymOwner == entry.Pseudonym {
-				// Attempt to decrypt the log
-				decrypted, consumerErr, ownerErr := decryptLog(singleLog, &entry.PrivateKey)
-				if consumerErr == nil && ownerErr == nil {
-					myLogs[entry.Pseudonym] = decrypted
-					break
-				}
-
-				// Could not decrypt log => Print error
-				fmt.Printf("! Could not decrypt log with pseudonym %s\n\tConsumer error: %s\n\tOwner error: %s\n\tLog: %v\n", entry.Pseudonym, consumerErr, ownerErr, singleLog)
-
-				// There is a maximum of one log with any given pseudonym
-				// Thus we can stop our search here
-				break
+	err := store.QueryAll(func(singleLog storage.BlockchainPayload) error {
+		for _, pseudonym := range []string{singleLog.PseudonymConsumer, singleLog.PseudonymOwner} {
+			entry, ok := entriesByPseudonym[pseudonym]
+			if !ok {
+				continue
+			}
+
+			// There is a maximum of one log with any given pseudonym
+			if _, found := myLogs[pseudonym]; found {
+				continue
+			}
+
+			// Attempt to decrypt the log
+			decrypted, consumerErr, ownerErr := decryptLog(singleLog, &entry.PrivateKey)
+			if consumerErr == nil && ownerErr == nil {
+				myLogs[pseudonym] = decrypted
+				continue
 			}
+
+			// Could not decrypt log => Print error
+			fmt.Printf("! Could not decrypt log with pseudonym %s\n\tConsumer error: %s\n\tOwner error: %s\n\tLog: %v\n", pseudonym, consumerErr, ownerErr, singleLog)
 		}
 
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Could not query logs: %s", err)
+	}
+
+	for _, entry := range allEntries {
 		_, ok := myLogs[entry.Pseudonym]
 		if !ok {
-			fmt.Printf("! Could not find a uage log for pseudonym '%s' in the blockchain!\n", entry.Pseudonym)
+			fmt.Printf("! Could not find a uage log for pseudonym '%s' in the %s store!\n", entry.Pseudonym, store.Name())
 		}
 	}
 
 	printUsageLogs(myLogs)
 }
 
-func SearchSingleLog(directories []string, pseudonym string) {
+func SearchSingleLog(directories []string, store storage.UsageLogStore, passphrase []byte, pseudonym string) {
 	foundEntry := entry{
 		Pseudonym: "",
 	}
@@ -90,7 +101,7 @@ func SearchSingleLog(directories []string, pseudonym string) {
 	for _, directory := range directories {
 		fmt.Printf("Searching in directory: %s\n", directory)
 
-		entries, err := getAllLogs(directory)
+		entries, err := getAllLogs(directory, passphrase)
 		if err != nil {
 			log.Fatalf("An error occurred: %s\n", err)
 		}
@@ -114,26 +125,21 @@ func SearchSingleLog(directories []string, pseudonym string) {
 	}
 
 	fmt.Printf("Found the P3 log entry\n")
-	allLogs, err := storage.QueryAllLogs()
+	singleLog, err := store.QueryByPseudonym(pseudonym)
 	if err != nil {
-		log.Fatalf("Could not query logs: %s", err)
+		log.Fatalf("Could not query log: %s", err)
 	}
 
-	for _, singleLog := range allLogs {
-		if singleLog.PseudonymConsumer == pseudonym || singleLog.PseudonymOwner == pseudonym {
-			// Attempt to decrypt the log
-			decrypted, consumerErr, ownerErr := decryptLog(singleLog, &foundEntry.PrivateKey)
-			if consumerErr == nil && ownerErr == nil {
-				printUsageLogs(map[string]storage.UsageLogContent{
-					foundEntry.Pseudonym: decrypted,
-				})
-				break
-			}
-
-			// Could not decrypt log => Print error
-			log.Fatalf("! Could not decrypt log with pseudonym %s\n\tConsumer error: %s\n\tOwner error: %s\n\tLog: %v\n", foundEntry.Pseudonym, consumerErr, ownerErr, singleLog)
-		}
+	// Attempt to decrypt the log
+	decrypted, consumerErr, ownerErr := decryptLog(singleLog, &foundEntry.PrivateKey)
+	if consumerErr != nil || ownerErr != nil {
+		// Could not decrypt log => Print error
+		log.Fatalf("! Could not decrypt log with pseudonym %s\n\tConsumer error: %s\n\tOwner error: %s\n\tLog: %v\n", foundEntry.Pseudonym, consumerErr, ownerErr, singleLog)
 	}
+
+	printUsageLogs(map[string]storage.UsageLogContent{
+		foundEntry.Pseudonym: decrypted,
+	})
 }
 
 func printUsageLogs(logs map[string]storage.UsageLogContent) {
@@ -230,24 +236,10 @@ func decryptLog(singleLog storage.BlockchainPayload, privateKey *rsa.PrivateKey)
 }
 
 func decryptUsageLogContent(usageLog *storage.UsageLogContent, privateKey *rsa.PrivateKey) (storage.UsageLogContent, error) {
-	decryptedJustification, err := storage.PublicKeyDecryption(usageLog.Justification, privateKey)
-	if err != nil {
-		return storage.UsageLogContent{}, err
-	}
-
-	decryptedDatum, err := storage.PublicKeyDecryption(usageLog.DatumRequest, privateKey)
-	if err != nil {
-		return storage.UsageLogContent{}, err
-	}
-
-	return storage.UsageLogContent{
-		Justification: string(decryptedJustification),
-		DatumRequest:  string(decryptedDatum),
-		Timestamp:     usageLog.Timestamp,
-	}, nil
+	return usageLog.Decrypt(privateKey)
 }
 
-func getAllLogs(path string) ([]entry, error) {
+func getAllLogs(path string, passphrase []byte) ([]entry, error) {
 	files, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
@@ -265,7 +257,7 @@ func getAllLogs(path string) ([]entry, error) {
 			continue
 		}
 
-		_, conversationPrivateKey, _, err := storage.LoadExchange(path + file.Name())
+		_, conversationPrivateKey, _, _, err := storage.LoadExchange(path+file.Name(), passphrase)
 		if err != nil {
 			log.Printf("getAllLogs - Could not load exchange '%s' because '%v' => Skipped it\n", file.Name(), err)
 			continue
@@ -277,11 +269,6 @@ func getAllLogs(path string) ([]entry, error) {
 			continue
 		}
 
-		if !strings.HasSuffix(file.Name(), "-"+pseudonym+".json") {
-			log.Printf("getAllLogs - File '%s' does not have the expected suffix of '%s' => Skipped it\n", file.Name(), pseudonym)
-			continue
-		}
-
 		entries = append(entries, entry{
 			Pseudonym:  pseudonym,
 			PrivateKey: conversationPrivateKey,
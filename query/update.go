@@ -5,23 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"strings"
 
+	"node/codec"
 	"node/constants"
 	"node/p2p"
 	"node/revolori"
 	"node/storage"
 )
 
-func UpdateLog(directories []string, pseudonym string, updatedJustification string, updatedDatum string) {
+func UpdateLog(directories []string, store storage.UsageLogStore, pseudonym string, updatedJustification string, updatedDatum string) {
 	var signedMessages []p2p.SignedMessage
-	var conversationPrivateKey rsa.PrivateKey
+	var conversationPublicKey rsa.PublicKey
 	var _ rsa.PublicKey
+	var parameters p2p.SessionParameters
 	var err error
 
 	for _, directory := range directories {
-		signedMessages, conversationPrivateKey, _, err = query(directory, pseudonym)
+		signedMessages, conversationPublicKey, _, parameters, err = query(directory, pseudonym)
 		if err == nil {
 			break
 		}
@@ -36,7 +36,12 @@ func UpdateLog(directories []string, pseudonym string, updatedJustification stri
 		log.Fatalf("UpdateLog - Could not get Revolori's key: %v\n", err)
 	}
 
-	firstMessage, _, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], &revoloriPublicKey)
+	messageCodec, err := codec.Get(parameters.Codec)
+	if err != nil {
+		log.Fatalf("UpdateLog - %v\n", err)
+	}
+
+	firstMessage, _, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], &revoloriPublicKey, messageCodec)
 	if err != nil {
 		log.Fatalf("UpdateLog - Could not extract the first message: %v\n", err)
 	}
@@ -48,17 +53,27 @@ func UpdateLog(directories []string, pseudonym string, updatedJustification stri
 		// I am the requester
 		fmt.Printf("I am the requester\n")
 		ownerKey = firstMessage.PublicKey
-		consumerKey = conversationPrivateKey.PublicKey
+		consumerKey = conversationPublicKey
 	} else {
 		// I am the listener
 		fmt.Printf("I am the listener\n")
-		ownerKey = conversationPrivateKey.PublicKey
+		ownerKey = conversationPublicKey
 		consumerKey = firstMessage.PublicKey
 	}
 
-	_, err = storage.ExportToBlockchain(updatedJustification, updatedDatum, &ownerKey, &consumerKey)
+	updatedLog, err := storage.NewUsageLog(updatedJustification, updatedDatum, &ownerKey, &consumerKey)
 	if err != nil {
-		log.Fatalf("UpdateLog - Could not create updated block: %v\n", err)
+		log.Fatalf("UpdateLog - Could not create updated log: %v\n", err)
+	}
+
+	// Append-only stores get the updated log in addition to the old one
+	err = store.Update(pseudonym, updatedLog)
+	if errors.Is(err, storage.ErrNotSupported) {
+		err = store.Append(updatedLog)
+	}
+
+	if err != nil {
+		log.Fatalf("UpdateLog - Could not store updated log: %v\n", err)
 	}
 
 	err = DeleteLog(directories, pseudonym)
@@ -67,17 +82,15 @@ func UpdateLog(directories []string, pseudonym string, updatedJustification stri
 	}
 }
 
-func query(path string, pseudonym string) ([]p2p.SignedMessage, rsa.PrivateKey, rsa.PublicKey, error) {
-	files, err := os.ReadDir(path)
+func query(path string, pseudonym string) ([]p2p.SignedMessage, rsa.PublicKey, rsa.PublicKey, p2p.SessionParameters, error) {
+	matches, err := storage.FindExchanges(path, pseudonym)
 	if err != nil {
-		return nil, rsa.PrivateKey{}, rsa.PublicKey{}, err
+		return nil, rsa.PublicKey{}, rsa.PublicKey{}, p2p.SessionParameters{}, err
 	}
 
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), "-"+pseudonym+".json") {
-			return storage.LoadExchange(path + file.Name())
-		}
+	if len(matches) > 0 {
+		return storage.LoadExchangeProof(matches[0])
 	}
 
-	return nil, rsa.PrivateKey{}, rsa.PublicKey{}, errors.New("no match was found")
+	return nil, rsa.PublicKey{}, rsa.PublicKey{}, p2p.SessionParameters{}, errors.New("no match was found")
 }
@@ -1,38 +1,120 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/host"
 	libPeer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
 	"node/constants"
+	"node/exchange"
 	log "node/logging"
 	"node/p2p"
 )
 
+// createNode creates the host and the requester that runs the exchanges on it. The returned result carries the
+// durations of both steps.
 // Adapted from https://github.com/libp2p/go-libp2p/tree/v0.16.0/examples/chat-with-mdns
-func createNode(config *configuration, peerStart *time.Time) {
+func createNode(config *configuration, options exchange.RequesterOptions) (host.Host, exchange.Result) {
 	start := time.Now()
-	signedIdentityCard, err := p2p.LoadSignedIdentityCard(&globalPrivateKey)
+
+	hostKey, err := p2p.LoadOrCreateHostKey(config.hostKey, config.hostKeyType)
 	if err != nil {
 		log.Error.Fatalln(err)
 	}
-	loadIdDuration := time.Since(start)
-
-	start = time.Now()
-	ctx := context.Background()
 
-	h, err := p2p.MakeHost(config.port)
+	h, err := p2p.MakeHost(config.port, hostKey)
 	if err != nil {
 		log.Error.Fatalln(err)
 	}
 	peerCreationDuration := time.Since(start)
 
+	start = time.Now()
+	options.IdentityCards, err = p2p.LoadSignedIdentityCards(&globalPrivateKey, h.ID())
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	loadIdDuration := time.Since(start)
+
+	exchangeRequester = exchange.NewRequester(options)
+
+	return h, exchange.Result{
+		LoadIDCardDuration:   loadIdDuration,
+		HostCreationDuration: peerCreationDuration,
+	}
+}
+
+// searchListener searches for the listener and opens a stream to every peer that is found. The streams are handled by
+// exchangeRequester, which reports the result.
+func searchListener(config *configuration, h host.Host, ret exchange.Result, peerStart time.Time) {
+	ctx := context.Background()
+	var err error
+
 	log.Info.Println("Starting search")
 	peerSearchStart := time.Now()
 
-	peerChan := p2p.InitMDNS(h)
+	var dhtDiscovery *p2p.DHTDiscovery
+	if config.discovery != constants.DiscoveryMDNS {
+		dhtDiscovery, err = joinDHT(ctx, h, config)
+		if err != nil {
+			log.Error.Fatalln(err)
+		}
+	}
+
+	// Search every peer or, with a deployment key, only the peers that publish the listener's rendezvous token. Fake
+	// chatter still contacts every peer as cover traffic.
+	search := func(searchCtx context.Context) (chan libPeer.AddrInfo, error) {
+		return p2p.Discover(searchCtx, h, config.discovery, dhtDiscovery)
+	}
+
+	if deploymentKey, ok := p2p.DeploymentKeyFromEnv(); ok {
+		token := p2p.RendezvousToken(deploymentKey, config.ssoid)
+		search = func(searchCtx context.Context) (chan libPeer.AddrInfo, error) {
+			found, err := p2p.FindToken(searchCtx, h, config.discovery, dhtDiscovery, token)
+			if err != nil || !config.enableFakeChatter {
+				return found, err
+			}
+
+			cover, err := p2p.Discover(searchCtx, h, config.discovery, dhtDiscovery)
+			if err != nil {
+				return nil, err
+			}
+
+			return p2p.MergePeerChans(searchCtx, found, cover), nil
+		}
+	}
+
+	// Either search via mDNS and/or the DHT or dial the listener directly. Every restart cancels the previous search.
+	cancelSearch := func() {}
+	discover := func() chan libPeer.AddrInfo {
+		cancelSearch()
+
+		var searchCtx context.Context
+		searchCtx, cancelSearch = context.WithCancel(ctx)
+
+		peerChan, err := search(searchCtx)
+		if err != nil {
+			log.Error.Fatalln(err)
+		}
+
+		return peerChan
+	}
+
+	if config.peer != "" {
+		target, err := resolveTarget(config)
+		if err != nil {
+			log.Error.Fatalln(err)
+		}
+
+		log.Info.Printf("Dialing %s directly\n", target.ID)
+		discover = func() chan libPeer.AddrInfo {
+			return dialDirectly(h, target, config.enableFakeChatter)
+		}
+	}
+
+	peerChan := discover()
 	endTime := time.Now().Add(maxSearchTime)
 	resetCount := 0
 
@@ -41,20 +123,17 @@ func createNode(config *configuration, peerStart *time.Time) {
 		select {
 		case tmp := <-peerChan:
 			peer = tmp
-		case <-exchangeFailed:
+		case <-exchangeRequester.Failed():
 			// The correct peet was found but the exchange failed for some reason. Thus, we restart the search.
 			// Since go has no fallthrough for select stmts, the code from the time-out case was copied here
 			if resetCount >= maxRetries {
 				log.Error.Printf("Did not find peer even after retrying for %d times\n", resetCount)
-				realDone <- returnValue{
-					success: false,
-					value:   "",
-				}
+				exchangeRequester.Finish(exchange.Result{})
 
 				break
 			}
 
-			peerChan = p2p.InitMDNS(h)
+			peerChan = discover()
 			endTime = time.Now().Add(maxSearchTime)
 			resetCount++
 			log.Info.Printf("Exchange failed. Restarting the search process for the %d. time\n", resetCount)
@@ -68,15 +147,12 @@ func createNode(config *configuration, peerStart *time.Time) {
 			// Thus, we restart the search and the timeout duration for a total of three times.
 			if resetCount >= maxRetries {
 				log.Error.Printf("Did not find peer even after retrying for %d times\n", resetCount)
-				realDone <- returnValue{
-					success: false,
-					value:   "",
-				}
+				exchangeRequester.Finish(exchange.Result{})
 
 				break
 			}
 
-			peerChan = p2p.InitMDNS(h)
+			peerChan = discover()
 			endTime = time.Now().Add(maxSearchTime)
 			resetCount++
 			log.Info.Printf("Did not find peer. Restarting the search process for the %d. time\n", resetCount)
@@ -104,14 +180,60 @@ func createNode(config *configuration, peerStart *time.Time) {
 
 			log.Info.Printf("Stream open failed: %s", err)
 		} else {
-			rw := bufio.NewReadWriter(bufio.NewReader(stream), bufio.NewWriter(stream))
-			ret := returnValue{
-				loadIDCardDuration:   loadIdDuration,
-				hostCreationDuration: peerCreationDuration,
-				searchRestarts:       resetCount,
+			rw := p2p.NewReadWriter(stream, string(stream.Protocol()))
+			ret.SearchRestarts = resetCount
+
+			remote := libPeer.AddrInfo{
+				ID:    stream.Conn().RemotePeer(),
+				Addrs: []multiaddr.Multiaddr{stream.Conn().RemoteMultiaddr()},
 			}
 
-			go streamHandler(rw, config, &signedIdentityCard, peerStart, &peerSearchStart, &ret)
+			go exchangeRequester.HandleStream(rw, remote, peerStart, peerSearchStart, ret)
+		}
+	}
+}
+
+// joinDHT joins the DHT via the configured bootstrap peers.
+func joinDHT(ctx context.Context, h host.Host, config *configuration) (*p2p.DHTDiscovery, error) {
+	bootstrapPeers, err := p2p.ParseBootstrapPeers(config.bootstrap)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bootstrapPeers) == 0 {
+		return nil, fmt.Errorf("requester/joinDHT - DHT discovery requires -bootstrap")
+	}
+
+	log.Info.Printf("Searching the DHT under '%s'\n", config.rendezvous)
+	return p2p.NewDHTDiscovery(ctx, h, bootstrapPeers, config.rendezvous)
+}
+
+// resolveTarget returns the listener that should be dialed directly.
+func resolveTarget(config *configuration) (libPeer.AddrInfo, error) {
+	if config.peer == "book" {
+		target, ok := addressBook.Lookup(config.ssoid)
+		if !ok {
+			return libPeer.AddrInfo{}, fmt.Errorf("requester/resolveTarget - The address book has no entry for '%s'", config.ssoid)
 		}
+
+		return target, nil
 	}
+
+	return p2p.ParsePeer(config.peer, addressBook)
+}
+
+// dialDirectly returns a channel that yields the target. With fake chatter, the peers found via mDNS follow so that
+// the real exchange is still hidden among fake ones.
+func dialDirectly(h host.Host, target libPeer.AddrInfo, withFakeChatter bool) chan libPeer.AddrInfo {
+	if !withFakeChatter {
+		peerChan := make(chan libPeer.AddrInfo, 1)
+		peerChan <- target
+
+		return peerChan
+	}
+
+	peerChan := p2p.InitMDNS(h)
+	peerChan <- target
+
+	return peerChan
 }
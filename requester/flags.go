@@ -5,6 +5,7 @@ import (
 	"log"
 	"strings"
 
+	"node/constants"
 	ownLog "node/logging"
 )
 
@@ -16,6 +17,14 @@ type configuration struct {
 	enableFakeChatter bool
 	cpuProf           bool
 	memProf           bool
+	peer              string
+	addressBook       string
+	discovery         string
+	bootstrap         string
+	rendezvous        string
+	hostKey           string
+	hostKeyType       string
+	arbiterKey        string
 }
 
 func parseFlags() configuration {
@@ -23,11 +32,19 @@ func parseFlags() configuration {
 
 	flag.StringVar(&config.ssoid, "ssoid", "", "SSOID of the peer you wish to connect to")
 	flag.StringVar(&config.justification, "justification", "Requesting data", "Justification for data access, defaults to 'Requesting data'")
-	flag.StringVar(&config.requestedDatum, "datum", "No datum given", "Which data you wish to request. Since this is a PoC, the listener doesn't care what data is requested")
+	flag.StringVar(&config.requestedDatum, "datum", "No datum given", "Which data you wish to request. The listener refuses the request if it does not have the datum")
 	flag.IntVar(&config.port, "port", 41000, "Port to listen to, defaults to 41000")
 	flag.BoolVar(&config.enableFakeChatter, "fakeChatter", false, "Set to true to enable fake chatter")
 	flag.BoolVar(&config.cpuProf, "cpuProf", false, "Enable CPU profiling")
 	flag.BoolVar(&config.memProf, "memProf", false, "Enable memory profiling")
+	flag.StringVar(&config.peer, "peer", "", "Dial the listener directly instead of searching for it: a multiaddr ending in /p2p/<peer ID>, a peer ID from the address book or 'book' to use the address last seen for -ssoid")
+	flag.StringVar(&config.addressBook, "addressBook", constants.AddressBookPath, "Path of the address book that stores where listeners were last reached")
+	flag.StringVar(&config.discovery, "discovery", constants.DiscoveryMDNS, "How to search for the listener: 'mdns', 'dht' or 'both'. Defaults to 'mdns'")
+	flag.StringVar(&config.bootstrap, "bootstrap", "", "Comma-separated multiaddrs of the DHT bootstrap peers")
+	flag.StringVar(&config.rendezvous, "rendezvous", constants.DefaultRendezvousNamespace, "DHT namespace listeners advertise themselves under")
+	flag.StringVar(&config.hostKey, "hostKey", constants.HostKeyFilePath, "Path of the libp2p host key, which determines the peer ID. It is created if it does not exist")
+	flag.StringVar(&config.hostKeyType, "hostKeyType", constants.HostKeyRSA, "Type of a newly created host key: 'rsa' or 'ed25519'")
+	flag.StringVar(&config.arbiterKey, "arbiterKey", "", "Path of the arbiter's public key. If set, the listener is asked to escrow the decryption data with the arbiter")
 	flag.Parse()
 
 	config.ssoid = strings.TrimSpace(config.ssoid)
@@ -49,5 +66,15 @@ func parseFlags() configuration {
 		log.Fatalf("requester/parseFlags - Both profilings have been enabled\n")
 	}
 
+	if config.discovery != constants.DiscoveryMDNS && config.discovery != constants.DiscoveryDHT && config.discovery != constants.DiscoveryBoth {
+		ownLog.Error.Printf("requester/parseFlags - Unknown discovery mechanism '%s'\n", config.discovery)
+		log.Fatalf("requester/parseFlags - Unknown discovery mechanism '%s'\n", config.discovery)
+	}
+
+	if config.hostKeyType != constants.HostKeyRSA && config.hostKeyType != constants.HostKeyEd25519 {
+		ownLog.Error.Printf("requester/parseFlags - Unknown host key type '%s'\n", config.hostKeyType)
+		log.Fatalf("requester/parseFlags - Unknown host key type '%s'\n", config.hostKeyType)
+	}
+
 	return config
 }
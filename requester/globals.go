@@ -3,12 +3,14 @@ package main
 import (
 	"crypto/rsa"
 	"time"
+
+	"node/exchange"
+	"node/p2p"
 )
 
 const (
 	// Connection search time.
-	maxSearchTime          = 30 * time.Second // Avg. search time when using fake chatter is 15 seconds. Adding 15 seconds as overhead.
-	minFakeConnectionCount = 5
+	maxSearchTime = 30 * time.Second // Avg. search time when using fake chatter is 15 seconds. Adding 15 seconds as overhead.
 )
 
 var (
@@ -16,15 +18,15 @@ var (
 	revoloriPublicKey rsa.PublicKey
 	globalPrivateKey  rsa.PrivateKey
 
-	// Channels.
-	realDone       = make(chan returnValue, 1)
-	fakeDone       = make(chan bool, 1)
-	exchangeFailed = make(chan bool, 1)
+	// Passphrase of the stored exchanges.
+	proofPassphrase []byte
 
-	// peer search.
-	maxRetries       = 20
-	foundCorrectPeer = false
+	// Last seen addresses of listeners.
+	addressBook *p2p.AddressBook
 
-	// fake chatter.
-	fakeConnectionsAmount int32 = 0 //nolint: revive
+	// Runs the exchanges on the streams opened by createNode.
+	exchangeRequester *exchange.Requester
+
+	// peer search.
+	maxRetries = 20
 )
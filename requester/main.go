@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/rsa"
 	"fmt"
 	"log"
 	"node/constants"
@@ -8,24 +9,14 @@ import (
 	"time"
 
 	"github.com/pkg/profile"
+	"node/arbiter"
+	"node/exchange"
 	ownLog "node/logging"
+	"node/p2p"
 	"node/revolori"
+	"node/storage"
 )
 
-type returnValue struct {
-	value                  string
-	success                bool
-	searchRestarts         int
-	exchangeDuration       time.Duration
-	loadIDCardDuration     time.Duration
-	hostCreationDuration   time.Duration
-	peerSearchDuration     time.Duration
-	idVerificationDuration time.Duration
-	newUsageMsgDuration    time.Duration
-	decryptionDuration     time.Duration
-	proofDuration          time.Duration
-}
-
 // run is needed since exiting the program with os.Exit or log.Fatal* results in defer not triggering. Thus, this
 // function only returns the exit code.
 func run() int {
@@ -63,15 +54,49 @@ func run() int {
 	}
 	startUpDuration := time.Since(peerStart)
 
-	go createNode(&config, &peerStart)
+	proofPassphrase, err = storage.ProofPassphrase()
+	if err != nil {
+		ownLog.Error.Println(err)
+		return 1
+	}
 
-	ret := <-realDone
+	addressBook, err = p2p.LoadAddressBook(config.addressBook)
+	if err != nil {
+		ownLog.Error.Println(err)
+		return 1
+	}
+
+	var arbiterPublicKey *rsa.PublicKey
+	if config.arbiterKey != "" {
+		arbiterPublicKey, err = arbiter.LoadPublicKey(config.arbiterKey)
+		if err != nil {
+			ownLog.Error.Println(err)
+			return 1
+		}
+	}
+
+	// The identity cards are added by createNode once the peer ID is known
+	h, nodeRet := createNode(&config, exchange.RequesterOptions{
+		RevoloriPublicKey: revoloriPublicKey,
+		PrivateKey:        globalPrivateKey,
+		SSOID:             config.ssoid,
+		Justification:     config.justification,
+		Datum:             config.requestedDatum,
+		EnableFakeChatter: config.enableFakeChatter,
+		ProofPassphrase:   proofPassphrase,
+		AddressBook:       addressBook,
+		ArbiterPublicKey:  arbiterPublicKey,
+	})
+
+	go searchListener(&config, h, nodeRet, peerStart)
+
+	ret := <-exchangeRequester.Done()
 
 	if config.enableFakeChatter {
 		timeOut := 15 * time.Second
 
 		select {
-		case <-fakeDone:
+		case <-exchangeRequester.FakeDone():
 			// There were at least 5 fake exchanges
 		case <-time.After(timeOut):
 			// There were less than 5 fake exchanges
@@ -80,27 +105,30 @@ func run() int {
 	}
 
 	ownLog.Info.Printf("Exchange summary\n" +
-		fmt.Sprintf("\tCompleted fake exchanges: %d\n", fakeConnectionsAmount) +
-		fmt.Sprintf("\tSearch restarts: %d\n", ret.searchRestarts) +
-		fmt.Sprintf("\tDuration of entire exchange: %dms\n", ret.exchangeDuration.Milliseconds()) +
+		fmt.Sprintf("\tCompleted fake exchanges: %d\n", exchangeRequester.FakeConnections()) +
+		fmt.Sprintf("\tSearch restarts: %d\n", ret.SearchRestarts) +
+		fmt.Sprintf("\tDuration of entire exchange: %dms\n", ret.ExchangeDuration.Milliseconds()) +
 		fmt.Sprintf("\tGet Revolori's public key and create/load private key: %dms\n", startUpDuration.Milliseconds()) +
-		fmt.Sprintf("\tLoad ID duration: %dms\n", ret.loadIDCardDuration.Milliseconds()) +
-		fmt.Sprintf("\tCreate node: %dms\n", ret.hostCreationDuration.Milliseconds()) +
-		fmt.Sprintf("\tPeer search duration: %dms\n", ret.peerSearchDuration.Milliseconds()) +
-		fmt.Sprintf("\tDuration of id verification: %dms\n", ret.idVerificationDuration.Milliseconds()) +
-		fmt.Sprintf("\tDuration of the new-usage protocol: %dms\n", (ret.newUsageMsgDuration+ret.decryptionDuration).Milliseconds()) +
-		fmt.Sprintf("\t\tDuration of msg exchange + timeout: %dms\n", ret.newUsageMsgDuration.Milliseconds()) +
+		fmt.Sprintf("\tLoad ID duration: %dms\n", ret.LoadIDCardDuration.Milliseconds()) +
+		fmt.Sprintf("\tCreate node: %dms\n", ret.HostCreationDuration.Milliseconds()) +
+		fmt.Sprintf("\tPeer search duration: %dms\n", ret.PeerSearchDuration.Milliseconds()) +
+		fmt.Sprintf("\tDuration of id verification: %dms\n", ret.IDVerificationDuration.Milliseconds()) +
+		fmt.Sprintf("\tDuration of the new-usage protocol: %dms\n", (ret.NewUsageMsgDuration+ret.DecryptionDuration).Milliseconds()) +
+		fmt.Sprintf("\t\tDuration of msg exchange + timeout: %dms\n", ret.NewUsageMsgDuration.Milliseconds()) +
 		fmt.Sprintf("\t\tTimeout duration: %dms\n", constants.MaxWaitTime.Milliseconds()) +
-		fmt.Sprintf("\t\tDuration of decryption: %dms\n", ret.decryptionDuration.Milliseconds()) +
-		fmt.Sprintf("\t\tDuration of writing proof of non-repudiation: %dms", ret.proofDuration.Milliseconds()),
+		fmt.Sprintf("\t\tDuration of decryption: %dms\n", ret.DecryptionDuration.Milliseconds()) +
+		fmt.Sprintf("\t\tDuration of writing proof of non-repudiation: %dms", ret.ProofDuration.Milliseconds()),
 	)
 
-	if !ret.success {
+	if !ret.Success {
 		ownLog.Error.Printf("Exchange failed!")
+		if ret.Resolvable {
+			fmt.Println("The listener stopped the exchange early. The transcript was stored and can be resolved by the arbiter")
+		}
 		return 1
 	}
 
-	fmt.Println(ret.value)
+	fmt.Println(ret.Value)
 	return 0
 }
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+
+	"node/constants"
+)
+
+type configuration struct {
+	address string
+	key     string
+	users   string
+}
+
+func parseFlags() configuration {
+	config := configuration{}
+
+	flag.StringVar(&config.address, "address", constants.RevoloriDevAddress, "Address the server listens on. Set REVOLORI_ADDRESS of the nodes to http://<address>")
+	flag.StringVar(&config.key, "key", constants.RevoloriDevKeyFilePath, "Path of the key that signs the identity cards. It is created if it does not exist")
+	flag.StringVar(&config.users, "users", constants.RevoloriDevUsersPath, "Path of the JSON file with the users that may request a signature")
+	flag.Parse()
+
+	return config
+}
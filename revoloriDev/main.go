@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"node/revoloridev"
+)
+
+func main() {
+	config := parseFlags()
+
+	key, err := revoloridev.LoadOrCreateKey(config.key)
+	if err != nil {
+		log.Fatalf("revoloriDev/main - %s\n", err)
+	}
+
+	users, err := revoloridev.LoadUsers(config.users)
+	if err != nil {
+		log.Fatalf("revoloriDev/main - %s\n", err)
+	}
+
+	fmt.Printf("Serving /key/show and /key/sign for %d users on %s\n", len(users), config.address)
+	err = http.ListenAndServe(config.address, revoloridev.NewServer(key, users))
+	if err != nil {
+		log.Fatalf("revoloriDev/main - %s\n", err)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"os"
 	"strings"
 
+	"node/codec"
 	"node/constants"
 	"node/p2p"
 	"node/storage"
+	"node/verification"
 )
 
 const (
@@ -18,93 +20,131 @@ const (
 	judgementNotPossible = 2
 )
 
+// disputedFile is the outcome of verifying one of the files of a dispute.
+type disputedFile struct {
+	recorder  constants.MessageType
+	ssoid     string
+	decrypted string
+	err       error
+}
+
 func solveDispute(files []string, revoloriPublicKey *rsa.PublicKey) {
+	disputedFiles, out, judgement := judgeDispute(files, revoloriPublicKey)
+
+	for i, file := range disputedFiles {
+		printFileInfo(file.recorder, file.ssoid, file.err, i+1)
+	}
+
+	printJudgment(out, judgement)
+}
+
+// judgeDispute verifies the files and decides whether the exchange they were recorded in ended successfully. It
+// returns the verified files, the reasoning and the judgement. The files are only returned if both belong to the
+// same exchange.
+func judgeDispute(files []string, revoloriPublicKey *rsa.PublicKey) ([]disputedFile, string, int) {
 	err := verifyThatFilesBelongTogether(files, revoloriPublicKey)
 	if err != nil {
-		printJudgment(err.Error(), judgementNotPossible)
+		return nil, err.Error(), judgementNotPossible
 	}
 
-	firstRecorder, firstSSOID, firstDecrypted, firstErr := verifyExchange(files[0], revoloriPublicKey)
-	printFileInfo(firstRecorder, firstSSOID, firstErr, 1)
+	first := verifyDisputedFile(files[0], revoloriPublicKey)
+	second := verifyDisputedFile(files[1], revoloriPublicKey)
+	disputedFiles := []disputedFile{first, second}
 
-	secondRecorder, secondSSOID, secondDecrypted, secondErr := verifyExchange(files[1], revoloriPublicKey)
-	printFileInfo(secondRecorder, secondSSOID, secondErr, 2)
-
-	if firstRecorder == constants.MessageTypeFailure || secondRecorder == constants.MessageTypeFailure {
+	if first.recorder == constants.MessageTypeFailure || second.recorder == constants.MessageTypeFailure {
 		out := "" +
 			"At least one file failed to parse\n" +
 			"It is therefore not possible to determine whether both files belong to the same exchange\n" +
 			"=> Unable to make a decision\n"
-		printJudgment(out, judgementNotPossible)
+
+		return disputedFiles, out, judgementNotPossible
 	}
 
-	if firstRecorder == secondRecorder {
+	if first.recorder == second.recorder {
 		out := "" +
 			"The files have the same type which means that they do not belong together\n" +
 			"=> Wrong input files provided\n"
 
-		printJudgment(out, judgementNotPossible)
+		return disputedFiles, out, judgementNotPossible
 	}
 
-	if firstDecrypted != secondDecrypted {
-		out := "" +
-			"The decrypted content is not equal\n" +
-			"=> Unable to make a decision"
+	if first.err == nil && second.err == nil {
+		if first.decrypted != second.decrypted {
+			out := "" +
+				"The decrypted content is not equal\n" +
+				"=> Unable to make a decision"
 
-		printJudgment(out, judgementNotPossible)
-	}
+			return disputedFiles, out, judgementNotPossible
+		}
 
-	if firstErr == nil && secondErr == nil {
 		out := "" +
 			"Both files state that the exchange ended successfully\n" +
 			"=> Protocol ended successfully\n"
 
-		printJudgment(out, judgementSuccess)
+		return disputedFiles, out, judgementSuccess
 	}
 
-	if firstErr != nil {
+	if first.err != nil && second.err != nil {
+		out := "" +
+			"Neither file proves that the exchange ended successfully\n" +
+			"=> Protocol failed\n"
+
+		return disputedFiles, out, judgementFailure
+	}
+
+	if first.err != nil {
 		out := "" +
 			"While the first file indicates that the exchange failed, the second file proves that it ended successfully\n" +
 			"=> Protocol ended successfully\n"
 
-		printJudgment(out, judgementSuccess)
+		return disputedFiles, out, judgementSuccess
 	}
 
-	if secondErr != nil {
+	if second.err != nil {
 		out := "" +
 			"While the second file indicates that the exchange failed, the first file proves that it ended successfully\n" +
 			"=> Protocol ended successfully\n"
 
-		printJudgment(out, judgementFailure)
+		return disputedFiles, out, judgementSuccess
 	}
 
-	fmt.Println("Reached a state that should be impossible to reach")
-	fmt.Println("Debugging")
-	fmt.Printf("\tfirstRecorder: %d\n\tfirstSSOID: %s\n\tfirstErr: %s\n", firstRecorder, firstSSOID, firstErr)
-	fmt.Println("=====")
-	fmt.Printf("\tsecondRecorder: %d\n\tsecondSSOID: %s\n\tsecondErr: %s\n", secondRecorder, secondSSOID, secondErr)
-	os.Exit(judgementNotPossible)
+	out := "" +
+		"Reached a state that should be impossible to reach\n" +
+		"Debugging\n" +
+		fmt.Sprintf("\tfirstRecorder: %d\n\tfirstSSOID: %s\n\tfirstErr: %s\n", first.recorder, first.ssoid, first.err) +
+		"=====\n" +
+		fmt.Sprintf("\tsecondRecorder: %d\n\tsecondSSOID: %s\n\tsecondErr: %s\n", second.recorder, second.ssoid, second.err)
+
+	return disputedFiles, out, judgementNotPossible
+}
+
+func verifyDisputedFile(file string, revoloriPublicKey *rsa.PublicKey) disputedFile {
+	recorder, ssoid, decrypted, err := verifyExchange(file, revoloriPublicKey)
+
+	return disputedFile{recorder: recorder, ssoid: ssoid, decrypted: decrypted, err: err}
 }
 
 func verifyExchange(file string, revoloriPublicKey *rsa.PublicKey) (constants.MessageType, string, string, error) {
-	signedMessages, conversationPrivateKey, identityKey, err := storage.LoadExchange(file)
+	metadata, _, err := storage.LoadProofMetadata(file)
 	if err != nil {
 		return constants.MessageTypeFailure, "", "", err
+	} else if metadata.Role == constants.ProofRoleTranscript {
+		return constants.MessageTypeFailure, "", "", errors.New("the transcript was not resolved by the arbiter yet")
 	}
 
-	firstMessage, identityCard, err := p2p.ExtractAndVerifyMessages(signedMessages[:2], revoloriPublicKey)
+	signedMessages, conversationPublicKey, identityKey, parameters, err := storage.LoadExchangeProof(file)
 	if err != nil {
 		return constants.MessageTypeFailure, "", "", err
 	}
 
-	/**	Since the *receiving* party stores the first message the types are switched **/
-	if firstMessage.Type == constants.MessageTypeListener {
-		decrypted, errVerify := verifyRequesterSuccess(signedMessages[2:], &firstMessage.PublicKey, firstMessage.Datum)
-		return constants.MessageTypeRequester, identityCard.SSOID, decrypted, errVerify
+	switch metadata.Role {
+	case constants.ProofRoleResolved:
+		return verification.Resolved(signedMessages, &conversationPublicKey, parameters, revoloriPublicKey)
+	case constants.ProofRoleIncomplete:
+		return verification.Incomplete(signedMessages, &conversationPublicKey, &identityKey, parameters, revoloriPublicKey)
 	}
 
-	decrypted, err := verifyListenerSuccess(signedMessages[2:], &firstMessage.PublicKey, &conversationPrivateKey.PublicKey, &identityKey)
-	return constants.MessageTypeListener, identityCard.SSOID, decrypted, err
+	return verification.Exchange(signedMessages, &conversationPublicKey, &identityKey, parameters, revoloriPublicKey)
 }
 
 func verifyThatFilesBelongTogether(files []string, revoloriPublicKey *rsa.PublicKey) error {
@@ -112,22 +152,31 @@ func verifyThatFilesBelongTogether(files []string, revoloriPublicKey *rsa.Public
 		return fmt.Errorf("invalid amount of fields passed: %d", len(files))
 	}
 
-	signedMessages1, conversationPrivateKey1, _, err := storage.LoadExchange(files[0])
+	signedMessages1, conversationPublicKey1, _, parameters1, err := storage.LoadExchangeProof(files[0])
 	if err != nil {
 		return fmt.Errorf("could not load the first file: %w", err)
 	}
 
-	signedMessages2, conversationPrivateKey2, _, err := storage.LoadExchange(files[1])
+	signedMessages2, conversationPublicKey2, _, parameters2, err := storage.LoadExchangeProof(files[1])
 	if err != nil {
 		return fmt.Errorf("could not load the second file: %w", err)
 	}
 
-	firstMessage1, identityCard1, err := p2p.ExtractAndVerifyMessages(signedMessages1[:2], revoloriPublicKey)
+	if parameters1 != parameters2 {
+		return errors.New("the files were recorded with different session parameters => they do not belong together")
+	}
+
+	messageCodec, err := codec.Get(parameters1.Codec)
+	if err != nil {
+		return err
+	}
+
+	firstMessage1, identityCard1, err := p2p.ExtractAndVerifyMessages(signedMessages1[:2], revoloriPublicKey, messageCodec)
 	if err != nil {
 		return fmt.Errorf("could not parse the first file: %w", err)
 	}
 
-	firstMessage2, identityCard2, err := p2p.ExtractAndVerifyMessages(signedMessages2[:2], revoloriPublicKey)
+	firstMessage2, identityCard2, err := p2p.ExtractAndVerifyMessages(signedMessages2[:2], revoloriPublicKey, messageCodec)
 	if err != nil {
 		return fmt.Errorf("could not parse the second file: %w", err)
 	}
@@ -150,12 +199,12 @@ func verifyThatFilesBelongTogether(files []string, revoloriPublicKey *rsa.Public
 		return fmt.Errorf("could not generate the pseudonym for the second file: %w", err)
 	}
 
-	pseudonymStored1, err := storage.GeneratePseudonym(&conversationPrivateKey1.PublicKey)
+	pseudonymStored1, err := storage.GeneratePseudonym(&conversationPublicKey1)
 	if err != nil {
 		return fmt.Errorf("could not generate the pseudonym for the first file's private key: %w", err)
 	}
 
-	pseudonymStored2, err := storage.GeneratePseudonym(&conversationPrivateKey2.PublicKey)
+	pseudonymStored2, err := storage.GeneratePseudonym(&conversationPublicKey2)
 	if err != nil {
 		return fmt.Errorf("could not generate the pseudonym for the second file's private key: %w", err)
 	}
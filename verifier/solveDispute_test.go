@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"node/constants"
+	nP "node/nonRepudiation"
+	"node/simulation"
+	"node/storage"
+	"node/verification"
+)
+
+const (
+	// waitTime is raised from constants.MaxWaitTime since all nodes of the simulation share the CPU
+	waitTime = 6 * time.Second
+	// fakeRounds is the number of fake rounds of every exchange, so the real decryption data is sent in the next round
+	fakeRounds = 5
+)
+
+func _newNetwork(t *testing.T) (*simulation.Network, *simulation.ListenerNode, *simulation.RequesterNode) {
+	t.Helper()
+
+	network, err := simulation.NewNetwork(t.TempDir())
+	if err != nil {
+		t.Fatalf("Could not create network: %s\n", err)
+	}
+	t.Cleanup(network.Close)
+	network.MaxWaitTime = waitTime
+
+	rounds, err := nP.NewRounds(constants.RoundDistributionUniform, fakeRounds, fakeRounds, 1)
+	if err != nil {
+		t.Fatalf("Could not create rounds: %s\n", err)
+	}
+
+	listener, err := network.AddListener(simulation.ListenerConfig{SSOID: "alice", Rounds: rounds})
+	if err != nil {
+		t.Fatalf("Could not add listener: %s\n", err)
+	}
+
+	requester, err := network.AddRequester("bob")
+	if err != nil {
+		t.Fatalf("Could not add requester: %s\n", err)
+	}
+
+	return network, listener, requester
+}
+
+// _runExchange runs one exchange and returns the proofs stored by the listener and the requester so far.
+func _runExchange(t *testing.T, network *simulation.Network, listener *simulation.ListenerNode, requester *simulation.RequesterNode, request simulation.Request) ([]string, []string) {
+	t.Helper()
+
+	request.SSOID = listener.SSOID
+	request.Datum = "heartRate"
+	request.Justification = "Treatment"
+
+	// The result is not checked, the stored proofs are what a dispute is decided on
+	_, _ = network.Request(requester, request)
+
+	listenerProofs, err := listener.Proofs()
+	if err != nil {
+		t.Fatalf("Could not list the listener's proofs: %s\n", err)
+	}
+
+	requesterProofs, err := requester.Proofs()
+	if err != nil {
+		t.Fatalf("Could not list the requester's proofs: %s\n", err)
+	}
+
+	return listenerProofs, requesterProofs
+}
+
+func TestSolveDisputeAfterFaults(t *testing.T) {
+	realRound := fakeRounds + 1
+	proof, incomplete := constants.ProofRoleListener, constants.ProofRoleIncomplete
+
+	tests := []struct {
+		name            string
+		requesterFaults []simulation.Fault
+		listenerFaults  []simulation.Fault
+		// listenerRole is the role of the file the listener stores, if any. requesterStored is true if the requester
+		// stores a proof. The dispute is only judged if both parties stored a file
+		listenerRole    string
+		requesterStored bool
+		judgement       int
+	}{
+		{"ack delayed within the wait time", []simulation.Fault{{Message: simulation.RequesterMessage(2), Kind: simulation.FaultDelay, Delay: waitTime / 3}}, nil, proof, true, judgementSuccess},
+		{"requester withholds the ack of the real data", []simulation.Fault{{Message: simulation.RequesterMessage(realRound), Kind: simulation.FaultDrop}}, nil, incomplete, true, judgementSuccess},
+		{"listener stops after sending the real data", nil, []simulation.Fault{{Message: simulation.ListenerMessage(realRound), Kind: simulation.FaultClose}}, incomplete, true, judgementSuccess},
+		{"corrupted identity card", []simulation.Fault{{Message: 2, Kind: simulation.FaultCorrupt}}, nil, "", false, judgementNotPossible},
+		{"dropped request", []simulation.Fault{{Message: 3, Kind: simulation.FaultDrop}}, nil, "", false, judgementNotPossible},
+		{"dropped encrypted datum", nil, []simulation.Fault{{Message: simulation.ListenerMessage(0), Kind: simulation.FaultDrop}}, incomplete, false, judgementNotPossible},
+		{"connection dies in round 1", nil, []simulation.Fault{{Message: simulation.ListenerMessage(1), Kind: simulation.FaultAbort}}, incomplete, false, judgementNotPossible},
+		{"connection dies in the last fake round", nil, []simulation.Fault{{Message: simulation.ListenerMessage(fakeRounds), Kind: simulation.FaultAbort}}, incomplete, false, judgementNotPossible},
+		{"dropped real data", nil, []simulation.Fault{{Message: simulation.ListenerMessage(realRound), Kind: simulation.FaultDrop}}, incomplete, false, judgementNotPossible},
+		{"ack delayed past the wait time", []simulation.Fault{{Message: simulation.RequesterMessage(2), Kind: simulation.FaultDelay, Delay: waitTime + time.Second}}, nil, incomplete, false, judgementNotPossible},
+		{"truncated ack", []simulation.Fault{{Message: simulation.RequesterMessage(1), Kind: simulation.FaultTruncate}}, nil, incomplete, false, judgementNotPossible},
+		{"corrupted data", nil, []simulation.Fault{{Message: simulation.ListenerMessage(3), Kind: simulation.FaultCorrupt}}, incomplete, false, judgementNotPossible},
+		{"truncated data", nil, []simulation.Fault{{Message: simulation.ListenerMessage(4), Kind: simulation.FaultTruncate}}, incomplete, false, judgementNotPossible},
+	}
+
+	for _, test := range tests {
+		network, listener, requester := _newNetwork(t)
+		listenerProofs, requesterProofs := _runExchange(t, network, listener, requester, simulation.Request{RequesterFaults: test.requesterFaults, ListenerFaults: test.listenerFaults})
+
+		expectedListener, expectedRequester := 0, 0
+		if test.listenerRole != "" {
+			expectedListener = 1
+		}
+		if test.requesterStored {
+			expectedRequester = 1
+		}
+
+		if len(listenerProofs) != expectedListener || len(requesterProofs) != expectedRequester {
+			t.Errorf("TestSolveDisputeAfterFaults - %s: Expected %d and %d proofs, got %d and %d\n", test.name, expectedListener, expectedRequester, len(listenerProofs), len(requesterProofs))
+			continue
+		} else if test.listenerRole == "" {
+			continue
+		}
+
+		metadata, _, err := storage.LoadProofMetadata(listenerProofs[0])
+		if err != nil || metadata.Role != test.listenerRole {
+			t.Errorf("TestSolveDisputeAfterFaults - %s: Expected a file with role '%s', got '%s': %v\n", test.name, test.listenerRole, metadata.Role, err)
+			continue
+		}
+
+		// An incomplete exchange is verified, but never counts as successful
+		revoloriPublicKey := network.RevoloriPublicKey()
+		recorder, ssoid, _, err := verifyExchange(listenerProofs[0], &revoloriPublicKey)
+		if recorder != constants.MessageTypeListener || ssoid != requester.SSOID || (test.listenerRole == incomplete) != errors.Is(err, verification.ErrIncomplete) {
+			t.Errorf("TestSolveDisputeAfterFaults - %s: Unexpected verification of the listener's file: %d, '%s', %v\n", test.name, recorder, ssoid, err)
+		}
+
+		if !test.requesterStored {
+			continue
+		}
+
+		for _, files := range [][]string{{listenerProofs[0], requesterProofs[0]}, {requesterProofs[0], listenerProofs[0]}} {
+			_, out, judgement := judgeDispute(files, &revoloriPublicKey)
+			if judgement != test.judgement {
+				t.Errorf("TestSolveDisputeAfterFaults - %s: Expected judgement %d, got %d: %s\n", test.name, test.judgement, judgement, out)
+			}
+		}
+	}
+}
+
+func TestSolveDisputeOfMixedProofs(t *testing.T) {
+	network, listener, requester := _newNetwork(t)
+
+	_runExchange(t, network, listener, requester, simulation.Request{})
+	listenerProofs, requesterProofs := _runExchange(t, network, listener, requester, simulation.Request{})
+	if len(listenerProofs) != 2 || len(requesterProofs) != 2 {
+		t.Fatalf("TestSolveDisputeOfMixedProofs - Expected two proofs per party, got %d and %d\n", len(listenerProofs), len(requesterProofs))
+	}
+
+	revoloriPublicKey := network.RevoloriPublicKey()
+	judgements := make(map[int]int)
+
+	// Exactly one of the requester's proofs belongs to the same exchange as the listener's first proof
+	for _, requesterProof := range requesterProofs {
+		_, _, judgement := judgeDispute([]string{listenerProofs[0], requesterProof}, &revoloriPublicKey)
+		judgements[judgement]++
+
+		_, _, reversedJudgement := judgeDispute([]string{requesterProof, listenerProofs[0]}, &revoloriPublicKey)
+		if reversedJudgement != judgement {
+			t.Errorf("TestSolveDisputeOfMixedProofs - The judgement depends on the order of the files: %d, %d\n", judgement, reversedJudgement)
+		}
+	}
+
+	if judgements[judgementSuccess] != 1 || judgements[judgementNotPossible] != 1 {
+		t.Errorf("TestSolveDisputeOfMixedProofs - Unexpected judgements: %v\n", judgements)
+	}
+
+	_, _, judgement := judgeDispute(listenerProofs, &revoloriPublicKey)
+	if judgement != judgementNotPossible {
+		t.Errorf("TestSolveDisputeOfMixedProofs - Two proofs of the listener were judged %d\n", judgement)
+	}
+}